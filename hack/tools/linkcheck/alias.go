@@ -0,0 +1,83 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// resolveAlias returns the absolute path of an alias declared by a page.
+// NOTE: relative aliases are resolved from the section containing the page, as in hugo.
+func resolveAlias(p *page, alias string) string {
+	if !strings.HasPrefix(alias, "/") {
+		dir := path.Dir(p.hugoPath)
+		if path.Base(p.hugoPath) == "_index.md" {
+			dir = path.Dir(dir)
+		}
+		alias = path.Join("/", dir, alias)
+	}
+	return path.Clean(alias)
+}
+
+// checkAliasCollisions warns about aliases declared by more than one page, because they
+// collide on the published site.
+func checkAliasCollisions() {
+	owners := map[string][]*page{}
+	aliases := []string{}
+	for i := range pages {
+		p := pages[i]
+		if !p.isHugoPage || p.fatalError != nil {
+			continue
+		}
+
+		seen := map[string]bool{}
+		for _, a := range p.frontMatter.Aliases {
+			// NOTE: aliases are served under the language of the page.
+			key := fmt.Sprintf("%s:%s", p.hugoLanguage, resolveAlias(p, a))
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			if _, ok := owners[key]; !ok {
+				aliases = append(aliases, key)
+			}
+			owners[key] = append(owners[key], p)
+		}
+	}
+
+	for _, key := range aliases {
+		ps := owners[key]
+		if len(ps) < 2 {
+			continue
+		}
+
+		paths := []string{}
+		for _, p := range ps {
+			paths = append(paths, p.logPath())
+		}
+		alias := strings.SplitN(key, ":", 2)[1]
+		for _, p := range ps {
+			p.warnings = append(p.warnings, warning{message: fmt.Sprintf("alias %s is declared by more than one page: %s", alias, strings.Join(paths, ", "))})
+		}
+	}
+}
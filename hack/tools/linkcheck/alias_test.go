@@ -0,0 +1,90 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_resolveAlias(t *testing.T) {
+	cancel := setFlags("/root", "hugo", []string{"en"})
+	defer cancel()
+
+	tests := []struct {
+		name  string
+		path  string
+		alias string
+		want  string
+	}{
+		{
+			name:  "absolute alias",
+			path:  "/root/hugo/content/en/folder/test.md",
+			alias: "/old/test/",
+			want:  "/old/test",
+		},
+		{
+			name:  "relative alias",
+			path:  "/root/hugo/content/en/folder/test.md",
+			alias: "old-test",
+			want:  "/folder/old-test",
+		},
+		{
+			name:  "relative alias on an _index.md page",
+			path:  "/root/hugo/content/en/folder/_index.md",
+			alias: "old-folder",
+			want:  "/old-folder",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			p := newPage(tt.path)
+			g.Expect(resolveAlias(&p, tt.alias)).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_checkAliasCollisions(t *testing.T) {
+	g := NewWithT(t)
+
+	cancel := setFlags("/root", "hugo", []string{"en"})
+	defer cancel()
+
+	a := newPage("/root/hugo/content/en/a.md")
+	a.frontMatter.Aliases = []string{"/old", "/old-a"}
+
+	b := newPage("/root/hugo/content/en/folder/b.md")
+	b.frontMatter.Aliases = []string{"/old/"}
+
+	c := newPage("/root/hugo/content/en/c.md")
+	c.frontMatter.Aliases = []string{"/old-c", "/old-c"}
+
+	pages = []*page{&a, &b, &c}
+	defer func() { pages = nil }()
+
+	checkAliasCollisions()
+
+	g.Expect(a.warnings).To(Equal([]warning{{message: "alias /old is declared by more than one page: <site>/content/en/a.md, <site>/content/en/folder/b.md"}}))
+	g.Expect(b.warnings).To(Equal([]warning{{message: "alias /old is declared by more than one page: <site>/content/en/a.md, <site>/content/en/folder/b.md"}}))
+	g.Expect(c.warnings).To(BeEmpty())
+}
@@ -0,0 +1,61 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Search for markdown attributes at the end of a line, e.g. {#id .class key="value"}, captures the attributes.
+// NOTE: the first character of the attributes must be # or . or a letter, so shortcodes, e.g. {{< ... >}}, do not match.
+var attributesRx = regexp.MustCompile(`(?:^|[^\{])\{\s*((?:[#\.][\w\-]+|[A-Za-z][\w\-]*=(?:"[^"]*"|[^\s\}]+))(?:\s+(?:[#\.][\w\-]+|[A-Za-z][\w\-]*=(?:"[^"]*"|[^\s\}]+)))*)\s*\}\s*$`)
+
+// Search for the id in markdown attributes, captures the id value.
+var attributeIDRx = regexp.MustCompile(`(?:^|\s)#([\w\-]+)`)
+
+// readAttributeID returns the id defined by the markdown attributes at the end of a line, if any, and
+// the line without the attributes.
+func readAttributeID(line string) (id, rest string) {
+	loc := attributesRx.FindStringSubmatchIndex(line)
+	if loc == nil {
+		return "", line
+	}
+	m := attributeIDRx.FindStringSubmatch(line[loc[2]:loc[3]])
+	if m == nil {
+		return "", line
+	}
+	return m[1], strings.TrimSpace(line[:strings.LastIndex(line[:loc[2]], "{")])
+}
+
+// readBlockAttributeAnchors returns the anchors defined by markdown attributes on blocks other than headings,
+// e.g. a paragraph followed by {#id}.
+// NOTE: anchors defined by attributes on headings are read by readMarkdownAnchors.
+func readBlockAttributeAnchors(body string) (anchors []string) {
+	for _, line := range strings.Split(body, "\n") {
+		if anchorRx.MatchString(line) {
+			continue
+		}
+		if id, _ := readAttributeID(line); id != "" {
+			anchors = append(anchors, id)
+		}
+	}
+	return
+}
@@ -0,0 +1,135 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_readAttributeID(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		wantID   string
+		wantRest string
+	}{
+		{
+			name:     "no attributes",
+			line:     "A paragraph",
+			wantID:   "",
+			wantRest: "A paragraph",
+		},
+		{
+			name:     "id attribute",
+			line:     "A paragraph {#intro}",
+			wantID:   "intro",
+			wantRest: "A paragraph",
+		},
+		{
+			name:     "id attribute with class and key value",
+			line:     "A paragraph {.note #intro data-x=\"y z\"}",
+			wantID:   "intro",
+			wantRest: "A paragraph",
+		},
+		{
+			name:     "id attribute on its own line",
+			line:     "{#intro .note}",
+			wantID:   "intro",
+			wantRest: "",
+		},
+		{
+			name:     "id attribute with spaces",
+			line:     "A paragraph { #intro }",
+			wantID:   "intro",
+			wantRest: "A paragraph",
+		},
+		{
+			name:     "class only",
+			line:     "A paragraph {.note}",
+			wantID:   "",
+			wantRest: "A paragraph {.note}",
+		},
+		{
+			name:     "shortcode",
+			line:     "{{< tab name=\"#intro\" >}}",
+			wantID:   "",
+			wantRest: "{{< tab name=\"#intro\" >}}",
+		},
+		{
+			name:     "attributes not at the end of the line",
+			line:     "A {#intro} paragraph",
+			wantID:   "",
+			wantRest: "A {#intro} paragraph",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			id, rest := readAttributeID(tt.line)
+			g.Expect(id).To(Equal(tt.wantID))
+			g.Expect(rest).To(Equal(tt.wantRest))
+		})
+	}
+}
+
+func Test_readMarkdownPage_attributes(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	path := filepath.Join(root, "hugo", contentFolder, "en/test.md")
+	write(g, path, `# Test
+
+## Custom heading {#custom}
+
+A paragraph with an id.
+{#paragraph .note}
+
+A list item {#item}
+
+See [custom](#custom), [paragraph](#paragraph), [item](#item) or [heading](#custom-heading).
+`)
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	addPage(readMarkdownPage(path))
+	linkcheckPage(context.Background(), path)
+
+	g.Expect(pagesByPath[path].anchors).To(Equal([]string{"test", "custom", "paragraph", "item"}))
+	g.Expect(collectErrors(root)).To(Equal([]string{
+		"/hugo/content/en/test.md:10: #custom-heading: #custom-heading does exists in <site>/content/en/test.md",
+	}))
+}
@@ -0,0 +1,46 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Search for bare http and https urls in text, e.g. https://example.com.
+var bareURLRx = regexp.MustCompile(`https?://[^\s<>"'\x60\(\)\[\]]+`)
+
+// Search for the parts of a line where urls are not bare urls: inline code, markdown links and images,
+// reference links and html attributes.
+var notBareURLRx = regexp.MustCompile("`[^`]*`|\\]\\([^\\)]*\\)|^(?:\\s*>)*\\s*\\[[^\\]]+\\]:\\s+\\S+|\\w+=\"[^\"]*\"|\\w+='[^']*'")
+
+// Search for code fences, e.g. ``` or ~~~.
+var codeFenceRx = regexp.MustCompile("^\\s*(?:```|~~~)")
+
+// readBareURLs returns the bare urls in a line, that hugo renders as links thanks to autolinking.
+// NOTE: trailing punctuation is not considered part of the url, e.g. in "see https://example.com.".
+func readBareURLs(line string) (urls []string) {
+	line = notBareURLRx.ReplaceAllString(line, " ")
+	for _, u := range bareURLRx.FindAllString(line, -1) {
+		u = strings.TrimRight(u, ".,;:!?*_")
+		urls = append(urls, u)
+	}
+	return
+}
@@ -0,0 +1,112 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_readBareURLs(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want []string
+	}{
+		{
+			name: "bare url in a paragraph",
+			line: "See https://example.com/docs for more details.",
+			want: []string{"https://example.com/docs"},
+		},
+		{
+			name: "bare url at the end of a sentence",
+			line: "See http://example.com.",
+			want: []string{"http://example.com"},
+		},
+		{
+			name: "autolink",
+			line: "See <https://example.com/docs>.",
+			want: []string{"https://example.com/docs"},
+		},
+		{
+			name: "markdown link",
+			line: "See [docs](https://example.com/docs) and ![logo](https://example.com/logo.png).",
+			want: nil,
+		},
+		{
+			name: "markdown link with the url as text",
+			line: "See [https://example.com/docs](https://example.com/docs).",
+			want: []string{"https://example.com/docs"},
+		},
+		{
+			name: "reference link",
+			line: "[docs]: https://example.com/docs",
+			want: nil,
+		},
+		{
+			name: "inline code",
+			line: "Run `curl https://example.com/install.sh`.",
+			want: nil,
+		},
+		{
+			name: "html attribute",
+			line: `<a href="https://example.com/docs">docs</a>`,
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(readBareURLs(tt.line)).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_readMarkdownPage_bareURLs(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	checkBareURLsBefore := *checkBareURLs
+	defer func() { *checkBareURLs = checkBareURLsBefore }()
+
+	path := filepath.Join(root, "hugo", contentFolder, "en/test.md")
+	write(g, path, "# Test\n\nSee https://example.com/docs and [home](https://example.com).\n\n```bash\ncurl https://example.com/install.sh\n```\n")
+
+	*checkBareURLs = false
+	p := readMarkdownPage(path)
+	g.Expect(p.links).To(HaveLen(1))
+
+	*checkBareURLs = true
+	p = readMarkdownPage(path)
+	g.Expect(p.links).To(HaveLen(2))
+	g.Expect(p.links[1].rawLink).To(Equal("https://example.com/docs"))
+	g.Expect(p.links[1].lineNumber).To(Equal(3))
+	g.Expect(p.links[1].URL).To(Equal(mustParseUrl("https://example.com/docs")))
+}
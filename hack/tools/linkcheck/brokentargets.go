@@ -0,0 +1,81 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// brokenTarget defines a missing file targeted by links, with the number of links pointing at it.
+type brokenTarget struct {
+	// path of the missing file, relative to root.
+	path string
+
+	// links is the number of links pointing at the missing file.
+	links int
+}
+
+// computeBrokenTargets returns the missing files targeted by links, ranked by the number of links pointing at them,
+// e.g. a renamed page linked from many pages comes first; only the first n targets are returned.
+func computeBrokenTargets(n int) []brokenTarget {
+	linksByPath := map[string]int{}
+	for i := range pages {
+		for _, l := range pages[i].links {
+			if l.fatalError == nil || l.fatalError.category != missingFileErrorCategory || severityFor(l.fatalError.category) == ignoreSeverity || l.URL == nil {
+				continue
+			}
+			linksByPath[strings.TrimPrefix(l.URL.Path, *root)]++
+		}
+	}
+
+	targets := make([]brokenTarget, 0, len(linksByPath))
+	for path, links := range linksByPath {
+		targets = append(targets, brokenTarget{path: path, links: links})
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].links != targets[j].links {
+			return targets[i].links > targets[j].links
+		}
+		return targets[i].path < targets[j].path
+	})
+	if len(targets) > n {
+		targets = targets[:n]
+	}
+	return targets
+}
+
+// printBrokenTargets prints the missing files targeted by the most links, so the breakages with the highest impact
+// can be fixed first.
+func printBrokenTargets(w io.Writer, n int) {
+	targets := computeBrokenTargets(n)
+	if len(targets) == 0 {
+		return
+	}
+
+	s := "TOP BROKEN TARGETS\n"
+	s += fmt.Sprintf("      %d targets\n\n", len(targets))
+	for _, t := range targets {
+		s += fmt.Sprintf(" - %d links: %s\n", t.links, t.path)
+	}
+	fmt.Fprintf(w, "%s\n", s)
+}
@@ -0,0 +1,78 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_readAllAndLinkcheckAll_brokenTargets(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	write(g, filepath.Join(root, "hugo", contentFolder, "en/_index.md"), `# Home
+
+See [renamed](/renamed), [renamed again](/renamed#section), [moved](/moved) or [page](/page#missing).
+`)
+	write(g, filepath.Join(root, "hugo", contentFolder, "en/a.md"), "# A\n\nSee [renamed](renamed) or [moved](/moved).\n")
+	write(g, filepath.Join(root, "hugo", contentFolder, "en/b.md"), "# B\n\nSee [renamed](/renamed) or [gone](/gone).\n")
+	write(g, filepath.Join(root, "hugo", contentFolder, "en/page.md"), "# Page\n")
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+
+	// Missing files are ranked by the number of links pointing at them, then by path; missing anchors are not included.
+	g.Expect(computeBrokenTargets(10)).To(Equal([]brokenTarget{
+		{path: "/hugo/content/en/renamed.md", links: 4},
+		{path: "/hugo/content/en/moved.md", links: 2},
+		{path: "/hugo/content/en/gone.md", links: 1},
+	}))
+	g.Expect(computeBrokenTargets(2)).To(HaveLen(2))
+
+	var out bytes.Buffer
+	printBrokenTargets(&out, 2)
+	g.Expect(out.String()).To(Equal(`TOP BROKEN TARGETS
+      2 targets
+
+ - 4 links: /hugo/content/en/renamed.md
+ - 2 links: /hugo/content/en/moved.md
+
+`))
+}
@@ -0,0 +1,241 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// extractedLink is an item of an extraction for a link, e.g. [text](addr).
+	extractedLink = "link"
+
+	// extractedImage is an item of an extraction for an image, e.g. ![alt](addr).
+	extractedImage = "image"
+
+	// extractedWarning is an item of an extraction for a warning.
+	extractedWarning = "warning"
+)
+
+// extraction defines what is extracted from the content of a markdown page, before resolving links.
+// NOTE: links, images and warnings are kept in a single list, so they are applied to the page in the same order
+// they are extracted, no matter if the extraction comes from the --cache-file.
+type extraction struct {
+	FrontMatter frontMatter         `json:"frontMatter"`
+	Terms       map[string][]string `json:"terms,omitempty"`
+	Anchors     []string            `json:"anchors,omitempty"`
+	TabAnchors  []string            `json:"tabAnchors,omitempty"`
+	Items       []extractedItem     `json:"items,omitempty"`
+}
+
+// extractedItem defines a link, an image or a warning extracted from a markdown page.
+// NOTE: for warnings, Value is the message and Link is the raw link the warning is about, if any.
+type extractedItem struct {
+	Kind       string `json:"kind"`
+	Value      string `json:"value"`
+	LineNumber int    `json:"line,omitempty"`
+	Link       string `json:"link,omitempty"`
+}
+
+func (e *extraction) addLink(l string, lineNumber int) {
+	e.Items = append(e.Items, extractedItem{Kind: extractedLink, Value: l, LineNumber: lineNumber})
+}
+
+func (e *extraction) addImage(i string, lineNumber int) {
+	e.Items = append(e.Items, extractedItem{Kind: extractedImage, Value: i, LineNumber: lineNumber})
+}
+
+func (e *extraction) addWarnings(warnings ...warning) {
+	for _, w := range warnings {
+		e.Items = append(e.Items, extractedItem{Kind: extractedWarning, Value: w.message, LineNumber: w.lineNumber, Link: w.rawLink})
+	}
+}
+
+// applyExtraction sets the front matter, the anchors and the warnings extracted from the page, and resolves its links.
+func (p *page) applyExtraction(e *extraction) {
+	p.frontMatter = e.FrontMatter
+	p.terms = e.Terms
+	p.anchors = e.Anchors
+	p.tabAnchors = e.TabAnchors
+	for _, i := range e.Items {
+		switch i.Kind {
+		case extractedLink:
+			p.addLink(i.Value, i.LineNumber)
+		case extractedImage:
+			p.addImage(i.Value, i.LineNumber)
+		case extractedWarning:
+			p.warnings = append(p.warnings, warning{lineNumber: i.LineNumber, rawLink: i.Link, message: i.Value})
+		}
+	}
+}
+
+// pageCache defines the content of the --cache-file.
+type pageCache struct {
+	// Settings are the flags of the run writing the cache which affect extractions, see cacheSettingsFlags; the
+	// cache is discarded when they change.
+	Settings string `json:"settings"`
+
+	// Pages contains the extraction of each page, by path.
+	Pages map[string]cachedPage `json:"pages"`
+}
+
+// cachedPage defines the extraction of a page, together with the hash of the content it was extracted from.
+type cachedPage struct {
+	Hash       string      `json:"hash"`
+	Extraction *extraction `json:"extraction"`
+}
+
+var (
+	// cachedPages contains the extraction of the pages read from the --cache-file, if any.
+	cachedPages map[string]cachedPage
+
+	// extractedPages contains the extraction of the pages read in this run, to be written to the --cache-file.
+	extractedPages map[string]cachedPage
+
+	// pageCacheLock protects cachedPages and extractedPages.
+	pageCacheLock sync.Mutex
+
+	// pageCacheHits is the number of pages whose extraction has been read from the --cache-file.
+	pageCacheHits int
+)
+
+// cacheSettingsFlags are the flags affecting the extraction of pages, e.g. --skip-begin-marker; only those flags are
+// written to the --cache-file, so the cache is not discarded when other flags change, e.g. -v or --workers, and secrets,
+// e.g. --github-token, are not written to disk.
+// NOTE: flags affecting how links are resolved and checked are not included, because links are resolved on each run.
+var cacheSettingsFlags = []string{
+	"check-bare-urls",
+	"check-yaml-comments",
+	"code-fence-anchors",
+	"forbid-link-text",
+	"heading-shortcodes",
+	"link-shortcodes",
+	"list-item-anchors",
+	"max-line-length",
+	"require-single-h1",
+	"reserved-anchors",
+	"skip-begin-marker",
+	"skip-end-marker",
+	"tab-headings",
+	"tab-shortcodes",
+}
+
+// cacheSettings returns the flags of the run which affect extractions, e.g. --skip-begin-marker=<!-- BEGIN -->.
+// NOTE: the taxonomies are read from the hugo website config, so they are included too.
+func cacheSettings() string {
+	settings := []string{}
+	for _, name := range cacheSettingsFlags {
+		settings = append(settings, fmt.Sprintf("--%s=%s", name, pflag.Lookup(name).Value.String()))
+	}
+	settings = append(settings, fmt.Sprintf("taxonomies=%s", strings.Join(taxonomies, ",")))
+	return strings.Join(settings, " ")
+}
+
+// contentHash returns the hash of the content of a page.
+func contentHash(content []byte) string {
+	h := sha256.Sum256(content)
+	return hex.EncodeToString(h[:])
+}
+
+// loadPageCache reads the extraction of the pages from the --cache-file, if any.
+// NOTE: a missing cache file, or a cache file written with different settings, is ignored.
+func loadPageCache() error {
+	pageCacheLock.Lock()
+	defer pageCacheLock.Unlock()
+
+	cachedPages = nil
+	extractedPages = nil
+	pageCacheHits = 0
+	if *cacheFile == "" {
+		return nil
+	}
+	extractedPages = map[string]cachedPage{}
+
+	content, err := os.ReadFile(*cacheFile)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	c := pageCache{}
+	if err := json.Unmarshal(content, &c); err != nil {
+		return errors.Wrapf(err, "failed to parse %s", *cacheFile)
+	}
+	if c.Settings != cacheSettings() {
+		debugf("cache file %s written with different settings, not used", *cacheFile)
+		return nil
+	}
+	cachedPages = c.Pages
+	return nil
+}
+
+// savePageCache writes the extraction of the pages read in this run to the --cache-file, if any.
+// NOTE: pages not read in this run, e.g. deleted pages, are dropped from the cache.
+func savePageCache() error {
+	pageCacheLock.Lock()
+	defer pageCacheLock.Unlock()
+
+	if *cacheFile == "" {
+		return nil
+	}
+	content, err := json.Marshal(pageCache{Settings: cacheSettings(), Pages: extractedPages})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(*cacheFile, content, 0600)
+}
+
+// cachedExtraction returns the extraction of a page from the --cache-file, if the content of the page is unchanged.
+func cachedExtraction(path string, content []byte) (*extraction, bool) {
+	pageCacheLock.Lock()
+	defer pageCacheLock.Unlock()
+
+	c, ok := cachedPages[path]
+	if !ok || c.Extraction == nil || c.Hash != contentHash(content) {
+		return nil, false
+	}
+	if extractedPages != nil {
+		extractedPages[path] = c
+	}
+	pageCacheHits++
+	debugf("%s: unchanged, extraction read from the cache", path)
+	return c.Extraction, true
+}
+
+// cacheExtraction stores the extraction of a page, to be written to the --cache-file.
+func cacheExtraction(path string, content []byte, e *extraction) {
+	pageCacheLock.Lock()
+	defer pageCacheLock.Unlock()
+
+	if extractedPages == nil {
+		return
+	}
+	extractedPages[path] = cachedPage{Hash: contentHash(content), Extraction: e}
+}
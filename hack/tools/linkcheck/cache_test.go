@@ -0,0 +1,189 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/pflag"
+)
+
+func Test_readAllAndLinkcheckAll_cacheFile(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	cacheFileBefore, checkBareURLsBefore, forbidLinkTextBefore := *cacheFile, *checkBareURLs, *forbidLinkText
+	defer func() {
+		*cacheFile, *checkBareURLs, *forbidLinkText = cacheFileBefore, checkBareURLsBefore, forbidLinkTextBefore
+		g.Expect(loadPageCache()).To(Succeed())
+	}()
+	*cacheFile = filepath.Join(root, "cache.json")
+	*forbidLinkText = []string{"here"}
+
+	indexPath := filepath.Join(root, "hugo", contentFolder, "en/_index.md")
+	pagePath := filepath.Join(root, "hugo", contentFolder, "en/page.md")
+	write(g, indexPath, `---
+title: Home
+aliases: [/home]
+---
+# Home
+
+See [page](/page#section), [missing](/missing) or https://example.com.
+![logo](missing.png)
+`)
+	write(g, pagePath, "# Page\n\n## Section\n\nSee [here](/).\n")
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	run := func() map[string]page {
+		pages = nil
+		pagesByPath = nil
+		g.Expect(loadPageCache()).To(Succeed())
+		g.Expect(readAll()).To(Succeed())
+		g.Expect(savePageCache()).To(Succeed())
+		g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+
+		result := map[string]page{}
+		for path, p := range pagesByPath {
+			result[path] = *p
+		}
+		return result
+	}
+
+	// Without a cache file, pages are parsed.
+	want := run()
+	g.Expect(pageCacheHits).To(Equal(0))
+	g.Expect(pagesByPath[indexPath].links).To(HaveLen(3))
+	g.Expect(pagesByPath[pagePath].warnings).To(Equal([]warning{
+		{lineNumber: 5, rawLink: "/", message: `link text "here" does not describe the target of the link, e.g. for screen readers`},
+	}))
+
+	// Unchanged pages are not parsed again, and they give the same result.
+	g.Expect(run()).To(Equal(want))
+	g.Expect(pageCacheHits).To(Equal(2))
+
+	// Changed pages are parsed again.
+	write(g, pagePath, "# Page\n\n## Section\n\nSee [home](/) and [index](/).\n")
+	got := run()
+	g.Expect(pageCacheHits).To(Equal(1))
+	g.Expect(got[indexPath]).To(Equal(want[indexPath]))
+	g.Expect(got[pagePath].links).To(HaveLen(2))
+	g.Expect(got[pagePath].warnings).To(BeEmpty())
+
+	// Links of unchanged pages are resolved again, because targets could be changed.
+	g.Expect(os.Remove(pagePath)).To(Succeed())
+	got = run()
+	g.Expect(pageCacheHits).To(Equal(1))
+	g.Expect(collectErrors(root)).To(ContainElement("/hugo/content/en/_index.md:7: /page#section: the link resolves to /hugo/content/en/page.md which does not exist"))
+
+	// A cache written with different settings is not used.
+	write(g, pagePath, "# Page\n\n## Section\n")
+	*checkBareURLs = true
+	got = run()
+	g.Expect(pageCacheHits).To(Equal(0))
+	g.Expect(got[indexPath].links).To(HaveLen(4))
+	run()
+	g.Expect(pageCacheHits).To(Equal(2))
+}
+
+func Test_loadPageCache(t *testing.T) {
+	g := NewWithT(t)
+
+	dir, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(dir)
+
+	cacheFileBefore := *cacheFile
+	defer func() {
+		*cacheFile = cacheFileBefore
+		g.Expect(loadPageCache()).To(Succeed())
+	}()
+
+	// A missing cache file is ignored.
+	*cacheFile = filepath.Join(dir, "cache.json")
+	g.Expect(loadPageCache()).To(Succeed())
+	g.Expect(cachedPages).To(BeNil())
+
+	write(g, *cacheFile, "{")
+	g.Expect(loadPageCache()).ToNot(Succeed())
+}
+
+func Test_cacheSettings(t *testing.T) {
+	g := NewWithT(t)
+
+	for _, name := range cacheSettingsFlags {
+		g.Expect(pflag.Lookup(name)).ToNot(BeNil(), name)
+	}
+
+	workersBefore, githubTokenBefore, skipBeginMarkerBefore := *workers, *githubToken, *skipBeginMarker
+	defer func() {
+		*workers, *githubToken, *skipBeginMarker = workersBefore, githubTokenBefore, skipBeginMarkerBefore
+	}()
+
+	// Flags not affecting extractions do not change the settings.
+	settings := cacheSettings()
+	*workers = workersBefore + 1
+	*githubToken = "secret-token"
+	g.Expect(cacheSettings()).To(Equal(settings))
+
+	*skipBeginMarker = "<!-- BEGIN -->"
+	g.Expect(cacheSettings()).ToNot(Equal(settings))
+	g.Expect(cacheSettings()).To(ContainSubstring("--skip-begin-marker=<!-- BEGIN -->"))
+}
+
+func Test_savePageCache_githubToken(t *testing.T) {
+	g := NewWithT(t)
+
+	dir, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(dir)
+
+	cacheFileBefore, githubTokenBefore := *cacheFile, *githubToken
+	defer func() {
+		*cacheFile, *githubToken = cacheFileBefore, githubTokenBefore
+		g.Expect(loadPageCache()).To(Succeed())
+	}()
+	*cacheFile = filepath.Join(dir, "cache.json")
+	*githubToken = "secret-token"
+
+	g.Expect(loadPageCache()).To(Succeed())
+	g.Expect(savePageCache()).To(Succeed())
+
+	content, err := os.ReadFile(*cacheFile)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(content)).ToNot(ContainSubstring("secret-token"))
+	g.Expect(string(content)).ToNot(ContainSubstring("github-token"))
+}
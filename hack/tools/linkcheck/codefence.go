@@ -0,0 +1,67 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Search for the title of code fences, e.g. ```go title="main.go", captures the title, quoted or not.
+var codeFenceTitleRx = regexp.MustCompile("^\\s*(?:```|~~~).*\\btitle=(?:\"([^\"]*)\"|'([^']*)'|([^\\s}]+))")
+
+// validateCodeFenceAnchors checks the --code-fence-anchors format has exactly one %s verb for the title of the code fence.
+func validateCodeFenceAnchors(format string) error {
+	if format == "" {
+		return nil
+	}
+	if strings.Count(format, "%") != 1 || !strings.Contains(format, "%s") {
+		return errors.Errorf("invalid code fence anchors format %q, must contain %%s exactly once, e.g. code-%%s", format)
+	}
+	return nil
+}
+
+// readCodeFenceAnchors returns the anchors assigned by the theme to titled code fences, using the --code-fence-anchors
+// format with the anchor generated from the title as for headings, e.g. code-main.go for ```go title="main.go".
+func readCodeFenceAnchors(body string) (anchors []string) {
+	if *codeFenceAnchors == "" {
+		return
+	}
+
+	inCodeFence := false
+	for _, line := range strings.Split(body, "\n") {
+		if !codeFenceRx.MatchString(line) {
+			continue
+		}
+		inCodeFence = !inCodeFence
+		if !inCodeFence {
+			continue
+		}
+		if m := codeFenceTitleRx.FindStringSubmatch(line); m != nil {
+			if title := m[1] + m[2] + m[3]; strings.TrimSpace(title) != "" {
+				anchors = append(anchors, fmt.Sprintf(*codeFenceAnchors, anchorFromHeading(title)))
+			}
+		}
+	}
+	return
+}
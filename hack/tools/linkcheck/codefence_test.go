@@ -0,0 +1,92 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_validateCodeFenceAnchors(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(validateCodeFenceAnchors("")).To(Succeed())
+	g.Expect(validateCodeFenceAnchors("%s")).To(Succeed())
+	g.Expect(validateCodeFenceAnchors("code-%s")).To(Succeed())
+	g.Expect(validateCodeFenceAnchors("code")).ToNot(Succeed())
+	g.Expect(validateCodeFenceAnchors("code-%d")).ToNot(Succeed())
+	g.Expect(validateCodeFenceAnchors("%s-code-%s")).ToNot(Succeed())
+}
+
+func Test_readCodeFenceAnchors(t *testing.T) {
+	g := NewWithT(t)
+
+	codeFenceAnchorsBefore := *codeFenceAnchors
+	defer func() { *codeFenceAnchors = codeFenceAnchorsBefore }()
+
+	body := "# Tutorial\n\n```go title=\"main.go\"\npackage main\n```\n\n~~~yaml {title='Cluster Config'}\nkind: Cluster\n~~~\n\n" +
+		"```sh title=install.sh\n```\n\n```go\n```\n\n```go title=\"\"\n```\n"
+
+	*codeFenceAnchors = ""
+	g.Expect(readCodeFenceAnchors(body)).To(BeEmpty())
+
+	*codeFenceAnchors = "%s"
+	g.Expect(readCodeFenceAnchors(body)).To(Equal([]string{"main.go", "cluster-config", "install.sh"}))
+
+	*codeFenceAnchors = "code-%s"
+	g.Expect(readCodeFenceAnchors(body)).To(Equal([]string{"code-main.go", "code-cluster-config", "code-install.sh"}))
+}
+
+func Test_readAllAndLinkcheckAll_codeFenceAnchors(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	write(g, filepath.Join(root, "hugo", contentFolder, "en/_index.md"), "# Tutorial\n\n"+
+		"```go title=\"main.go\"\npackage main\n```\n\n"+
+		"Edit [main.go](#code-main.go); there is no [go.mod](#code-go.mod).\n")
+
+	codeFenceAnchorsBefore := *codeFenceAnchors
+	defer func() { *codeFenceAnchors = codeFenceAnchorsBefore }()
+	*codeFenceAnchors = "code-%s"
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+	g.Expect(collectErrors(root)).To(Equal([]string{
+		"/hugo/content/en/_index.md:7: #code-go.mod: #code-go.mod does exists in <site>/content/en/_index.md",
+	}))
+}
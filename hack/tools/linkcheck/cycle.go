@@ -0,0 +1,114 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// reportIndexCycles warns about cycles among _index.md pages, e.g. a folder page linking to a sub folder whose page
+// links back to the folder, which create navigation loops; each cycle is reported once, on its first page by path.
+// NOTE: cycles are detected with a depth first visit of the links among _index.md pages, so at least one cycle is
+// reported for each group of pages linking to each other, but not all the cycles in the group.
+// NOTE: cycles are known only once all the pages are checked, so when streaming they are printed with the report.
+func reportIndexCycles() {
+	// Collect the links among _index.md pages, ignoring links with errors and links to the page itself, e.g. #anchor.
+	edges := map[string][]string{}
+	nodes := []string{}
+	for i := range pages {
+		p := pages[i]
+		if !isIndexPage(p.path) || p.fatalError != nil {
+			continue
+		}
+		nodes = append(nodes, p.path)
+		for _, l := range p.links {
+			if l.fatalError != nil || l.URL == nil || l.URL.Scheme != "" || l.URL.Path == p.path || !isIndexPage(l.URL.Path) {
+				continue
+			}
+			if _, ok := pagesByPath[l.URL.Path]; !ok || containsString(edges[p.path], l.URL.Path) {
+				continue
+			}
+			edges[p.path] = append(edges[p.path], l.URL.Path)
+		}
+	}
+	sort.Strings(nodes)
+	for _, n := range nodes {
+		sort.Strings(edges[n])
+	}
+
+	visited := map[string]bool{}
+	onStack := map[string]bool{}
+	stack := []string{}
+	var visit func(n string)
+	visit = func(n string) {
+		visited[n] = true
+		onStack[n] = true
+		stack = append(stack, n)
+		for _, m := range edges[n] {
+			if onStack[m] {
+				// The link closes a cycle, from the page m in the stack to n and back to m.
+				for i := range stack {
+					if stack[i] == m {
+						reportIndexCycle(append(append([]string{}, stack[i:]...), m))
+						break
+					}
+				}
+				continue
+			}
+			if !visited[m] {
+				visit(m)
+			}
+		}
+		stack = stack[:len(stack)-1]
+		onStack[n] = false
+	}
+	for _, n := range nodes {
+		if !visited[n] {
+			visit(n)
+		}
+	}
+}
+
+// reportIndexCycle adds the warning about a cycle, e.g. a, b, a, to the first page of the cycle by path.
+func reportIndexCycle(cycle []string) {
+	// Rotate the cycle to start from the first page by path, so the same cycle is always reported in the same way.
+	first := 0
+	for i := range cycle[:len(cycle)-1] {
+		if cycle[i] < cycle[first] {
+			first = i
+		}
+	}
+	rotated := append(append([]string{}, cycle[first:len(cycle)-1]...), cycle[:first+1]...)
+
+	logPaths := []string{}
+	for _, path := range rotated {
+		logPaths = append(logPaths, pagesByPath[path].logPath())
+	}
+	p := pagesByPath[rotated[0]]
+	p.warnings = append(p.warnings, warning{message: fmt.Sprintf("_index.md pages link to each other in a navigation cycle: %s", strings.Join(logPaths, " -> "))})
+}
+
+// isIndexPage returns true if a path is the _index.md page of a folder.
+func isIndexPage(path string) bool {
+	return filepath.Base(path) == "_index.md"
+}
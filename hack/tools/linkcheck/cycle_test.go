@@ -0,0 +1,117 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_reportIndexCycles(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	contentDir := filepath.Join(root, "hugo", contentFolder, "en")
+	write(g, filepath.Join(contentDir, "_index.md"), "# Home\n\nSee [tasks](/tasks) and [reference](/reference).\n")
+	write(g, filepath.Join(contentDir, "tasks/_index.md"), "# Tasks\n\nSee [upgrade](upgrade), [install](#install) or go back to [reference](/reference).\n\n## Install\n")
+	write(g, filepath.Join(contentDir, "tasks/upgrade.md"), "# Upgrade\n\nBack to [tasks](/tasks).\n")
+	write(g, filepath.Join(contentDir, "reference/_index.md"), "# Reference\n\nSee [tasks](/tasks) or [api](api).\n")
+	write(g, filepath.Join(contentDir, "reference/api/_index.md"), "# API\n\nBack to the [home](/).\n")
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+	g.Expect(collectErrors(root)).To(BeEmpty())
+
+	reportIndexCycles()
+
+	// The two-page cycle between reference and tasks is reported once, as well as the cycle from the home to
+	// reference/api and back; links to pages other than _index.md pages, e.g. upgrade, are not considered.
+	g.Expect(pagesByPath[filepath.Join(contentDir, "_index.md")].warnings).To(Equal([]warning{
+		{message: "_index.md pages link to each other in a navigation cycle: <site>/content/en/_index.md -> <site>/content/en/reference/_index.md -> <site>/content/en/reference/api/_index.md -> <site>/content/en/_index.md"},
+	}))
+	g.Expect(pagesByPath[filepath.Join(contentDir, "reference/_index.md")].warnings).To(Equal([]warning{
+		{message: "_index.md pages link to each other in a navigation cycle: <site>/content/en/reference/_index.md -> <site>/content/en/tasks/_index.md -> <site>/content/en/reference/_index.md"},
+	}))
+	g.Expect(pagesByPath[filepath.Join(contentDir, "tasks/_index.md")].warnings).To(BeEmpty())
+	g.Expect(pagesByPath[filepath.Join(contentDir, "tasks/upgrade.md")].warnings).To(BeEmpty())
+}
+
+func Test_reportIndexCycles_stream(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	streamBefore := *stream
+	defer func() { *stream = streamBefore }()
+	*stream = true
+
+	contentDir := filepath.Join(root, "hugo", contentFolder, "en")
+	write(g, filepath.Join(contentDir, "_index.md"), "# Home\n\nSee [tasks](/tasks).\n")
+	write(g, filepath.Join(contentDir, "tasks/_index.md"), "# Tasks\n\nBack to the [home](/).\n")
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	g.Expect(readAll()).To(Succeed())
+
+	var out strings.Builder
+	g.Expect(linkcheckAll(context.Background(), &out)).To(Succeed())
+	g.Expect(out.String()).To(BeEmpty())
+
+	reportIndexCycles()
+	g.Expect(printReport(&out)).To(Succeed())
+
+	// Cycles are detected after the pages have been streamed, so they are printed with the report.
+	g.Expect(out.String()).To(Equal(`
+PAGE: <site>/content/en/_index.md
+
+ - WARNING: _index.md pages link to each other in a navigation cycle: <site>/content/en/_index.md -> <site>/content/en/tasks/_index.md -> <site>/content/en/_index.md
+
+Total page processed: 2 links: 2 anchors: 2 
+`))
+}
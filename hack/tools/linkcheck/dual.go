@@ -0,0 +1,85 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// dualValidateAll warns about internal links that work only on the hugo website or only when
+// the markdown is browsed in the source repository, e.g. on GitHub.
+// NOTE: on the website links are resolved by hugo (no .md extension, folders for _index.md), while in the
+// source repository links are resolved as relative paths to files.
+// NOTE: links must be checked before, so when streaming the warnings are printed with the report.
+func dualValidateAll() {
+	for i := range pages {
+		p := pages[i]
+		if !p.isHugoPage || p.fatalError != nil {
+			continue
+		}
+		for _, l := range p.links {
+			if l.isImage || (l.URL != nil && l.URL.Scheme != "") {
+				continue
+			}
+
+			worksOnSite := worksOnSite(l)
+			worksOnRepo := worksOnRepo(p, l)
+			switch {
+			case worksOnSite && !worksOnRepo:
+				p.warnings = append(p.warnings, warning{lineNumber: l.lineNumber, rawLink: l.rawLink, message: "the link works on the website but not in the source repository"})
+			case !worksOnSite && worksOnRepo:
+				p.warnings = append(p.warnings, warning{lineNumber: l.lineNumber, rawLink: l.rawLink, message: "the link works in the source repository but not on the website"})
+			}
+		}
+	}
+}
+
+// worksOnSite returns true if the link resolves to a file on the hugo website.
+// NOTE: anchors and publishing state are not considered, because they are not checked in the source repository.
+func worksOnSite(l link) bool {
+	if l.fatalError == nil {
+		return true
+	}
+	switch l.fatalError.category {
+	case invalidLinkErrorCategory, forbiddenLinkErrorCategory, missingFileErrorCategory:
+		return false
+	}
+	return true
+}
+
+// worksOnRepo returns true if the link resolves to a file when the markdown is browsed in the source repository.
+// NOTE: absolute links are resolved from the root of the repository.
+func worksOnRepo(p *page, l link) bool {
+	path, _ := splitPathAndFragment(l.rawLink)
+	if path == "" {
+		return true
+	}
+	if strings.HasPrefix(path, "{{") {
+		return false
+	}
+
+	target := filepath.Join(filepath.Dir(p.path), path)
+	if filepath.IsAbs(path) {
+		target = filepath.Join(*root, path)
+	}
+	_, err := statFile(target)
+	return err == nil
+}
@@ -0,0 +1,117 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_dualValidateAll(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	contentDir := filepath.Join(root, "hugo", contentFolder)
+	touch(g, filepath.Join(contentDir, "en/_index.md"))
+	write(g, filepath.Join(contentDir, "en/docs/page.md"), "# Page\n")
+	write(g, filepath.Join(contentDir, "en/docs/_index.md"), `# Docs
+
+Works on the website only: [page](page).
+Works in the repository only: [page](page.md).
+Works in both: [page](#docs), [pdf](files/doc.pdf).
+Works in none: [missing](missing).
+`)
+	touch(g, filepath.Join(contentDir, "en/docs/files/doc.pdf"))
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+
+	dualValidateAll()
+
+	p := pagesByPath[filepath.Join(contentDir, "en/docs/_index.md")]
+	g.Expect(p.warnings).To(Equal([]warning{
+		{lineNumber: 3, rawLink: "page", message: "the link works on the website but not in the source repository"},
+		{lineNumber: 4, rawLink: "page.md", message: "the link works in the source repository but not on the website"},
+	}))
+}
+
+func Test_dualValidateAll_stream(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	streamBefore := *stream
+	defer func() { *stream = streamBefore }()
+	*stream = true
+
+	contentDir := filepath.Join(root, "hugo", contentFolder)
+	touch(g, filepath.Join(contentDir, "en/_index.md"))
+	write(g, filepath.Join(contentDir, "en/docs/page.md"), "# Page\n")
+	write(g, filepath.Join(contentDir, "en/docs/_index.md"), "# Docs\n\nWorks on the website only: [page](page).\n")
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	g.Expect(readAll()).To(Succeed())
+
+	var out strings.Builder
+	g.Expect(linkcheckAll(context.Background(), &out)).To(Succeed())
+	g.Expect(out.String()).To(BeEmpty())
+
+	dualValidateAll()
+	g.Expect(printReport(&out)).To(Succeed())
+
+	// Warnings are added after the pages have been streamed, so they are printed with the report.
+	g.Expect(out.String()).To(Equal(`
+PAGE: <site>/content/en/docs/_index.md
+
+ - WARNING: line 3, page: the link works on the website but not in the source repository
+
+Total page processed: 3 links: 1 anchors: 2 
+`))
+}
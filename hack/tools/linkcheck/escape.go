@@ -0,0 +1,52 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// isInFolder returns true if path is the folder or it is contained in the folder.
+func isInFolder(path, folder string) bool {
+	return path == folder || strings.HasPrefix(path, folder+string(filepath.Separator))
+}
+
+// addEscapingLink adds a relative link escaping the content dir of the page language, e.g. ../../static/x.png.
+// Such links are allowed only when targeting one of the --escape-folders of the hugo website,
+// and they are checked as links to files, like assets.
+func (p *page) addEscapingLink(l string, lineNumber int, target string) {
+	hugoDir := filepath.Join(*root, *hugoFolder)
+	if !isInFolder(target, hugoDir) {
+		p.links = append(p.links, link{rawLink: l, lineNumber: lineNumber, fatalError: newLinkcheckError(invalidLinkErrorCategory, "the link points outside of the hugo website folder")})
+		return
+	}
+
+	rel := filepath.ToSlash(strings.TrimPrefix(target, hugoDir+string(filepath.Separator)))
+	folder := strings.SplitN(rel, "/", 2)[0]
+	if !containsString(*escapeFolders, folder) {
+		p.links = append(p.links, link{rawLink: l, lineNumber: lineNumber, fatalError: newLinkcheckError(invalidLinkErrorCategory, "the link points to %s outside of the content folder, only links to %s are allowed", strings.TrimPrefix(target, *root), strings.Join(*escapeFolders, ", "))})
+		return
+	}
+
+	debugf("%s line %d, %s: resolved to %s", p.logPath(), lineNumber, l, target)
+	p.links = append(p.links, link{rawLink: l, lineNumber: lineNumber, URL: &url.URL{Path: target}})
+}
@@ -0,0 +1,111 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_isInFolder(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		folder string
+		want   bool
+	}{
+		{
+			name:   "folder",
+			path:   "/hugo/content/en",
+			folder: "/hugo/content/en",
+			want:   true,
+		},
+		{
+			name:   "path in folder",
+			path:   "/hugo/content/en/docs/page.md",
+			folder: "/hugo/content/en",
+			want:   true,
+		},
+		{
+			name:   "path in sibling folder with the same prefix",
+			path:   "/hugo/content/en-us/page.md",
+			folder: "/hugo/content/en",
+			want:   false,
+		},
+		{
+			name:   "path outside folder",
+			path:   "/hugo/static/x.png",
+			folder: "/hugo/content/en",
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(isInFolder(tt.path, tt.folder)).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_readAllAndLinkcheckAll_escapingLinks(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	contentDir := filepath.Join(root, "hugo", contentFolder)
+	write(g, filepath.Join(contentDir, "en/_index.md"), `# Home
+
+See [image](../../static/x.png), [asset](../../assets/docs/y.pdf) or [missing image](../../static/missing.png).
+See [layout](../../layouts/index.html), [etc](../../../etc) or [passwd](../../../../etc/passwd).
+See [docs](docs/) or [home](docs/../).
+`)
+	write(g, filepath.Join(contentDir, "en/docs/_index.md"), "# Docs\n")
+	touch(g, filepath.Join(root, "hugo", "static/x.png"))
+	touch(g, filepath.Join(root, "hugo", "assets/docs/y.pdf"))
+	touch(g, filepath.Join(root, "hugo", "layouts/index.html"))
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+
+	g.Expect(collectErrors(root)).To(Equal([]string{
+		"/hugo/content/en/_index.md:3: ../../static/missing.png: the link resolves to /hugo/static/missing.png which does not exist",
+		"/hugo/content/en/_index.md:4: ../../../../etc/passwd: the link points outside of the hugo website folder",
+		"/hugo/content/en/_index.md:4: ../../../etc: the link points outside of the hugo website folder",
+		"/hugo/content/en/_index.md:4: ../../layouts/index.html: the link points to /hugo/layouts/index.html outside of the content folder, only links to static, assets are allowed",
+	}))
+}
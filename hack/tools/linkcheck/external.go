@@ -0,0 +1,252 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// httpClient is the client used for checking external links.
+	httpClient = &http.Client{}
+
+	// externalResults contains the result of external links already checked or being checked, by url.
+	// NOTE: this avoids duplicated http calls when the same url is linked many times, also from pages checked in parallel.
+	externalResults = map[string]*externalResult{}
+
+	// externalResultsLock protects externalResults, which is used by all the workers checking pages.
+	externalResultsLock sync.Mutex
+
+	// externalNetrc contains the credentials used for checking external links, if any.
+	externalNetrc *netrc
+
+	// externalLinkRules contains the rules used for checking external links.
+	externalLinkRules = &externalRules{}
+
+	// externalResolvers contains the resolvers used for checking external links to specific services.
+	externalResolvers []externalResolver
+)
+
+// externalResolver checks external links to a specific service beyond the HTTP status, e.g. using the API of the service.
+type externalResolver interface {
+	// matches returns true if the resolver checks the url.
+	matches(u *url.URL) bool
+
+	// resolve checks the url, including the fragment, and returns an error message if the link is broken.
+	resolve(ctx context.Context, u *url.URL) string
+}
+
+// resolverFor returns the first resolver checking the url, if any.
+func resolverFor(u *url.URL) externalResolver {
+	for _, r := range externalResolvers {
+		if r.matches(u) {
+			return r
+		}
+	}
+	return nil
+}
+
+// externalRules defines the rules used for checking external links, as read from the --external-rules file.
+type externalRules struct {
+	// Timeouts overrides the default timeout for hosts matching a pattern.
+	// NOTE: the first matching rule applies.
+	Timeouts []timeoutRule `yaml:"timeouts"`
+}
+
+// timeoutRule overrides the default timeout for hosts matching a pattern.
+type timeoutRule struct {
+	// Host pattern, e.g. *.example.com; see path.Match for the pattern syntax.
+	Host string `yaml:"host"`
+
+	// Timeout for checking links to the host.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// externalResult defines the result of checking an external url, shared by all the links to the url.
+type externalResult struct {
+	// done is closed when the url has been checked.
+	done chan struct{}
+
+	// message if set, defines why the url is broken.
+	message string
+}
+
+// isExternalLink returns true if a link points to an http or https url.
+func isExternalLink(u *url.URL) bool {
+	return u.Scheme == "http" || u.Scheme == "https"
+}
+
+// loadNetrc loads the credentials used for checking external links.
+// NOTE: if the netrc file is not explicitly set, a missing file in the user home directory is ignored.
+func loadNetrc() error {
+	externalNetrc = nil
+	if *disableNetrc {
+		return nil
+	}
+
+	path := *netrcFile
+	if path == "" {
+		path = defaultNetrcPath()
+		if _, err := os.Stat(path); path == "" || errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+	}
+
+	n, err := readNetrc(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read netrc file %s", path)
+	}
+	externalNetrc = n
+	return nil
+}
+
+// loadExternalRules loads the rules used for checking external links.
+func loadExternalRules() error {
+	externalLinkRules = &externalRules{}
+	if *externalRulesFile == "" {
+		return nil
+	}
+
+	content, err := os.ReadFile(*externalRulesFile)
+	if err != nil {
+		return err
+	}
+
+	r := &externalRules{}
+	if err := yaml.Unmarshal(content, r); err != nil {
+		return errors.Wrapf(err, "failed to parse %s", *externalRulesFile)
+	}
+	for _, t := range r.Timeouts {
+		if _, err := path.Match(t.Host, ""); err != nil {
+			return errors.Wrapf(err, "invalid host pattern %q in %s", t.Host, *externalRulesFile)
+		}
+	}
+	externalLinkRules = r
+	return nil
+}
+
+// timeoutFor returns the timeout for checking links to a host.
+func timeoutFor(host string) time.Duration {
+	for _, t := range externalLinkRules.Timeouts {
+		if ok, _ := path.Match(t.Host, host); ok {
+			return t.Timeout
+		}
+	}
+	return *externalTimeout
+}
+
+// checkExternalLink checks an external link, and returns an error message if the link is broken.
+// NOTE: the url is checked only once, and links to the same url checked in parallel wait for the same result; each
+// link gets its own copy of the message, so errors are reported with the page and the line of every link.
+// NOTE: if the context is done, e.g. because --timeout-total expired, the result is not cached.
+func checkExternalLink(ctx context.Context, u *url.URL) string {
+	// NOTE: fragments are not sent to the server, so they are dropped from the url.
+	target := *u
+	target.Fragment = ""
+	target.RawFragment = ""
+	key := target.String()
+	check := func() string { return fetchExternalLink(ctx, &target) }
+
+	// Urls checked by a resolver keep the fragment, which can be meaningful for the service, e.g. lines of a file.
+	if resolver := resolverFor(u); resolver != nil {
+		key = u.String()
+		check = func() string { return resolver.resolve(ctx, u) }
+	}
+
+	externalResultsLock.Lock()
+	r, ok := externalResults[key]
+	if !ok {
+		r = &externalResult{done: make(chan struct{})}
+		externalResults[key] = r
+	}
+	externalResultsLock.Unlock()
+
+	// If the url is already checked or being checked for another link, use the same result.
+	if ok {
+		select {
+		case <-r.done:
+			return r.message
+		case <-ctx.Done():
+			return fmt.Sprintf("external link not checked: %v", ctx.Err())
+		}
+	}
+
+	// NOTE: with --external-requests, the link waits for one of the slots for checking links in parallel.
+	if release, ok := acquireExternalRequest(ctx); ok {
+		r.message = check()
+		release()
+	}
+	if ctx.Err() != nil {
+		r.message = fmt.Sprintf("external link not checked: %v", ctx.Err())
+
+		externalResultsLock.Lock()
+		delete(externalResults, key)
+		externalResultsLock.Unlock()
+	}
+	close(r.done)
+	return r.message
+}
+
+func fetchExternalLink(ctx context.Context, u *url.URL) string {
+	ctx, cancel := context.WithTimeout(ctx, timeoutFor(u.Hostname()))
+	defer cancel()
+
+	// NOTE: some servers do not support HEAD requests, in this case fallback to GET.
+	status, err := doExternalRequest(ctx, http.MethodHead, u)
+	if err == nil && status == http.StatusMethodNotAllowed {
+		status, err = doExternalRequest(ctx, http.MethodGet, u)
+	}
+	if err != nil {
+		return fmt.Sprintf("error checking external link: %v", err)
+	}
+	if status >= http.StatusBadRequest {
+		return fmt.Sprintf("the link returned HTTP status %d", status)
+	}
+	return ""
+}
+
+func doExternalRequest(ctx context.Context, method string, u *url.URL) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), http.NoBody)
+	if err != nil {
+		return 0, err
+	}
+	if c, ok := externalNetrc.credentials(u.Hostname()); ok {
+		req.SetBasicAuth(c.login, c.password)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,461 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_checkExternalLink_netrc(t *testing.T) {
+	g := NewWithT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		if !ok || user != "user" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	dir, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name         string
+		netrcContent string
+		disableNetrc bool
+		want         string
+	}{
+		{
+			name:         "netrc supplying credentials",
+			netrcContent: "machine 127.0.0.1 login user password secret\n",
+			want:         "",
+		},
+		{
+			name:         "netrc supplying wrong credentials",
+			netrcContent: "machine 127.0.0.1 login user password wrong\n",
+			want:         "the link returned HTTP status 401",
+		},
+		{
+			name:         "netrc disabled",
+			netrcContent: "machine 127.0.0.1 login user password secret\n",
+			disableNetrc: true,
+			want:         "the link returned HTTP status 401",
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			path := filepath.Join(dir, fmt.Sprintf("netrc-%d", i))
+			write(g, path, tt.netrcContent)
+
+			cancel := setNetrcFlags(path, tt.disableNetrc)
+			defer cancel()
+
+			g.Expect(loadNetrc()).To(Succeed())
+
+			externalResults = map[string]*externalResult{}
+			defer func() { externalResults = map[string]*externalResult{} }()
+
+			g.Expect(checkExternalLink(context.Background(), mustParseUrl(server.URL+"/protected#anchor"))).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_checkExternalLink(t *testing.T) {
+	g := NewWithT(t)
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/ok":
+			w.WriteHeader(http.StatusOK)
+		case "/no-head":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	externalResults = map[string]*externalResult{}
+	defer func() { externalResults = map[string]*externalResult{} }()
+
+	g.Expect(checkExternalLink(context.Background(), mustParseUrl(server.URL+"/ok"))).To(BeEmpty())
+	g.Expect(checkExternalLink(context.Background(), mustParseUrl(server.URL+"/no-head"))).To(BeEmpty())
+	g.Expect(checkExternalLink(context.Background(), mustParseUrl(server.URL+"/missing"))).To(Equal("the link returned HTTP status 404"))
+
+	// Links to an url already checked are not fetched again.
+	requests = 0
+	g.Expect(checkExternalLink(context.Background(), mustParseUrl(server.URL+"/missing#anchor"))).To(Equal("the link returned HTTP status 404"))
+	g.Expect(requests).To(Equal(0))
+}
+
+func Test_loadExternalRules(t *testing.T) {
+	g := NewWithT(t)
+
+	dir, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "rules.yaml")
+	write(g, path, `timeouts:
+- host: "slow.example.com"
+  timeout: 30s
+- host: "*.example.com"
+  timeout: 1s
+`)
+
+	cancel := setExternalRulesFlags(path, 10*time.Second)
+	defer cancel()
+
+	g.Expect(loadExternalRules()).To(Succeed())
+	g.Expect(timeoutFor("slow.example.com")).To(Equal(30 * time.Second))
+	g.Expect(timeoutFor("docs.example.com")).To(Equal(1 * time.Second))
+	g.Expect(timeoutFor("another.com")).To(Equal(10 * time.Second))
+
+	write(g, path, `timeouts:
+- host: "[invalid"
+  timeout: 30s
+`)
+	g.Expect(loadExternalRules()).ToNot(Succeed())
+}
+
+func Test_checkExternalLink_timeoutOverride(t *testing.T) {
+	g := NewWithT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	dir, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name       string
+		rules      string
+		wantBroken bool
+	}{
+		{
+			name:       "default timeout too short for a slow host",
+			rules:      "",
+			wantBroken: true,
+		},
+		{
+			name:       "timeout override for a slow host",
+			rules:      "timeouts:\n- host: \"127.0.0.1\"\n  timeout: 5s\n",
+			wantBroken: false,
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			path := filepath.Join(dir, fmt.Sprintf("rules-%d.yaml", i))
+			write(g, path, tt.rules)
+
+			cancel := setExternalRulesFlags(path, 50*time.Millisecond)
+			defer cancel()
+
+			g.Expect(loadExternalRules()).To(Succeed())
+
+			externalResults = map[string]*externalResult{}
+			defer func() { externalResults = map[string]*externalResult{} }()
+
+			err := checkExternalLink(context.Background(), mustParseUrl(server.URL+"/slow"))
+			if tt.wantBroken {
+				g.Expect(err).To(ContainSubstring("context deadline exceeded"))
+				return
+			}
+			g.Expect(err).To(BeEmpty())
+		})
+	}
+}
+
+func Test_linkcheckAll_timeoutTotal(t *testing.T) {
+	g := NewWithT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cancel := setFlags("/root", "hugo", []string{"en"})
+	defer cancel()
+
+	checkExternalBefore := *checkExternal
+	defer func() { *checkExternal = checkExternalBefore }()
+	*checkExternal = true
+
+	cancelRules := setExternalRulesFlags("", 10*time.Second)
+	defer cancelRules()
+	g.Expect(loadExternalRules()).To(Succeed())
+
+	externalResults = map[string]*externalResult{}
+	defer func() { externalResults = map[string]*externalResult{} }()
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	a := newPage("/root/README.md")
+	a.addLink(server.URL+"/slow", 1)
+	addPage(a)
+	b := newPage("/root/CONTRIBUTING.md")
+	b.addLink(server.URL+"/another", 1)
+	addPage(b)
+
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancelCtx()
+
+	start := time.Now()
+	g.Expect(linkcheckAll(ctx, io.Discard)).To(Succeed())
+	g.Expect(time.Since(start)).To(BeNumerically("<", 2*time.Second))
+	g.Expect(ctx.Err()).To(MatchError(context.DeadlineExceeded))
+
+	// The link being checked when the timeout expires is reported as not checked, and the result is not cached.
+	g.Expect(pagesByPath["/root/README.md"].links[0].fatalError.Error()).To(Equal("external link not checked: context deadline exceeded"))
+	g.Expect(externalResults).To(BeEmpty())
+
+	// Pages not yet checked when the timeout expires are reported with a warning.
+	g.Expect(pagesByPath["/root/CONTRIBUTING.md"].links[0].fatalError).To(BeNil())
+	g.Expect(pagesByPath["/root/CONTRIBUTING.md"].warnings).To(Equal([]warning{{message: "links have not been checked: context deadline exceeded"}}))
+}
+
+func Test_linkcheckAll_onlyExternalOrInternal(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name          string
+		checkExternal bool
+		onlyExternal  bool
+		onlyInternal  bool
+		wantErrors    []string
+		wantSummary   string
+	}{
+		{
+			name:          "internal and external links",
+			checkExternal: true,
+			wantErrors: []string{
+				"/hugo/content/en/_index.md:3: SERVER/missing: the link returned HTTP status 404",
+				"/hugo/content/en/_index.md:3: missing: the link resolves to /hugo/content/en/missing.md which does not exist",
+			},
+			wantSummary: "Total page processed: 1 links: 2 anchors: 1 \nExternal hosts contacted: 1\n",
+		},
+		{
+			name:          "only internal links",
+			checkExternal: true,
+			onlyInternal:  true,
+			wantErrors: []string{
+				"/hugo/content/en/_index.md:3: missing: the link resolves to /hugo/content/en/missing.md which does not exist",
+			},
+			wantSummary: "Total page processed: 1 links: 2 anchors: 1 \nOnly internal links checked\n",
+		},
+		{
+			name:         "only external links",
+			onlyExternal: true,
+			wantErrors: []string{
+				"/hugo/content/en/_index.md:3: SERVER/missing: the link returned HTTP status 404",
+			},
+			wantSummary: "Total page processed: 1 links: 2 anchors: 1 \nExternal hosts contacted: 1\nOnly external links checked\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			root, err := os.MkdirTemp("", "linkcheck")
+			g.Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(root)
+
+			cancel := setFlags(root, "hugo", []string{"en"})
+			defer cancel()
+
+			checkExternalBefore, onlyExternalBefore, onlyInternalBefore := *checkExternal, *onlyExternal, *onlyInternal
+			defer func() {
+				*checkExternal, *onlyExternal, *onlyInternal = checkExternalBefore, onlyExternalBefore, onlyInternalBefore
+			}()
+			*checkExternal, *onlyExternal, *onlyInternal = tt.checkExternal, tt.onlyExternal, tt.onlyInternal
+
+			externalResults = map[string]*externalResult{}
+			defer func() { externalResults = map[string]*externalResult{} }()
+
+			write(g, filepath.Join(root, "hugo", contentFolder, "en/_index.md"), fmt.Sprintf("# Home\n\nSee [missing](missing) and [external](%s/missing).\n", server.URL))
+
+			pages = nil
+			pagesByPath = nil
+			defer func() {
+				pages = nil
+				pagesByPath = nil
+			}()
+
+			g.Expect(readAll()).To(Succeed())
+			g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+
+			errs := []string{}
+			for _, e := range collectErrors(root) {
+				errs = append(errs, strings.ReplaceAll(e, server.URL, "SERVER"))
+			}
+			g.Expect(errs).To(Equal(tt.wantErrors))
+
+			resetLogLevel := setLogLevel("quiet", 0)
+			defer resetLogLevel()
+
+			var out bytes.Buffer
+			g.Expect(printReport(&out)).To(Succeed())
+			g.Expect(out.String()).To(Equal("\n" + tt.wantSummary))
+		})
+	}
+}
+
+// NOTE: run with -race to detect data races when many links to the same url are checked in parallel.
+func Test_checkExternalLink_parallel(t *testing.T) {
+	g := NewWithT(t)
+
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	cancel := setFlags("/root", "hugo", []string{"en"})
+	defer cancel()
+
+	checkExternalBefore := *checkExternal
+	defer func() { *checkExternal = checkExternalBefore }()
+	*checkExternal = true
+
+	workersBefore := *workers
+	defer func() { *workers = workersBefore }()
+	*workers = 8
+
+	externalResults = map[string]*externalResult{}
+	defer func() { externalResults = map[string]*externalResult{} }()
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	want := []string{}
+	for i := 0; i < 20; i++ {
+		p := newPage(fmt.Sprintf("/root/page%02d.md", i))
+		p.addLink(fmt.Sprintf("%s/missing#anchor-%d", server.URL, i), i+1)
+		p.addLink(server.URL+"/missing", i+2)
+		addPage(p)
+		want = append(want,
+			fmt.Sprintf("/page%02d.md:%d: SERVER/missing#anchor-%d: the link returned HTTP status 404", i, i+1, i),
+			fmt.Sprintf("/page%02d.md:%d: SERVER/missing: the link returned HTTP status 404", i, i+2),
+		)
+	}
+	sort.Strings(want)
+
+	// Many goroutines check the same url while the pages are checked in parallel.
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g.Expect(checkExternalLink(context.Background(), mustParseUrl(server.URL+"/missing"))).To(Equal("the link returned HTTP status 404"))
+		}()
+	}
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+	wg.Wait()
+
+	// The url is checked only once, and the error is reported on every link, with its own page and line.
+	g.Expect(atomic.LoadInt32(&hits)).To(Equal(int32(1)))
+
+	errs := []string{}
+	for _, e := range collectErrors("/root") {
+		errs = append(errs, strings.ReplaceAll(e, server.URL, "SERVER"))
+	}
+	g.Expect(errs).To(Equal(want))
+}
+
+func setExternalRulesFlags(externalRulesFileValue string, externalTimeoutValue time.Duration) (resetExternalRulesFlags func()) {
+	externalRulesFileBefore := *externalRulesFile
+	externalTimeoutBefore := *externalTimeout
+	externalLinkRulesBefore := externalLinkRules
+
+	*externalRulesFile = externalRulesFileValue
+	*externalTimeout = externalTimeoutValue
+
+	return func() {
+		*externalRulesFile = externalRulesFileBefore
+		*externalTimeout = externalTimeoutBefore
+		externalLinkRules = externalLinkRulesBefore
+	}
+}
+
+func setNetrcFlags(netrcFileValue string, disableNetrcValue bool) (resetNetrcFlags func()) {
+	netrcFileBefore := *netrcFile
+	disableNetrcBefore := *disableNetrc
+	externalNetrcBefore := externalNetrc
+
+	*netrcFile = netrcFileValue
+	*disableNetrc = disableNetrcValue
+
+	return func() {
+		*netrcFile = netrcFileBefore
+		*disableNetrc = disableNetrcBefore
+		externalNetrc = externalNetrcBefore
+	}
+}
@@ -0,0 +1,131 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"net/url"
+	"sync"
+)
+
+var (
+	// externalRequestSlots bounds the number of external links checked in parallel, if --external-requests is set.
+	externalRequestSlots chan struct{}
+
+	// pipeline checks external links as soon as pages are read, if --external-queue-size is set.
+	pipeline *externalPipeline
+)
+
+// externalPipeline checks external links while pages are read, instead of waiting for all the pages to be read;
+// links are buffered in a bounded queue, so reading pages waits for the checkers when the queue is full.
+// NOTE: only the links waiting to be checked and the requests in flight are bounded; pages, their links and the
+// results of external links are kept in memory until the report is printed.
+// NOTE: results are stored with the result of other external links, so linkcheckPage uses them, or waits for the
+// links being checked, instead of checking links again.
+type externalPipeline struct {
+	queue  chan *url.URL
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// startExternalChecks bounds the number of external links checked in parallel to --external-requests, if set, and
+// starts checking external links as soon as pages are read, if --external-queue-size is set; checkers stop
+// when ctx is done, e.g. when --timeout-total expires.
+// NOTE: links are checked by --external-requests checkers, defaulting to --workers.
+func startExternalChecks(ctx context.Context) {
+	externalRequestSlots = nil
+	if *externalRequests > 0 {
+		externalRequestSlots = make(chan struct{}, *externalRequests)
+	}
+
+	pipeline = nil
+	if *externalQueue == 0 || !checksExternal() {
+		return
+	}
+
+	checkers := *externalRequests
+	if checkers == 0 {
+		checkers = *workers
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	pipeline = &externalPipeline{queue: make(chan *url.URL, *externalQueue), cancel: cancel}
+	for n := 0; n < checkers; n++ {
+		pipeline.wg.Add(1)
+		go func() {
+			defer pipeline.wg.Done()
+			for u := range pipeline.queue {
+				if ctx.Err() == nil {
+					checkExternalLink(ctx, u)
+				}
+			}
+		}()
+	}
+}
+
+// enqueueExternalLinks adds the external links of a page just read to the pipeline, if any.
+// NOTE: this waits for the checkers when the queue is full, so the links buffered in memory are bounded.
+func enqueueExternalLinks(p *page) {
+	if pipeline == nil || p.fatalError != nil {
+		return
+	}
+	for _, l := range p.links {
+		if l.fatalError != nil || l.URL == nil || !isExternalLink(l.URL) {
+			continue
+		}
+		// NOTE: the url is copied, because the link is checked while the page could be processed.
+		u := *l.URL
+		pipeline.queue <- &u
+	}
+}
+
+// closeExternalPipeline signals the pipeline that all the pages have been read, if any; checkers stop once
+// the queue is empty.
+func closeExternalPipeline() {
+	if pipeline == nil {
+		return
+	}
+	close(pipeline.queue)
+}
+
+// stopExternalPipeline stops checking links buffered in the pipeline, if any, and waits for the checkers to stop;
+// links being checked when the pipeline is stopped are not cached, as when --timeout-total expires.
+func stopExternalPipeline() {
+	if pipeline == nil {
+		return
+	}
+	pipeline.cancel()
+	pipeline.wg.Wait()
+	pipeline = nil
+}
+
+// acquireExternalRequest waits for a slot for checking an external link, if --external-requests is set, and
+// returns the func releasing the slot; it returns false if the context is done before a slot is available.
+func acquireExternalRequest(ctx context.Context) (func(), bool) {
+	if externalRequestSlots == nil {
+		return func() {}, true
+	}
+	slots := externalRequestSlots
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, true
+	case <-ctx.Done():
+		return nil, false
+	}
+}
@@ -0,0 +1,221 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_readAllAndLinkcheckAll_externalPipeline(t *testing.T) {
+	g := NewWithT(t)
+
+	// The server tracks the maximum number of requests in flight, and fails links to /missing pages.
+	var inFlight, maxInFlight, requests int32
+	var lock sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		atomic.AddInt32(&requests, 1)
+		lock.Lock()
+		if n > maxInFlight {
+			maxInFlight = n
+		}
+		lock.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+		if filepath.Base(r.URL.Path) == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	// Many pages with many links, also linking the same urls as other pages.
+	for i := 0; i < 20; i++ {
+		content := fmt.Sprintf("# Page %d\n\n", i)
+		for j := 0; j < 10; j++ {
+			content += fmt.Sprintf("See [link](%s/%d/%d) and [shared](%s/shared/%d).\n", server.URL, i, j, server.URL, j)
+		}
+		content += fmt.Sprintf("See [missing](%s/%d/missing).\n", server.URL, i)
+		write(g, filepath.Join(root, "hugo", contentFolder, fmt.Sprintf("en/page%d.md", i)), content)
+	}
+	write(g, filepath.Join(root, "hugo", contentFolder, "en/_index.md"), "# Home\n")
+
+	checkExternalBefore := *checkExternal
+	workersBefore := *workers
+	externalRequestsBefore := *externalRequests
+	externalQueueBefore := *externalQueue
+	defer func() {
+		*checkExternal = checkExternalBefore
+		*workers = workersBefore
+		*externalRequests = externalRequestsBefore
+		*externalQueue = externalQueueBefore
+	}()
+	*checkExternal = true
+	*workers = 8
+	*externalRequests = 3
+	*externalQueue = 5
+
+	externalResults = map[string]*externalResult{}
+	defer func() { externalResults = map[string]*externalResult{} }()
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	startExternalChecks(context.Background())
+	defer stopExternalPipeline()
+	g.Expect(pipeline).ToNot(BeNil())
+	g.Expect(cap(pipeline.queue)).To(Equal(5))
+
+	g.Expect(readAll()).To(Succeed())
+	closeExternalPipeline()
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+	stopExternalPipeline()
+
+	// Each url is checked once, never exceeding --external-requests in flight, also with more --workers.
+	g.Expect(atomic.LoadInt32(&requests)).To(Equal(int32(20*10 + 10 + 20)))
+	g.Expect(maxInFlight).To(BeNumerically("<=", 3))
+	g.Expect(maxInFlight).To(BeNumerically(">", 0))
+
+	// Results are the same as without the pipeline.
+	errs := collectErrors(root)
+	g.Expect(errs).To(HaveLen(20))
+	g.Expect(errs).To(ContainElement(fmt.Sprintf("/hugo/content/en/page0.md:13: %s/0/missing: the link returned HTTP status 404", server.URL)))
+}
+
+func Test_readAllAndLinkcheckAll_externalPipelineTimeout(t *testing.T) {
+	g := NewWithT(t)
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	content := "# Home\n\n"
+	for i := 0; i < 10; i++ {
+		content += fmt.Sprintf("See [page %d](%s/%d).\n", i, server.URL, i)
+	}
+	write(g, filepath.Join(root, "hugo", contentFolder, "en/_index.md"), content)
+
+	checkExternalBefore := *checkExternal
+	externalQueueBefore := *externalQueue
+	defer func() {
+		*checkExternal = checkExternalBefore
+		*externalQueue = externalQueueBefore
+	}()
+	*checkExternal = true
+	*externalQueue = 2
+
+	externalResults = map[string]*externalResult{}
+	defer func() { externalResults = map[string]*externalResult{} }()
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	// When --timeout-total expires while pages are read, the pipeline stops checking links.
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	cancelCtx()
+
+	startExternalChecks(ctx)
+	defer stopExternalPipeline()
+	g.Expect(readAll()).To(Succeed())
+	closeExternalPipeline()
+	g.Expect(linkcheckAll(ctx, io.Discard)).To(Succeed())
+	stopExternalPipeline()
+
+	g.Expect(atomic.LoadInt32(&requests)).To(Equal(int32(0)))
+	g.Expect(pagesByPath[filepath.Join(root, "hugo", contentFolder, "en/_index.md")].warnings).To(Equal([]warning{
+		{message: "links have not been checked: context canceled"},
+	}))
+}
+
+func Test_acquireExternalRequest(t *testing.T) {
+	g := NewWithT(t)
+
+	externalRequestsBefore := *externalRequests
+	externalQueueBefore := *externalQueue
+	defer func() {
+		*externalRequests = externalRequestsBefore
+		*externalQueue = externalQueueBefore
+		startExternalChecks(context.Background())
+	}()
+
+	// Without --external-requests, there is no limit.
+	*externalRequests = 0
+	*externalQueue = 0
+	startExternalChecks(context.Background())
+	g.Expect(pipeline).To(BeNil())
+	for i := 0; i < 10; i++ {
+		_, ok := acquireExternalRequest(context.Background())
+		g.Expect(ok).To(BeTrue())
+	}
+
+	// With --external-requests, requests wait for a slot, and give up when the context is done.
+	*externalRequests = 1
+	startExternalChecks(context.Background())
+	release, ok := acquireExternalRequest(context.Background())
+	g.Expect(ok).To(BeTrue())
+
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancelCtx()
+	_, ok = acquireExternalRequest(ctx)
+	g.Expect(ok).To(BeFalse())
+
+	release()
+	_, ok = acquireExternalRequest(context.Background())
+	g.Expect(ok).To(BeTrue())
+}
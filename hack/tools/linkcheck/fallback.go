@@ -0,0 +1,47 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// resolveLanguageFallback returns the path of the page in the default language, i.e. the first of --hugo-languages,
+// for the path of a missing page in another language, if it exists; this is the page served when a translation is
+// missing and hugo falls back to the default language.
+// NOTE: links to folders missing in the language of the link are resolved to page.md, so also the _index.md
+// of the folder in the default language is considered.
+func resolveLanguageFallback(missingPath string) (string, bool) {
+	missingp := newPage(missingPath)
+	if !missingp.isHugoPage || missingp.hugoLanguage == "" || len(*hugoLanguages) == 0 || missingp.hugoLanguage == (*hugoLanguages)[0] {
+		return "", false
+	}
+
+	fallbackPath := filepath.Join(languageContentDir((*hugoLanguages)[0]), filepath.FromSlash(missingp.hugoPath))
+	for _, path := range []string{fallbackPath, filepath.Join(strings.TrimSuffix(fallbackPath, ".md"), "_index.md")} {
+		if _, err := statFile(path); !errors.Is(err, fs.ErrNotExist) {
+			return path, true
+		}
+	}
+	return "", false
+}
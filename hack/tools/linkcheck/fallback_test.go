@@ -0,0 +1,139 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_readAllAndLinkcheckAll_languageFallback(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en", "it"})
+	defer cancel()
+
+	write(g, filepath.Join(root, "hugo", contentFolder, "it/_index.md"), `# Home
+
+See [section](/page#section), [docs](/docs#intro) or [translated](/translated#sezione).
+See [missing section](/page#missing) or [missing](/missing).
+`)
+	write(g, filepath.Join(root, "hugo", contentFolder, "it/translated.md"), "# Tradotto\n\n## Sezione\n")
+	write(g, filepath.Join(root, "hugo", contentFolder, "en/_index.md"), "# Home\n")
+	write(g, filepath.Join(root, "hugo", contentFolder, "en/page.md"), "# Page\n\n## Section\n")
+	write(g, filepath.Join(root, "hugo", contentFolder, "en/docs/_index.md"), "# Docs\n\n## Intro\n")
+	write(g, filepath.Join(root, "hugo", contentFolder, "en/translated.md"), "# Translated\n\n## Section\n")
+
+	languageFallbackBefore := *languageFallback
+	defer func() { *languageFallback = languageFallbackBefore }()
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	// By default, links to missing translations are errors.
+	*languageFallback = false
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+	g.Expect(collectErrors(root)).To(HaveLen(4))
+
+	pages = nil
+	pagesByPath = nil
+
+	// With --language-fallback, links to missing translations and their anchors are checked against the page
+	// in the default language, while links to existing translations are still checked against the translation.
+	*languageFallback = true
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+	g.Expect(collectErrors(root)).To(Equal([]string{
+		`/hugo/content/it/_index.md:4: /missing: the link resolves to /hugo/content/it/missing.md which does not exist`,
+		`/hugo/content/it/_index.md:4: /page#missing: #missing does exists in <site>/content/en/page.md`,
+	}))
+}
+
+func Test_resolveLanguageFallback(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en", "it"})
+	defer cancel()
+
+	touch(g, filepath.Join(root, "hugo", contentFolder, "en/page.md"))
+	touch(g, filepath.Join(root, "hugo", contentFolder, "en/docs/_index.md"))
+
+	tests := []struct {
+		name        string
+		missingPath string
+		want        string
+		wantOk      bool
+	}{
+		{
+			name:        "page in the default language",
+			missingPath: filepath.Join(root, "hugo", contentFolder, "it/page.md"),
+			want:        filepath.Join(root, "hugo", contentFolder, "en/page.md"),
+			wantOk:      true,
+		},
+		{
+			name:        "folder in the default language",
+			missingPath: filepath.Join(root, "hugo", contentFolder, "it/docs.md"),
+			want:        filepath.Join(root, "hugo", contentFolder, "en/docs/_index.md"),
+			wantOk:      true,
+		},
+		{
+			name:        "missing also in the default language",
+			missingPath: filepath.Join(root, "hugo", contentFolder, "it/missing.md"),
+			wantOk:      false,
+		},
+		{
+			name:        "missing in the default language",
+			missingPath: filepath.Join(root, "hugo", contentFolder, "en/missing.md"),
+			wantOk:      false,
+		},
+		{
+			name:        "not a hugo page",
+			missingPath: filepath.Join(root, "docs/page.md"),
+			wantOk:      false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, ok := resolveLanguageFallback(tt.missingPath)
+			g.Expect(ok).To(Equal(tt.wantOk))
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
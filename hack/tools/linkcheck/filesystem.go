@@ -0,0 +1,180 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// fileSystem is the filesystem where pages are read and link targets are checked.
+// NOTE: linkcheck uses absolute paths, which are translated into paths of a filesystem rooted at /;
+// tests can replace it with an in memory filesystem, e.g. fstest.MapFS.
+var fileSystem fs.FS = osFS{}
+
+// osFS is the filesystem of the operating system, rooted at /.
+// NOTE: unlike os.DirFS, errors report the absolute path of files.
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	f, err := os.Open(filepath.Join("/", filepath.FromSlash(name)))
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (osFS) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+	return os.Stat(filepath.Join("/", filepath.FromSlash(name)))
+}
+
+// fsPath returns the path in fileSystem for an absolute path.
+func fsPath(path string) string {
+	p := strings.TrimPrefix(filepath.ToSlash(filepath.Clean(path)), "/")
+	if p == "" {
+		return "."
+	}
+	return p
+}
+
+// statFile returns the file info for an absolute path.
+func statFile(path string) (fs.FileInfo, error) {
+	return fs.Stat(fileSystem, fsPath(path))
+}
+
+// readFile returns the content of the file at an absolute path.
+func readFile(path string) ([]byte, error) {
+	return fs.ReadFile(fileSystem, fsPath(path))
+}
+
+// walkFiles walks the file tree rooted at an absolute path, calling fn with the absolute path of each file or folder.
+// NOTE: see fs.WalkDir for how errors are handled; with --follow-symlinks, symlinked folders are walked too, and files
+// inside them are reported with the path of the symlink, so links crossing into them are resolved as on the website.
+func walkFiles(root string, fn func(path string, d fs.DirEntry, err error) error) error {
+	return fs.WalkDir(fileSystem, fsPath(root), func(path string, d fs.DirEntry, err error) error {
+		absPath := filepath.Join("/", filepath.FromSlash(path))
+		if err == nil && *followSymlinks && d.Type()&fs.ModeSymlink != 0 {
+			if info, statErr := statFile(absPath); statErr == nil && info.IsDir() && !isParentFolder(info, absPath) {
+				return walkFiles(absPath, fn)
+			}
+		}
+		return fn(absPath, d, err)
+	})
+}
+
+// isParentFolder returns true if a folder is one of the folders containing an absolute path, e.g. the target of a
+// symlink pointing to a parent folder, which would cause an endless loop when following symlinks.
+func isParentFolder(folder fs.FileInfo, path string) bool {
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if info, err := statFile(dir); err == nil && os.SameFile(info, folder) {
+			return true
+		}
+		if dir == filepath.Dir(dir) {
+			return false
+		}
+	}
+}
+
+var (
+	// dirNames contains the names of the files and folders in the folders already listed by canonicalPath, by folder.
+	// NOTE: this avoids listing the same folders for every link; it is reset when links are checked.
+	dirNames = map[string][]string{}
+
+	// dirNamesLock protects dirNames, which is used by all the workers checking pages.
+	dirNamesLock sync.Mutex
+)
+
+// resetDirNames drops the folders already listed by canonicalPath, e.g. because files could be changed on disk.
+func resetDirNames() {
+	dirNamesLock.Lock()
+	defer dirNamesLock.Unlock()
+	dirNames = map[string][]string{}
+}
+
+// readDirNames returns the names of the files and folders in a folder, listing each folder only once.
+func readDirNames(dir string) ([]string, error) {
+	dirNamesLock.Lock()
+	names, ok := dirNames[dir]
+	dirNamesLock.Unlock()
+	if ok {
+		return names, nil
+	}
+
+	entries, err := fs.ReadDir(fileSystem, fsPath(dir))
+	if err != nil {
+		return nil, err
+	}
+	names = []string{}
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+
+	dirNamesLock.Lock()
+	dirNames[dir] = names
+	dirNamesLock.Unlock()
+	return names, nil
+}
+
+// canonicalPath returns an absolute path using the casing of the files and folders on disk, e.g. docs/reference.md
+// for docs/Reference.md on a case insensitive filesystem.
+// NOTE: only the part of the path inside root is considered; folders are listed once, see readDirNames.
+func canonicalPath(path string) (string, error) {
+	if !isInFolder(path, *root) || path == *root {
+		return path, nil
+	}
+
+	canonical := *root
+	for _, name := range strings.Split(strings.TrimPrefix(path, *root+string(filepath.Separator)), string(filepath.Separator)) {
+		names, err := readDirNames(canonical)
+		if err != nil {
+			return "", err
+		}
+		match := ""
+		for _, n := range names {
+			if n == name {
+				match = name
+				break
+			}
+			if match == "" && strings.EqualFold(n, name) {
+				match = n
+			}
+		}
+		if match == "" {
+			return "", &fs.PathError{Op: "stat", Path: path, Err: fs.ErrNotExist}
+		}
+		canonical = filepath.Join(canonical, match)
+	}
+	return canonical, nil
+}
+
+// casingWarning returns the warning for a link resolving to canonicalTargetPath using a different casing.
+func casingWarning(l link, canonicalTargetPath string) warning {
+	return warning{lineNumber: l.lineNumber, rawLink: l.rawLink, message: fmt.Sprintf("the link resolves to %s using a different casing, which does not exist on case sensitive filesystems", strings.TrimPrefix(canonicalTargetPath, *root))}
+}
@@ -0,0 +1,292 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_fsPath(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(fsPath("/")).To(Equal("."))
+	g.Expect(fsPath("/root")).To(Equal("root"))
+	g.Expect(fsPath("/root/hugo/content/en/")).To(Equal("root/hugo/content/en"))
+}
+
+// permissionDeniedFS is a filesystem where some files cannot be opened.
+type permissionDeniedFS struct {
+	fs.FS
+	denied map[string]bool
+}
+
+func (f permissionDeniedFS) Open(name string) (fs.File, error) {
+	if f.denied[name] {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	return f.FS.Open(name)
+}
+
+func Test_readAllAndLinkcheckAll_fileSystem(t *testing.T) {
+	g := NewWithT(t)
+
+	cancel := setFlags("/root", "hugo", []string{"en"})
+	defer cancel()
+
+	fileSystemBefore := fileSystem
+	defer func() { fileSystem = fileSystemBefore }()
+	fileSystem = permissionDeniedFS{
+		FS: fstest.MapFS{
+			"root/README.md":                      {Data: []byte("# Readme\n")},
+			"root/hugo/content/en/_index.md":      {Data: []byte("# Home\n\nSee [docs](docs/), [page](docs/page#section), [missing](docs/missing) and the ![logo](images/logo.png).\n")},
+			"root/hugo/content/en/docs/_index.md": {Data: []byte("# Docs\n")},
+			"root/hugo/content/en/docs/page.md":   {Data: []byte("# Page\n\n## Section\n")},
+			"root/hugo/content/en/unreadable.md":  {Data: []byte("# Unreadable\n")},
+			"root/hugo/static/images/logo.png":    {Data: []byte{}},
+			"root/hugo/content/en/docs/folder.md": {Mode: fs.ModeDir},
+		},
+		denied: map[string]bool{"root/hugo/content/en/unreadable.md": true},
+	}
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	g.Expect(readAll()).To(Succeed())
+	checkLanguageIndexes()
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+
+	g.Expect(collectErrors("/root")).To(Equal([]string{
+		"/hugo/content/en/_index.md:3: docs/missing: the link resolves to /hugo/content/en/docs/missing.md which does not exist",
+		"/hugo/content/en/docs/folder.md: Error reading content: read root/hugo/content/en/docs/folder.md: invalid argument",
+		"/hugo/content/en/unreadable.md: Error reading content: open root/hugo/content/en/unreadable.md: permission denied",
+	}))
+}
+
+// caseInsensitiveFS is a filesystem where files and folders are found no matter of the casing used, like on macOS.
+type caseInsensitiveFS struct {
+	fstest.MapFS
+}
+
+func (f caseInsensitiveFS) Open(name string) (fs.File, error) {
+	for n := range f.MapFS {
+		// NOTE: folders are implicitly defined by the files they contain.
+		for p := n; p != "."; p = path.Dir(p) {
+			if strings.EqualFold(p, name) {
+				return f.MapFS.Open(p)
+			}
+		}
+	}
+	return f.MapFS.Open(name)
+}
+
+func (f caseInsensitiveFS) Stat(name string) (fs.FileInfo, error) {
+	file, err := f.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return file.Stat()
+}
+
+func Test_canonicalPath(t *testing.T) {
+	g := NewWithT(t)
+
+	cancel := setFlags("/root", "hugo", []string{"en"})
+	defer cancel()
+
+	fileSystemBefore := fileSystem
+	defer func() { fileSystem = fileSystemBefore }()
+	fileSystem = caseInsensitiveFS{
+		MapFS: fstest.MapFS{
+			"root/hugo/content/en/docs/reference.md": {Data: []byte("# Reference\n")},
+			"root/hugo/content/en/docs/Guide.md":     {Data: []byte("# Guide\n")},
+		},
+	}
+	resetDirNames()
+	defer resetDirNames()
+
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{path: "/root/hugo/content/en/docs/reference.md", want: "/root/hugo/content/en/docs/reference.md"},
+		{path: "/root/hugo/content/en/docs/Reference.md", want: "/root/hugo/content/en/docs/reference.md"},
+		{path: "/root/hugo/content/en/Docs/guide.md", want: "/root/hugo/content/en/docs/Guide.md"},
+		{path: "/root/hugo/content/en/docs", want: "/root/hugo/content/en/docs"},
+		{path: "/other/Docs", want: "/other/Docs"},
+		{path: "/root/hugo/content/en/docs/missing.md", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := canonicalPath(tt.path)
+		if tt.wantErr {
+			g.Expect(err).To(HaveOccurred())
+			continue
+		}
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(got).To(Equal(tt.want))
+	}
+}
+
+// readDirCountingFS is a filesystem counting how many times each folder is listed.
+type readDirCountingFS struct {
+	fstest.MapFS
+	count map[string]int
+}
+
+func (f readDirCountingFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	f.count[name]++
+	return f.MapFS.ReadDir(name)
+}
+
+func Test_canonicalPath_listsFoldersOnce(t *testing.T) {
+	g := NewWithT(t)
+
+	cancel := setFlags("/root", "hugo", []string{"en"})
+	defer cancel()
+
+	fileSystemBefore := fileSystem
+	defer func() { fileSystem = fileSystemBefore }()
+	countingFS := readDirCountingFS{
+		MapFS: fstest.MapFS{
+			"root/hugo/content/en/docs/a.md": {Data: []byte("# A\n")},
+			"root/hugo/content/en/docs/b.md": {Data: []byte("# B\n")},
+		},
+		count: map[string]int{},
+	}
+	fileSystem = countingFS
+	resetDirNames()
+	defer resetDirNames()
+
+	for _, path := range []string{"/root/hugo/content/en/docs/a.md", "/root/hugo/content/en/docs/b.md", "/root/hugo/content/en/docs/a.md"} {
+		got, err := canonicalPath(path)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(got).To(Equal(path))
+	}
+	g.Expect(countingFS.count).To(Equal(map[string]int{"root": 1, "root/hugo": 1, "root/hugo/content": 1, "root/hugo/content/en": 1, "root/hugo/content/en/docs": 1}))
+
+	// Folders are listed again after a reset, e.g. when links are checked again.
+	resetDirNames()
+	_, err := canonicalPath("/root/hugo/content/en/docs/a.md")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(countingFS.count["root/hugo/content/en/docs"]).To(Equal(2))
+}
+
+func Test_readAllAndLinkcheckAll_caseInsensitiveFileSystem(t *testing.T) {
+	g := NewWithT(t)
+
+	cancel := setFlags("/root", "hugo", []string{"en"})
+	defer cancel()
+
+	fileSystemBefore := fileSystem
+	defer func() { fileSystem = fileSystemBefore }()
+	fileSystem = caseInsensitiveFS{
+		MapFS: fstest.MapFS{
+			"root/hugo/content/en/_index.md":         {Data: []byte("# Home\n\nSee [reference](docs/Reference#usage), [docs](Docs/) and [guide](docs/guide).\n")},
+			"root/hugo/content/en/docs/_index.md":    {Data: []byte("# Docs\n")},
+			"root/hugo/content/en/docs/reference.md": {Data: []byte("# Reference\n\n## Usage\n")},
+			"root/hugo/content/en/docs/guide.md":     {Data: []byte("# Guide\n")},
+		},
+	}
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+
+	g.Expect(collectErrors("/root")).To(BeEmpty())
+	g.Expect(pagesByPath["/root/hugo/content/en/_index.md"].warnings).To(Equal([]warning{
+		{lineNumber: 3, rawLink: "docs/Reference#usage", message: "the link resolves to /hugo/content/en/docs/reference.md using a different casing, which does not exist on case sensitive filesystems"},
+		{lineNumber: 3, rawLink: "Docs/", message: "the link resolves to /hugo/content/en/docs/_index.md using a different casing, which does not exist on case sensitive filesystems"},
+	}))
+}
+
+func Test_readAllAndLinkcheckAll_followSymlinks(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	// The docs of a submodule, outside root, are included in the website with a symlink.
+	submodule, err := os.MkdirTemp("", "submodule")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(submodule)
+
+	contentDir := filepath.Join(root, "hugo", contentFolder, "en")
+	write(g, filepath.Join(contentDir, "_index.md"), "# Home\n\nSee the [provider](/provider/page#section).\n")
+	write(g, filepath.Join(submodule, "_index.md"), "# Provider\n")
+	write(g, filepath.Join(submodule, "page.md"), "# Page\n\n## Section\n\nBack [home](/) or to a [missing page](/missing).\n")
+	g.Expect(os.Symlink(submodule, filepath.Join(contentDir, "provider"))).To(Succeed())
+
+	// A symlink to a parent folder is not followed.
+	g.Expect(os.Symlink(submodule, filepath.Join(submodule, "loop"))).To(Succeed())
+
+	followSymlinksBefore := *followSymlinks
+	defer func() { *followSymlinks = followSymlinksBefore }()
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	// Without --follow-symlinks, pages in the symlinked folder are not read.
+	*followSymlinks = false
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+	g.Expect(collectErrors(root)).To(Equal([]string{
+		"/hugo/content/en/_index.md:3: /provider/page#section: the link resolves to /hugo/content/en/provider/page.md which is inside the symlinked folder /hugo/content/en/provider; use --follow-symlinks to check it",
+	}))
+
+	pages = nil
+	pagesByPath = nil
+
+	*followSymlinks = true
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+	g.Expect(pagesByPath).To(HaveKey(filepath.Join(contentDir, "provider/page.md")))
+	g.Expect(collectErrors(root)).To(Equal([]string{
+		"/hugo/content/en/provider/page.md:5: /missing: the link resolves to /hugo/content/en/missing.md which does not exist",
+	}))
+}
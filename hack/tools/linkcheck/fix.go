@@ -0,0 +1,242 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// linkFix defines the rewrite of a forbidden link in a page.
+type linkFix struct {
+	lineNumber int
+	rawLink    string
+	fixedLink  string
+}
+
+// fixAll rewrites forbidden links in all pages, and prints the list of applied fixes.
+func fixAll(w io.Writer) error {
+	for i := range pages {
+		p := pages[i]
+
+		fixes, err := fixPage(p)
+		if err != nil {
+			return errors.Wrapf(err, "failed to fix %s", p.logPath())
+		}
+		if currentLogLevel() <= quietLogLevel {
+			continue
+		}
+		for _, f := range fixes {
+			fmt.Fprintf(w, "FIXED: %s line %d, %s -> %s\n", p.logPath(), f.lineNumber, f.rawLink, f.fixedLink)
+		}
+	}
+	return nil
+}
+
+// diffAll prints a unified diff of the rewrites of forbidden links in all pages, without applying them, and
+// returns the number of rewrites.
+func diffAll(w io.Writer) (int, error) {
+	n := 0
+	for i := range pages {
+		p := pages[i]
+
+		fixes := pageFixes(p)
+		if len(fixes) == 0 {
+			continue
+		}
+		content, err := readFile(p.path)
+		if err != nil {
+			return n, errors.Wrapf(err, "failed to read %s", p.logPath())
+		}
+		name := strings.TrimPrefix(strings.TrimPrefix(p.path, *root), "/")
+		fixed, applied := applyFixes(string(content), fixes)
+		fmt.Fprint(w, unifiedDiff(name, string(content), fixed))
+		n += len(applied)
+	}
+	return n, nil
+}
+
+// diffContextLines is the number of unchanged lines printed around the changed lines in a diff.
+const diffContextLines = 3
+
+// unifiedDiff returns the unified diff between two versions of a file.
+// NOTE: fixes rewrite links in place, so both versions have the same number of lines, and lines
+// with the same number can be compared without computing the longest common subsequence.
+func unifiedDiff(name, before, after string) string {
+	a := strings.Split(before, "\n")
+	b := strings.Split(after, "\n")
+	if len(a) != len(b) {
+		return ""
+	}
+
+	changed := []int{}
+	for i := range a {
+		if a[i] != b[i] {
+			changed = append(changed, i)
+		}
+	}
+	if len(changed) == 0 {
+		return ""
+	}
+
+	s := fmt.Sprintf("--- a/%s\n+++ b/%s\n", name, name)
+	for len(changed) > 0 {
+		// Group changes whose context lines overlap in the same hunk.
+		last := 0
+		for last+1 < len(changed) && changed[last+1]-changed[last] <= 2*diffContextLines {
+			last++
+		}
+		start := changed[0] - diffContextLines
+		if start < 0 {
+			start = 0
+		}
+		end := changed[last] + diffContextLines
+		if end > len(a)-1 {
+			end = len(a) - 1
+		}
+		// NOTE: a trailing empty line is the end of the last line, not a line of the file.
+		if end == len(a)-1 && a[end] == "" && end > changed[last] {
+			end--
+		}
+
+		s += fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", start+1, end-start+1, start+1, end-start+1)
+		for i := start; i <= end; {
+			if a[i] == b[i] {
+				s += fmt.Sprintf(" %s\n", a[i])
+				i++
+				continue
+			}
+
+			// Print a run of changed lines as removed lines followed by added lines.
+			j := i
+			for j <= end && a[j] != b[j] {
+				s += fmt.Sprintf("-%s\n", a[j])
+				j++
+			}
+			for k := i; k < j; k++ {
+				s += fmt.Sprintf("+%s\n", b[k])
+			}
+			i = j
+		}
+		changed = changed[last+1:]
+	}
+	return s
+}
+
+// fixPage rewrites in place forbidden links in a page using the suggested form, and
+// then updates the page links so the fixed links are checked.
+// NOTE: links that cannot be rewritten, e.g. links in shortcode parameters, keep their forbidden link error.
+func fixPage(p *page) ([]linkFix, error) {
+	fixes := pageFixes(p)
+	if len(fixes) == 0 {
+		return nil, nil
+	}
+
+	info, err := statFile(p.path)
+	if err != nil {
+		return nil, err
+	}
+	content, err := readFile(p.path)
+	if err != nil {
+		return nil, err
+	}
+
+	fixed, applied := applyFixes(string(content), fixes)
+	if len(applied) == 0 {
+		return nil, nil
+	}
+
+	// NOTE: fileSystem is read only, so fixed pages are written to the filesystem of the operating system.
+	if err := os.WriteFile(p.path, []byte(fixed), info.Mode()); err != nil {
+		return nil, err
+	}
+
+	links := p.links
+	p.links = nil
+	for _, l := range links {
+		if l.suggestedFix != "" && isAppliedFix(applied, l) {
+			p.addLink(l.suggestedFix, l.lineNumber)
+			continue
+		}
+		p.links = append(p.links, l)
+	}
+	return applied, nil
+}
+
+// isAppliedFix returns true if the fix of a link is in the list of applied fixes.
+func isAppliedFix(applied []linkFix, l link) bool {
+	for _, f := range applied {
+		if f.lineNumber == l.lineNumber && f.rawLink == l.rawLink {
+			return true
+		}
+	}
+	return false
+}
+
+// pageFixes returns the list of fixes for the forbidden links in a page.
+func pageFixes(p *page) []linkFix {
+	fixes := []linkFix{}
+	for _, l := range p.links {
+		if l.suggestedFix != "" {
+			fixes = append(fixes, linkFix{lineNumber: l.lineNumber, rawLink: l.rawLink, fixedLink: l.suggestedFix})
+		}
+	}
+	return fixes
+}
+
+// applyFixes applies fixes to the content of a page, and returns the fixed content and the list of fixes
+// actually applied.
+// NOTE: only the link address is rewritten, e.g. (addr) for inline links or ]: addr for reference links,
+// so link texts matching the link address are preserved; links in other forms are left unchanged.
+func applyFixes(content string, fixes []linkFix) (string, []linkFix) {
+	lines := strings.Split(content, "\n")
+	var applied []linkFix
+	for _, f := range fixes {
+		i := f.lineNumber - 1
+		if i < 0 || i >= len(lines) {
+			continue
+		}
+
+		// NOTE: all the occurrences of a link in a line are rewritten at once, so the fixes for
+		// the following occurrences are already applied.
+		if isAppliedFix(applied, link{lineNumber: f.lineNumber, rawLink: f.rawLink}) {
+			applied = append(applied, f)
+			continue
+		}
+
+		inlineLink := fmt.Sprintf("(%s)", f.rawLink)
+		if strings.Contains(lines[i], inlineLink) {
+			lines[i] = strings.ReplaceAll(lines[i], inlineLink, fmt.Sprintf("(%s)", f.fixedLink))
+			applied = append(applied, f)
+			continue
+		}
+
+		referenceLink := fmt.Sprintf("]: %s", f.rawLink)
+		if strings.HasSuffix(strings.TrimSpace(lines[i]), referenceLink) {
+			lines[i] = strings.Replace(lines[i], referenceLink, fmt.Sprintf("]: %s", f.fixedLink), 1)
+			applied = append(applied, f)
+		}
+	}
+	return strings.Join(lines, "\n"), applied
+}
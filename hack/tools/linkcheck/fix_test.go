@@ -0,0 +1,277 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_fixPage(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		wantContent string
+		wantFixes   []linkFix
+	}{
+		{
+			name:        "link with .md extension",
+			content:     "Read the [page.md](page.md#anchor).\n",
+			wantContent: "Read the [page.md](page#anchor).\n",
+			wantFixes:   []linkFix{{lineNumber: 1, rawLink: "page.md#anchor", fixedLink: "page#anchor"}},
+		},
+		{
+			name:        "link to _index.md",
+			content:     "Read the [folder](folder/_index.md).\n",
+			wantContent: "Read the [folder](folder/).\n",
+			wantFixes:   []linkFix{{lineNumber: 1, rawLink: "folder/_index.md", fixedLink: "folder/"}},
+		},
+		{
+			name:        "link with ref shortcode",
+			content:     "Read the [page]({{< ref \"page\" >}}).\n",
+			wantContent: "Read the [page](page).\n",
+			wantFixes:   []linkFix{{lineNumber: 1, rawLink: "{{< ref \"page\" >}}", fixedLink: "page"}},
+		},
+		{
+			name:        "reference link with .md extension",
+			content:     "Read the [page].\n\n [page]: page.md\n",
+			wantContent: "Read the [page].\n\n [page]: page\n",
+			wantFixes:   []linkFix{{lineNumber: 3, rawLink: "page.md", fixedLink: "page"}},
+		},
+		{
+			name:        "link with ref shortcode to a forbidden form is not fixed",
+			content:     "Read the [page]({{< ref \"page.md\" >}}).\n",
+			wantContent: "Read the [page]({{< ref \"page.md\" >}}).\n",
+			wantFixes:   nil,
+		},
+		{
+			name:        "valid links are not fixed",
+			content:     "Read the [page](page).\n",
+			wantContent: "Read the [page](page).\n",
+			wantFixes:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			root, err := os.MkdirTemp("", "linkcheck")
+			g.Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(root)
+
+			cancel := setFlags(root, "hugo", []string{"en"})
+			defer cancel()
+
+			path := filepath.Join(root, "hugo", contentFolder, "en/test.md")
+			write(g, path, tt.content)
+
+			p := readMarkdownPage(path)
+			fixes, err := fixPage(&p)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(fixes).To(Equal(tt.wantFixes))
+
+			content, err := os.ReadFile(path)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(string(content)).To(Equal(tt.wantContent))
+
+			// Fixed links must not be reported as forbidden anymore.
+			for _, l := range p.links {
+				if len(tt.wantFixes) > 0 && l.fatalError != nil {
+					g.Expect(l.fatalError.category).ToNot(Equal(forbiddenLinkErrorCategory))
+				}
+			}
+		})
+	}
+}
+
+func Test_fixPage_fileSystem(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	// The page on disk differs from the page in fileSystem, which is the one to be fixed.
+	path := filepath.Join(root, "hugo", contentFolder, "en/test.md")
+	write(g, path, "Stale content.\n")
+
+	fileSystemBefore := fileSystem
+	defer func() { fileSystem = fileSystemBefore }()
+	fileSystem = fstest.MapFS{
+		fsPath(path): {Data: []byte("Read the [page.md](page.md#anchor).\n"), Mode: 0600},
+	}
+
+	p := readMarkdownPage(path)
+	fixes, err := fixPage(&p)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(fixes).To(Equal([]linkFix{{lineNumber: 1, rawLink: "page.md#anchor", fixedLink: "page#anchor"}}))
+
+	content, err := os.ReadFile(path)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(content)).To(Equal("Read the [page.md](page#anchor).\n"))
+}
+
+func Test_fixPage_linkShortcodes(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	resetLinkShortcodes := setLinkShortcodes([]string{"button.href"})
+	defer resetLinkShortcodes()
+
+	// Links in shortcode parameters cannot be rewritten, so they must keep their forbidden link error.
+	content := "{{< button href=\"docs/page.md\" >}}Docs{{< /button >}}\n"
+	path := filepath.Join(root, "hugo", contentFolder, "en/test.md")
+	write(g, path, content)
+
+	p := readMarkdownPage(path)
+	g.Expect(pageFixes(&p)).To(HaveLen(1))
+
+	fixes, err := fixPage(&p)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(fixes).To(BeEmpty())
+
+	got, err := os.ReadFile(path)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(got)).To(Equal(content))
+
+	g.Expect(p.links).To(HaveLen(1))
+	g.Expect(p.links[0].fatalError).ToNot(BeNil())
+	g.Expect(p.links[0].fatalError.category).To(Equal(forbiddenLinkErrorCategory))
+}
+
+func Test_applyFixes(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		fixes       []linkFix
+		wantContent string
+		wantApplied []linkFix
+	}{
+		{
+			name:        "same link twice in a line",
+			content:     "Read [a](page.md) and [b](page.md).",
+			fixes:       []linkFix{{lineNumber: 1, rawLink: "page.md", fixedLink: "page"}, {lineNumber: 1, rawLink: "page.md", fixedLink: "page"}},
+			wantContent: "Read [a](page) and [b](page).",
+			wantApplied: []linkFix{{lineNumber: 1, rawLink: "page.md", fixedLink: "page"}, {lineNumber: 1, rawLink: "page.md", fixedLink: "page"}},
+		},
+		{
+			name:        "link in a shortcode parameter",
+			content:     `{{< button href="page.md" >}}`,
+			fixes:       []linkFix{{lineNumber: 1, rawLink: "page.md", fixedLink: "page"}},
+			wantContent: `{{< button href="page.md" >}}`,
+			wantApplied: nil,
+		},
+		{
+			name:        "line out of range",
+			content:     "Read [a](page.md).",
+			fixes:       []linkFix{{lineNumber: 2, rawLink: "page.md", fixedLink: "page"}},
+			wantContent: "Read [a](page.md).",
+			wantApplied: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			content, applied := applyFixes(tt.content, tt.fixes)
+			g.Expect(content).To(Equal(tt.wantContent))
+			g.Expect(applied).To(Equal(tt.wantApplied))
+		})
+	}
+}
+
+func Test_diffAll(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	content := `# Test
+
+Read the [page](page.md#anchor).
+
+1
+2
+3
+4
+5
+6
+7
+Read the [other page](other).
+Read the [folder](folder/_index.md).
+`
+	path := filepath.Join(root, "hugo", contentFolder, "en/test.md")
+	write(g, path, content)
+	write(g, filepath.Join(root, "hugo", contentFolder, "en/valid.md"), "Read the [page](page).\n")
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	g.Expect(readAll()).To(Succeed())
+
+	var out bytes.Buffer
+	n, err := diffAll(&out)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(n).To(Equal(2))
+	g.Expect(out.String()).To(Equal(`--- a/hugo/content/en/test.md
++++ b/hugo/content/en/test.md
+@@ -1,6 +1,6 @@
+ # Test
+ 
+-Read the [page](page.md#anchor).
++Read the [page](page#anchor).
+ 
+ 1
+ 2
+@@ -10,4 +10,4 @@
+ 6
+ 7
+ Read the [other page](other).
+-Read the [folder](folder/_index.md).
++Read the [folder](folder/).
+`))
+
+	// The page is not modified.
+	got, err := os.ReadFile(path)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(got)).To(Equal(content))
+}
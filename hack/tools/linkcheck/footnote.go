@@ -0,0 +1,73 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Search for footnote references in the format [^label], captures label; a reference followed by : is a definition.
+var footnoteRx = regexp.MustCompile(`\[\^([^\]\s]+)\](:)?`)
+
+// Search for footnote definitions in the format [^label]: text, captures label.
+var footnoteDefinitionRx = regexp.MustCompile(`^\s{0,3}\[\^([^\]\s]+)\]:`)
+
+// readFootnoteWarnings returns warnings for footnote references without a definition and
+// for footnote definitions never referenced in a page.
+// NOTE: footnotes are not links, so they are kept separate from regular and reference links.
+func readFootnoteWarnings(body string) (warnings []warning) {
+	references := map[string]int{}
+	definitions := map[string]int{}
+	referenceLabels := []string{}
+	definitionLabels := []string{}
+
+	for i, line := range strings.Split(body, "\n") {
+		if m := footnoteDefinitionRx.FindStringSubmatch(line); m != nil {
+			if _, ok := definitions[m[1]]; !ok {
+				definitions[m[1]] = i + 1
+				definitionLabels = append(definitionLabels, m[1])
+			}
+			line = line[len(m[0]):]
+		}
+		for _, m := range footnoteRx.FindAllStringSubmatch(line, -1) {
+			if m[2] != "" {
+				continue
+			}
+			if _, ok := references[m[1]]; !ok {
+				references[m[1]] = i + 1
+				referenceLabels = append(referenceLabels, m[1])
+			}
+		}
+	}
+
+	for _, label := range referenceLabels {
+		if _, ok := definitions[label]; !ok {
+			warnings = append(warnings, warning{lineNumber: references[label], message: fmt.Sprintf("footnote [^%s] is not defined", label)})
+		}
+	}
+	for _, label := range definitionLabels {
+		if _, ok := references[label]; !ok {
+			warnings = append(warnings, warning{lineNumber: definitions[label], message: fmt.Sprintf("footnote [^%s] is defined but never referenced", label)})
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,67 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_readFootnoteWarnings(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []warning
+	}{
+		{
+			name: "no footnotes",
+			body: "some text with a [link](a)\n",
+			want: nil,
+		},
+		{
+			name: "footnotes referenced and defined",
+			body: "some text[^1] and more[^note].\n\n[^1]: first\n[^note]: second, see [^1]\n",
+			want: nil,
+		},
+		{
+			name: "undefined footnote",
+			body: "some text[^1] and more[^2].\n\n[^1]: first\n",
+			want: []warning{{lineNumber: 1, message: "footnote [^2] is not defined"}},
+		},
+		{
+			name: "unused definition",
+			body: "some text[^1].\n\n[^1]: first\n[^2]: second\n",
+			want: []warning{{lineNumber: 4, message: "footnote [^2] is defined but never referenced"}},
+		},
+		{
+			name: "definitions referencing only themselves are unused",
+			body: "some text.\n\n[^1]: first\n",
+			want: []warning{{lineNumber: 3, message: "footnote [^1] is defined but never referenced"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(readFootnoteWarnings(tt.body)).To(Equal(tt.want))
+		})
+	}
+}
@@ -0,0 +1,139 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// yamlFrontMatterSeparator delimits YAML front matter at the beginning of a markdown page.
+const yamlFrontMatterSeparator = "---"
+
+// frontMatter defines the subset of the hugo front matter used by linkcheck.
+type frontMatter struct {
+	// Draft is true for pages that are not published.
+	Draft bool `yaml:"draft"`
+
+	// PublishDate is the date after which the page is published.
+	// NOTE: publish dates are kept as defined in the page, and parsed only when --check-publish-dates is set.
+	PublishDate string `yaml:"publishDate"`
+
+	// ExpiryDate is the date after which the page is no longer published.
+	ExpiryDate string `yaml:"expiryDate"`
+
+	// Aliases are additional paths the page is served from.
+	Aliases []string `yaml:"aliases"`
+
+	// Title is the title of the page, used by permalinks.
+	Title string `yaml:"title"`
+
+	// Slug is the last segment of the page url, used by permalinks.
+	Slug string `yaml:"slug"`
+
+	// Date is the date of the page, used by permalinks.
+	Date frontMatterDate `yaml:"date"`
+}
+
+// frontMatterDateLayouts defines the date layouts supported in front matter.
+var frontMatterDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// frontMatterDate is a date defined in front matter.
+type frontMatterDate struct {
+	time.Time
+}
+
+// UnmarshalYAML parses a front matter date using one of the supported layouts.
+func (d *frontMatterDate) UnmarshalYAML(value *yaml.Node) error {
+	t, err := parseFrontMatterDate(value.Value)
+	if err != nil {
+		return err
+	}
+	d.Time = t
+	return nil
+}
+
+// parseFrontMatterDate parses a front matter date using one of the supported layouts; an empty date is parsed
+// as the zero time.
+func parseFrontMatterDate(value string) (time.Time, error) {
+	s := strings.TrimSpace(value)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range frontMatterDateLayouts {
+		t, err := time.Parse(layout, s)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.Errorf("invalid date %q", s)
+}
+
+// readPublishDates parses the publish and expiry dates defined in the page front matter.
+// NOTE: hugo accepts more date layouts than linkcheck, so dates that cannot be parsed are reported as page
+// warnings, and the page is checked as if they were not defined.
+func (p *page) readPublishDates() {
+	var err error
+	if p.publishDate, err = parseFrontMatterDate(p.frontMatter.PublishDate); err != nil {
+		p.warnings = append(p.warnings, warning{message: fmt.Sprintf("publishDate is not checked: %v", err)})
+	}
+	if p.expiryDate, err = parseFrontMatterDate(p.frontMatter.ExpiryDate); err != nil {
+		p.warnings = append(p.warnings, warning{message: fmt.Sprintf("expiryDate is not checked: %v", err)})
+	}
+}
+
+// readFrontMatter reads the front matter at the beginning of a markdown page, if any.
+// TODO: support TOML and JSON front matter.
+func readFrontMatter(content string) (frontMatter, error) {
+	fm := frontMatter{}
+
+	data, err := readFrontMatterYAML(content)
+	if err != nil || data == "" {
+		return fm, err
+	}
+	if err := yaml.Unmarshal([]byte(data), &fm); err != nil {
+		return fm, errors.Wrap(err, "failed to parse YAML front matter")
+	}
+	return fm, nil
+}
+
+// readFrontMatterYAML returns the YAML front matter at the beginning of a markdown page, if any.
+func readFrontMatterYAML(content string) (string, error) {
+	lines := strings.Split(content, "\n")
+	if strings.TrimSpace(lines[0]) != yamlFrontMatterSeparator {
+		return "", nil
+	}
+
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == yamlFrontMatterSeparator {
+			return strings.Join(lines[1:i], "\n"), nil
+		}
+	}
+	return "", errors.New("YAML front matter is not terminated")
+}
@@ -0,0 +1,144 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_readFrontMatter(t *testing.T) {
+	tests := []struct {
+		name            string
+		content         string
+		wantFrontMatter frontMatter
+		wantErr         bool
+	}{
+		{
+			name:            "page without front matter",
+			content:         "# Title\n",
+			wantFrontMatter: frontMatter{},
+		},
+		{
+			name:            "page with front matter",
+			content:         "---\ntitle: \"Title\"\n---\n# Title\n",
+			wantFrontMatter: frontMatter{Title: "Title"},
+		},
+		{
+			name:            "draft page",
+			content:         "---\ntitle: \"Title\"\ndraft: true\n---\n# Title\n",
+			wantFrontMatter: frontMatter{Title: "Title", Draft: true},
+		},
+		{
+			name:    "page with slug and date",
+			content: "---\nslug: my-post\ndate: 2022-01-02\n---\n# Title\n",
+			wantFrontMatter: frontMatter{
+				Slug: "my-post",
+				Date: frontMatterDate{time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name:    "page with publish and expiry dates",
+			content: "---\npublishDate: 2022-01-02\nexpiryDate: \"2023-01-02T10:00:00Z\"\n---\n# Title\n",
+			wantFrontMatter: frontMatter{
+				PublishDate: "2022-01-02",
+				ExpiryDate:  "2023-01-02T10:00:00Z",
+			},
+		},
+		{
+			name:            "page with aliases",
+			content:         "---\naliases:\n- /old\n- old-page\n---\n# Title\n",
+			wantFrontMatter: frontMatter{Aliases: []string{"/old", "old-page"}},
+		},
+		{
+			name:            "publish date in a layout not supported by linkcheck",
+			content:         "---\npublishDate: tomorrow\n---\n# Title\n",
+			wantFrontMatter: frontMatter{PublishDate: "tomorrow"},
+		},
+		{
+			name:    "invalid date",
+			content: "---\ndate: tomorrow\n---\n# Title\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid front matter",
+			content: "---\ndraft: [\n---\n# Title\n",
+			wantErr: true,
+		},
+		{
+			name:    "front matter not terminated",
+			content: "---\ndraft: true\n# Title\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			fm, err := readFrontMatter(tt.content)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(fm).To(Equal(tt.wantFrontMatter))
+		})
+	}
+}
+
+func Test_readPublishDates(t *testing.T) {
+	tests := []struct {
+		name            string
+		frontMatter     frontMatter
+		wantPublishDate time.Time
+		wantExpiryDate  time.Time
+		wantWarnings    []warning
+	}{
+		{
+			name:        "no dates",
+			frontMatter: frontMatter{},
+		},
+		{
+			name:            "valid dates",
+			frontMatter:     frontMatter{PublishDate: "2022-01-02", ExpiryDate: "2023-01-02T10:00:00Z"},
+			wantPublishDate: time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC),
+			wantExpiryDate:  time.Date(2023, 1, 2, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name:           "dates in a layout not supported by linkcheck",
+			frontMatter:    frontMatter{PublishDate: "Jan 2, 2022", ExpiryDate: "2023-01-02"},
+			wantExpiryDate: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC),
+			wantWarnings:   []warning{{message: "publishDate is not checked: invalid date \"Jan 2, 2022\""}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			p := page{frontMatter: tt.frontMatter}
+			p.readPublishDates()
+			g.Expect(p.publishDate).To(Equal(tt.wantPublishDate))
+			g.Expect(p.expiryDate).To(Equal(tt.wantExpiryDate))
+			g.Expect(p.warnings).To(Equal(tt.wantWarnings))
+		})
+	}
+}
@@ -0,0 +1,119 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// printReportGCC prints the result of linkcheck for all pages in the gcc format, so editors can jump to each error.
+// NOTE: when streaming, the result of each page is already printed by linkcheckAll, and only the warnings
+// added afterwards are printed.
+func printReportGCC(w io.Writer) {
+	if currentLogLevel() <= quietLogLevel {
+		return
+	}
+	for i := range pages {
+		p := pages[i]
+		if *stream {
+			if warnings := p.unstreamedWarnings(); p.fatalError == nil && len(warnings) > 0 {
+				printWarningsGCC(w, gccPath(p), gccLines(p), warnings)
+			}
+			continue
+		}
+		printPageGCC(w, p)
+	}
+}
+
+// printPageGCC prints the errors and warnings of a page, one for each line in the path:line:col: severity: message format.
+// NOTE: the path is relative to the root, errors and warnings on the whole page are reported on the first line, and
+// columns are computed looking for the raw link in the page, defaulting to the first column.
+func printPageGCC(w io.Writer, p *page) {
+	path := gccPath(p)
+
+	if p.fatalError != nil {
+		if severityFor(p.fatalError.category) != ignoreSeverity {
+			fmt.Fprintf(w, "%s:1:1: %s: %s\n", path, gccSeverity(p.fatalError.category), p.fatalError)
+		}
+		return
+	}
+
+	lines := gccLines(p)
+	for _, l := range p.links {
+		if l.fatalError == nil || severityFor(l.fatalError.category) == ignoreSeverity {
+			continue
+		}
+		fmt.Fprintf(w, "%s:%d:%d: %s: %s: %s\n", path, l.lineNumber, column(lines, l.lineNumber, l.rawLink), gccSeverity(l.fatalError.category), l.rawLink, l.fatalError)
+	}
+	printWarningsGCC(w, path, lines, p.warnings)
+}
+
+// printWarningsGCC prints warnings of a page, one for each line in the path:line:col: warning: message format.
+func printWarningsGCC(w io.Writer, path string, lines []string, warnings []warning) {
+	for _, pw := range warnings {
+		switch {
+		case pw.rawLink != "":
+			fmt.Fprintf(w, "%s:%d:%d: warning: %s: %s\n", path, pw.lineNumber, column(lines, pw.lineNumber, pw.rawLink), pw.rawLink, pw.message)
+		case pw.lineNumber > 0:
+			fmt.Fprintf(w, "%s:%d:1: warning: %s\n", path, pw.lineNumber, pw.message)
+		default:
+			fmt.Fprintf(w, "%s:1:1: warning: %s\n", path, pw.message)
+		}
+	}
+}
+
+// gccPath returns the path of a page relative to the root, as printed in the gcc format.
+func gccPath(p *page) string {
+	if rel, err := filepath.Rel(*root, p.path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return p.path
+}
+
+// gccLines returns the lines of a page, used to compute the columns printed in the gcc format.
+func gccLines(p *page) []string {
+	content, _ := readFile(p.path)
+	return strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+}
+
+// gccSeverity returns the severity used when printing errors in a category in the gcc format.
+func gccSeverity(c errorCategory) string {
+	return strings.ToLower(severityLabel(c))
+}
+
+// column returns the column, starting from 1, where a link is found in a line of a page, or 1 if not found.
+// NOTE: the link address, e.g. (addr) for inline links or ]: addr for reference links, is searched first, so link
+// texts matching the link address are skipped; columns are counted in characters, not bytes, as editors do.
+func column(lines []string, lineNumber int, rawLink string) int {
+	if lineNumber < 1 || lineNumber > len(lines) {
+		return 1
+	}
+	line := lines[lineNumber-1]
+	for _, prefix := range []string{"(", "]: ", ""} {
+		if i := strings.Index(line, prefix+rawLink); i >= 0 {
+			return utf8.RuneCountInString(line[:i+len(prefix)]) + 1
+		}
+	}
+	return 1
+}
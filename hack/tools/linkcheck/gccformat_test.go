@@ -0,0 +1,98 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_printReport_gccFormat(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	formatBefore := *format
+	defer func() { *format = formatBefore }()
+	*format = formatGCC
+
+	maxLineLengthBefore := *maxLineLength
+	requireSingleH1Before := *requireSingleH1
+	forbidLinkTextBefore := *forbidLinkText
+	defer func() {
+		*maxLineLength = maxLineLengthBefore
+		*requireSingleH1 = requireSingleH1Before
+		*forbidLinkText = forbidLinkTextBefore
+	}()
+	*maxLineLength = 50
+	*requireSingleH1 = true
+	*forbidLinkText = []string{"click here"}
+
+	// Warnings are reported on the line they are about, at the column of the link they are about, if any.
+	contentDir := filepath.Join(root, "hugo", contentFolder)
+	write(g, filepath.Join(contentDir, "en/a.md"), "# A\n\nSee [b](b) and [missing](missing).\nSee ⇒ [anchor](#missing).\nFor details [click here](b).\n")
+	write(g, filepath.Join(contentDir, "en/b.md"), "# B\n\nSee [a](a). See [unterminated](a.\n\nSee [a](a)"+strings.Repeat(" and more", 5)+".\n")
+	write(g, filepath.Join(contentDir, "en/d.md"), "No headings.\n")
+	write(g, filepath.Join(contentDir, "it/c.md"), "# C\n")
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), &bytes.Buffer{})).To(Succeed())
+
+	var out bytes.Buffer
+	g.Expect(printReport(&out)).To(Succeed())
+	g.Expect(out.String()).To(Equal(`hugo/content/en/a.md:3:26: error: missing: the link resolves to /hugo/content/en/missing.md which does not exist
+hugo/content/en/a.md:4:16: error: #missing: #missing does exists in <site>/content/en/a.md
+hugo/content/en/a.md:5:26: warning: b: link text "click here" does not describe the target of the link, e.g. for screen readers
+hugo/content/en/b.md:3:1: warning: malformed link [unterminated](a. is missing a closing parenthesis
+hugo/content/en/b.md:5:1: warning: the line is 56 bytes long, more than the 50 allowed; only [text](addr) links and ![alt](addr) images are checked
+hugo/content/en/d.md:1:1: warning: the page does not have an H1 header
+hugo/content/it/c.md:1:1: error: hugo page /it/c.md does not belong to one of the know languages: en
+`))
+}
+
+func Test_column(t *testing.T) {
+	g := NewWithT(t)
+
+	lines := []string{"# A", "See ⇒ [anchor](#missing)."}
+	g.Expect(column(lines, 2, "#missing")).To(Equal(16))
+	g.Expect(column([]string{"[missing](missing)"}, 1, "missing")).To(Equal(11))
+	g.Expect(column([]string{"[missing]: missing"}, 1, "missing")).To(Equal(12))
+	g.Expect(column(lines, 2, "not-found")).To(Equal(1))
+	g.Expect(column(lines, 0, "#missing")).To(Equal(1))
+	g.Expect(column(lines, 3, "#missing")).To(Equal(1))
+}
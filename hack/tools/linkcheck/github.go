@@ -0,0 +1,134 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const githubHost = "github.com"
+
+// githubAPIURL is the url of the GitHub API.
+var githubAPIURL = "https://api.github.com"
+
+// loadExternalResolvers loads the resolvers used for checking external links to specific services.
+func loadExternalResolvers() {
+	externalResolvers = nil
+	if *githubToken != "" {
+		externalResolvers = append(externalResolvers, &githubBlobResolver{apiURL: githubAPIURL, token: *githubToken})
+	}
+}
+
+// githubBlobResolver checks links to GitHub blobs, e.g. https://github.com/org/repo/blob/main/file.go#L10, using the
+// GitHub API to verify the file exists at the ref, and the lines referenced by the fragment, if any, are within the file.
+// NOTE: the ref is assumed to be a single path segment, e.g. main, v1.6.0 or a commit sha; branches with a slash
+// in the name are not supported.
+type githubBlobResolver struct {
+	// apiURL is the url of the GitHub API.
+	apiURL string
+
+	// token used to authenticate to the GitHub API.
+	token string
+}
+
+// githubBlob defines the parts of a GitHub blob url, e.g. https://github.com/org/repo/blob/main/file.go.
+type githubBlob struct {
+	owner string
+	repo  string
+	ref   string
+	path  string
+}
+
+// parseGitHubBlob returns the parts of a GitHub blob url, and whether the url is a GitHub blob url.
+func parseGitHubBlob(u *url.URL) (githubBlob, bool) {
+	if !strings.EqualFold(u.Hostname(), githubHost) {
+		return githubBlob{}, false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(u.Path, "/"), "/", 5)
+	if len(parts) != 5 || parts[2] != "blob" {
+		return githubBlob{}, false
+	}
+	for _, p := range parts {
+		if p == "" {
+			return githubBlob{}, false
+		}
+	}
+	return githubBlob{owner: parts[0], repo: parts[1], ref: parts[3], path: strings.TrimSuffix(parts[4], "/")}, true
+}
+
+func (r *githubBlobResolver) matches(u *url.URL) bool {
+	_, ok := parseGitHubBlob(u)
+	return ok
+}
+
+func (r *githubBlobResolver) resolve(ctx context.Context, u *url.URL) string {
+	blob, _ := parseGitHubBlob(u)
+
+	first, last, hasLines := parseLineRange(u.Fragment)
+	if hasLines && (first < 1 || last < first) {
+		return fmt.Sprintf("%s%s is not a valid line range, lines start from 1 and the first line must not be after the last line", anchorSeparator, u.Fragment)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeoutFor(githubHost))
+	defer cancel()
+
+	status, content, err := r.getContent(ctx, blob)
+	if err != nil {
+		return fmt.Sprintf("error checking GitHub blob: %v", err)
+	}
+	switch {
+	case status == http.StatusNotFound:
+		return fmt.Sprintf("%s does not exist at %s in %s/%s", blob.path, blob.ref, blob.owner, blob.repo)
+	case status >= http.StatusBadRequest:
+		return fmt.Sprintf("the GitHub API returned HTTP status %d", status)
+	}
+
+	if lines := countLines(content); hasLines && last > lines {
+		return fmt.Sprintf("%s%s is out of range, %s has %d lines at %s", anchorSeparator, u.Fragment, blob.path, lines, blob.ref)
+	}
+	return ""
+}
+
+// getContent gets the raw content of a blob from the GitHub API.
+func (r *githubBlobResolver) getContent(ctx context.Context, blob githubBlob) (int, []byte, error) {
+	contentURL := fmt.Sprintf("%s/repos/%s/%s/contents/%s?ref=%s", strings.TrimSuffix(r.apiURL, "/"), blob.owner, blob.repo, blob.path, url.QueryEscape(blob.ref))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, contentURL, http.NoBody)
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github.raw")
+	req.Header.Set("Authorization", "Bearer "+r.token)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+	return resp.StatusCode, content, nil
+}
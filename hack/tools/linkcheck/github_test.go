@@ -0,0 +1,201 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_parseGitHubBlob(t *testing.T) {
+	tests := []struct {
+		url      string
+		wantBlob githubBlob
+		wantOk   bool
+	}{
+		{
+			url:      "https://github.com/kubernetes-sigs/cluster-api/blob/main/api/v1beta1/cluster_types.go#L10",
+			wantBlob: githubBlob{owner: "kubernetes-sigs", repo: "cluster-api", ref: "main", path: "api/v1beta1/cluster_types.go"},
+			wantOk:   true,
+		},
+		{
+			url:      "https://GitHub.com/kubernetes-sigs/cluster-api/blob/v1.6.0/Makefile",
+			wantBlob: githubBlob{owner: "kubernetes-sigs", repo: "cluster-api", ref: "v1.6.0", path: "Makefile"},
+			wantOk:   true,
+		},
+		{
+			url:    "https://github.com/kubernetes-sigs/cluster-api/tree/main/docs",
+			wantOk: false,
+		},
+		{
+			url:    "https://github.com/kubernetes-sigs/cluster-api/blob/main",
+			wantOk: false,
+		},
+		{
+			url:    "https://github.com/kubernetes-sigs/cluster-api/issues/1",
+			wantOk: false,
+		},
+		{
+			url:    "https://gitlab.com/org/repo/blob/main/file.go",
+			wantOk: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			g := NewWithT(t)
+
+			blob, ok := parseGitHubBlob(mustParseUrl(tt.url))
+			g.Expect(ok).To(Equal(tt.wantOk))
+			g.Expect(blob).To(Equal(tt.wantBlob))
+		})
+	}
+}
+
+func Test_githubBlobResolver_resolve(t *testing.T) {
+	requests := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RequestURI())
+		switch {
+		case r.Header.Get("Authorization") != "Bearer secret":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.Header.Get("Accept") != "application/vnd.github.raw":
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+		case r.URL.RequestURI() == "/repos/org/repo/contents/docs/file.go?ref=main":
+			_, _ = w.Write([]byte("package main\n\nfunc main() {\n}\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		token        string
+		url          string
+		want         string
+		wantRequests []string
+	}{
+		{
+			name:         "blob without lines",
+			token:        "secret",
+			url:          "https://github.com/org/repo/blob/main/docs/file.go",
+			want:         "",
+			wantRequests: []string{"/repos/org/repo/contents/docs/file.go?ref=main"},
+		},
+		{
+			name:         "blob with lines within the file",
+			token:        "secret",
+			url:          "https://github.com/org/repo/blob/main/docs/file.go#L3-L4",
+			want:         "",
+			wantRequests: []string{"/repos/org/repo/contents/docs/file.go?ref=main"},
+		},
+		{
+			name:         "blob with a fragment not referencing lines",
+			token:        "secret",
+			url:          "https://github.com/org/repo/blob/main/docs/file.go#main",
+			want:         "",
+			wantRequests: []string{"/repos/org/repo/contents/docs/file.go?ref=main"},
+		},
+		{
+			name:         "blob with lines out of range",
+			token:        "secret",
+			url:          "https://github.com/org/repo/blob/main/docs/file.go#L10",
+			want:         "#L10 is out of range, docs/file.go has 4 lines at main",
+			wantRequests: []string{"/repos/org/repo/contents/docs/file.go?ref=main"},
+		},
+		{
+			name:         "blob with an invalid line range",
+			token:        "secret",
+			url:          "https://github.com/org/repo/blob/main/docs/file.go#L4-L3",
+			want:         "#L4-L3 is not a valid line range, lines start from 1 and the first line must not be after the last line",
+			wantRequests: []string{},
+		},
+		{
+			name:         "blob moved",
+			token:        "secret",
+			url:          "https://github.com/org/repo/blob/main/file.go#L1",
+			want:         "file.go does not exist at main in org/repo",
+			wantRequests: []string{"/repos/org/repo/contents/file.go?ref=main"},
+		},
+		{
+			name:         "blob at another ref",
+			token:        "secret",
+			url:          "https://github.com/org/repo/blob/v1.0.0/docs/file.go",
+			want:         "docs/file.go does not exist at v1.0.0 in org/repo",
+			wantRequests: []string{"/repos/org/repo/contents/docs/file.go?ref=v1.0.0"},
+		},
+		{
+			name:         "wrong token",
+			token:        "wrong",
+			url:          "https://github.com/org/repo/blob/main/docs/file.go",
+			want:         "the GitHub API returned HTTP status 401",
+			wantRequests: []string{"/repos/org/repo/contents/docs/file.go?ref=main"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			requests = []string{}
+			r := &githubBlobResolver{apiURL: server.URL, token: tt.token}
+			g.Expect(r.matches(mustParseUrl(tt.url))).To(BeTrue())
+			g.Expect(r.resolve(context.Background(), mustParseUrl(tt.url))).To(Equal(tt.want))
+			g.Expect(requests).To(Equal(tt.wantRequests))
+		})
+	}
+}
+
+func Test_checkExternalLink_githubBlobs(t *testing.T) {
+	g := NewWithT(t)
+
+	apiRequests := 0
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiRequests++
+		_, _ = w.Write([]byte("line 1\nline 2\n"))
+	}))
+	defer api.Close()
+
+	githubTokenBefore, githubAPIURLBefore := *githubToken, githubAPIURL
+	defer func() {
+		*githubToken, githubAPIURL = githubTokenBefore, githubAPIURLBefore
+		loadExternalResolvers()
+	}()
+	externalResults = map[string]*externalResult{}
+	defer func() { externalResults = map[string]*externalResult{} }()
+
+	// Without a token, GitHub blobs are not checked with the GitHub API.
+	*githubToken = ""
+	loadExternalResolvers()
+	g.Expect(resolverFor(mustParseUrl("https://github.com/org/repo/blob/main/file.go#L3"))).To(BeNil())
+
+	*githubToken = "secret"
+	githubAPIURL = api.URL
+	loadExternalResolvers()
+
+	// Links to the same blob with different lines are checked separately, while identical links are checked once.
+	g.Expect(checkExternalLink(context.Background(), mustParseUrl("https://github.com/org/repo/blob/main/file.go#L2"))).To(BeEmpty())
+	g.Expect(checkExternalLink(context.Background(), mustParseUrl("https://github.com/org/repo/blob/main/file.go#L2"))).To(BeEmpty())
+	g.Expect(checkExternalLink(context.Background(), mustParseUrl("https://github.com/org/repo/blob/main/file.go#L3"))).To(Equal("#L3 is out of range, file.go has 2 lines at main"))
+	g.Expect(apiRequests).To(Equal(2))
+}
@@ -0,0 +1,37 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"regexp"
+)
+
+// Search for html elements with an id attribute, e.g. <h3 id="anchor"> or <a id="anchor">, or anchors with a
+// name attribute, e.g. <a name="anchor">, captures the id or name value.
+// NOTE: this is how anchors are defined in generated API references, e.g. <a id="cluster.x-k8s.io/v1beta1.ClusterSpec">.
+var htmlAnchorRx = regexp.MustCompile(`<(?:[a-zA-Z][\w\-]*(?:\s[^>]*)?\sid|a(?:\s[^>]*)?\sname)\s*=\s*["']([^"']+)["']`)
+
+// readHTMLAnchors returns the anchors defined by html elements in a page.
+func readHTMLAnchors(body string) (anchors []string) {
+	for _, m := range htmlAnchorRx.FindAllStringSubmatch(body, -1) {
+		anchors = append(anchors, m[1])
+	}
+	return
+}
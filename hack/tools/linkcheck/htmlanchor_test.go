@@ -0,0 +1,117 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_readHTMLAnchors(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantAnchors []string
+	}{
+		{
+			name:        "no html anchors",
+			body:        "# Title\n\n<div class=\"note\">text</div>\n",
+			wantAnchors: nil,
+		},
+		{
+			name:        "elements with id",
+			body:        "<h3 id=\"cluster.x-k8s.io/v1beta1.ClusterSpec\">ClusterSpec</h3>\n<a href=\"#x\" id='anchor'></a>\n",
+			wantAnchors: []string{"cluster.x-k8s.io/v1beta1.ClusterSpec", "anchor"},
+		},
+		{
+			name:        "anchors with name",
+			body:        "<a name=\"old-anchor\"></a>\n<input name=\"not-an-anchor\">\n",
+			wantAnchors: []string{"old-anchor"},
+		},
+		{
+			name:        "attributes ending with id or name",
+			body:        "<div data-id=\"x\"></div><a data-name=\"y\"></a>\n",
+			wantAnchors: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(readHTMLAnchors(tt.body)).To(Equal(tt.wantAnchors))
+		})
+	}
+}
+
+func Test_readAllAndLinkcheckAll_apiReferenceTable(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	// Generated API references have huge table rows, with many links to field ids in the same page.
+	var row strings.Builder
+	row.WriteString("| <a id=\"cluster.x-k8s.io/v1beta1.Cluster\">Cluster</a> |")
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&row, " `field%d` <em>[ClusterSpec](#cluster.x-k8s.io/v1beta1.ClusterSpec)</em> description of field %d |", i, i)
+	}
+	row.WriteString(" [Missing](#cluster.x-k8s.io/v1beta1.Missing) |")
+
+	contentDir := filepath.Join(root, "hugo", contentFolder)
+	write(g, filepath.Join(contentDir, "en/_index.md"), "# Home\n")
+	write(g, filepath.Join(contentDir, "en/api.md"), fmt.Sprintf(`# API reference
+
+| Type | Fields |
+|------|--------|
+%s
+
+<h3 id="cluster.x-k8s.io/v1beta1.ClusterSpec">ClusterSpec</h3>
+`, row.String()))
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	start := time.Now()
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+	g.Expect(time.Since(start)).To(BeNumerically("<", 10*time.Second))
+
+	p := pagesByPath[filepath.Join(contentDir, "en/api.md")]
+	g.Expect(p.links).To(HaveLen(2001))
+	g.Expect(collectErrors(root)).To(Equal([]string{
+		"/hugo/content/en/api.md:5: #cluster.x-k8s.io/v1beta1.Missing: #cluster.x-k8s.io/v1beta1.Missing does exists in <site>/content/en/api.md",
+	}))
+}
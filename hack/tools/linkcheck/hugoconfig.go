@@ -0,0 +1,179 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+)
+
+const hugoConfigFile = "config.toml"
+
+// hugoConfig defines the subset of the hugo website config used by linkcheck.
+// TODO: support config.yaml/config.json and config directories.
+type hugoConfig struct {
+	ContentDir             string                    `toml:"contentDir"`
+	DefaultContentLanguage string                    `toml:"defaultContentLanguage"`
+	Menu                   map[string][]menuEntry    `toml:"menu"`
+	Languages              map[string]languageConfig `toml:"languages"`
+	Permalinks             map[string]string         `toml:"permalinks"`
+	UglyURLs               bool                      `toml:"uglyURLs"`
+	Taxonomies             map[string]string         `toml:"taxonomies"`
+}
+
+// languageConfig defines the subset of a language config in the hugo website config used by linkcheck.
+type languageConfig struct {
+	ContentDir string `toml:"contentDir"`
+	Weight     int    `toml:"weight"`
+}
+
+// defaultContentLanguage is the language used by hugo when defaultContentLanguage is not set.
+const defaultContentLanguage = "en"
+
+// menuEntry defines a menu entry in the hugo website config.
+type menuEntry struct {
+	Name string `toml:"name"`
+	URL  string `toml:"url"`
+}
+
+// hugoConfigPath returns the path of the hugo website config.
+// NOTE: --hugo-config is converted to an absolute path at startup.
+func hugoConfigPath() string {
+	if *hugoConfigFlag != "" {
+		return *hugoConfigFlag
+	}
+	return filepath.Join(*root, *hugoFolder, hugoConfigFile)
+}
+
+// readHugoConfig reads the hugo website config.
+func readHugoConfig() (hugoConfig, error) {
+	config := hugoConfig{}
+	content, err := readFile(hugoConfigPath())
+	if err != nil {
+		return config, errors.Wrapf(err, "failed to read hugo config")
+	}
+	if err := toml.Unmarshal(content, &config); err != nil {
+		return config, errors.Wrapf(err, "failed to parse hugo config")
+	}
+	return config, nil
+}
+
+// languageContentDirs holds the content dir of the languages that define one in the hugo website config.
+var languageContentDirs map[string]string
+
+// loadLanguageContentDirs reads the content dir of each language from the hugo website config, if any.
+func loadLanguageContentDirs() error {
+	languageContentDirs = nil
+	if *hugoFolder == "" {
+		return nil
+	}
+
+	config, err := readHugoConfig()
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	for l, c := range config.Languages {
+		if c.ContentDir == "" {
+			continue
+		}
+		if languageContentDirs == nil {
+			languageContentDirs = map[string]string{}
+		}
+		languageContentDirs[l] = filepath.Clean(c.ContentDir)
+	}
+
+	// The top level contentDir applies to single language websites only, because otherwise all the
+	// languages would share the same content dir, which is not supported by linkcheck.
+	if languages := config.languages(); config.ContentDir != "" && len(languages) == 1 {
+		if _, ok := languageContentDirs[languages[0]]; !ok {
+			languageContentDirs = map[string]string{languages[0]: filepath.Clean(config.ContentDir)}
+		}
+	}
+	return nil
+}
+
+// languages returns the languages of the hugo website, starting from the default content language
+// followed by the other languages sorted by weight.
+func (c hugoConfig) languages() []string {
+	defaultLanguage := c.DefaultContentLanguage
+	if defaultLanguage == "" {
+		defaultLanguage = defaultContentLanguage
+	}
+
+	languages := []string{}
+	for l := range c.Languages {
+		if l != defaultLanguage {
+			languages = append(languages, l)
+		}
+	}
+	sort.Slice(languages, func(i, j int) bool {
+		wi, wj := c.Languages[languages[i]].Weight, c.Languages[languages[j]].Weight
+		if wi != wj {
+			return wi < wj
+		}
+		return languages[i] < languages[j]
+	})
+	return append([]string{defaultLanguage}, languages...)
+}
+
+// applyHugoConfig derives the hugo folder and the hugo languages from the --hugo-config file.
+// NOTE: --hugo-folder and --hugo-languages, if explicitly set, take precedence.
+func applyHugoConfig() error {
+	path, err := filepath.Abs(*hugoConfigFlag)
+	if err != nil {
+		return errors.Wrapf(err, "failed to convert hugo config to an absolute path")
+	}
+	*hugoConfigFlag = path
+
+	folder, err := filepath.Rel(*root, filepath.Dir(path))
+	if err != nil || folder == ".." || strings.HasPrefix(folder, ".."+string(filepath.Separator)) {
+		return errors.Errorf("hugo config %s must be inside root %s", path, *root)
+	}
+
+	config, err := readHugoConfig()
+	if err != nil {
+		return err
+	}
+
+	if !pflag.CommandLine.Changed("hugo-folder") {
+		*hugoFolder = folder
+	}
+	if !pflag.CommandLine.Changed("hugo-languages") {
+		*hugoLanguages = config.languages()
+	}
+	return nil
+}
+
+// languageContentDir returns the content dir of a language, defaulting to content/<lang>.
+func languageContentDir(language string) string {
+	if dir, ok := languageContentDirs[language]; ok {
+		return filepath.Join(*root, *hugoFolder, dir)
+	}
+	return filepath.Join(*root, *hugoFolder, contentFolder, language)
+}
@@ -0,0 +1,196 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_readHugoConfig(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	_, err = readHugoConfig()
+	g.Expect(err).To(HaveOccurred())
+
+	write(g, filepath.Join(root, "hugo", hugoConfigFile), `[[menu.main]]
+name = "Docs"
+url = "/docs/"
+`)
+
+	config, err := readHugoConfig()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(config.Menu).To(Equal(map[string][]menuEntry{
+		"main": {{Name: "Docs", URL: "/docs/"}},
+	}))
+
+	write(g, filepath.Join(root, "hugo", hugoConfigFile), `[[menu.main]`)
+
+	_, err = readHugoConfig()
+	g.Expect(err).To(HaveOccurred())
+}
+
+func Test_loadLanguageContentDirs(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en", "it"})
+	defer cancel()
+	defer func() { languageContentDirs = nil }()
+
+	// No config, defaults to content/<lang>.
+	g.Expect(loadLanguageContentDirs()).To(Succeed())
+	g.Expect(languageContentDir("en")).To(Equal(filepath.Join(root, "hugo", "content", "en")))
+	g.Expect(languageContentDir("it")).To(Equal(filepath.Join(root, "hugo", "content", "it")))
+
+	write(g, filepath.Join(root, "hugo", hugoConfigFile), `[languages]
+[languages.en]
+title = "English"
+[languages.it]
+contentDir = "content/it/docs/"
+`)
+
+	g.Expect(loadLanguageContentDirs()).To(Succeed())
+	g.Expect(languageContentDir("en")).To(Equal(filepath.Join(root, "hugo", "content", "en")))
+	g.Expect(languageContentDir("it")).To(Equal(filepath.Join(root, "hugo", "content", "it", "docs")))
+
+	write(g, filepath.Join(root, "hugo", hugoConfigFile), `[languages`)
+
+	g.Expect(loadLanguageContentDirs()).ToNot(Succeed())
+}
+
+func Test_readAllAndLinkcheckAll_languageContentDirs(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en", "it"})
+	defer cancel()
+
+	write(g, filepath.Join(root, "hugo", hugoConfigFile), `[languages.en]
+contentDir = "content/en/docs"
+[languages.it]
+contentDir = "content-it"
+`)
+	g.Expect(loadLanguageContentDirs()).To(Succeed())
+	defer func() { languageContentDirs = nil }()
+
+	write(g, filepath.Join(root, "hugo", "content/en/docs/_index.md"), `# Home
+
+See [page](/page#section) or [missing](/missing).
+`)
+	write(g, filepath.Join(root, "hugo", "content/en/docs/page.md"), "# Page\n\n## Section\n")
+	write(g, filepath.Join(root, "hugo", "content-it/_index.md"), "# Home\n\nSee [page](/page).\n")
+	write(g, filepath.Join(root, "hugo", "content-it/page.md"), "# Pagina\n")
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	g.Expect(readAll()).To(Succeed())
+	checkLanguageIndexes()
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+
+	g.Expect(pagesByPath[filepath.Join(root, "hugo", "content-it/page.md")].hugoLanguage).To(Equal("it"))
+	g.Expect(pagesByPath[filepath.Join(root, "hugo", "content-it/page.md")].logPath()).To(Equal("<site>/content-it/page.md"))
+	g.Expect(collectErrors(root)).To(Equal([]string{
+		"/hugo/content/en/docs/_index.md:3: /missing: the link resolves to /hugo/content/en/docs/missing.md which does not exist",
+	}))
+}
+
+func Test_applyHugoConfig(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "", []string{"en"})
+	defer cancel()
+
+	hugoConfigFlagBefore := *hugoConfigFlag
+	defer func() { *hugoConfigFlag = hugoConfigFlagBefore }()
+	defer func() { languageContentDirs = nil }()
+
+	configPath := filepath.Join(root, "docs", "book", hugoConfigFile)
+	write(g, configPath, `baseURL = "https://cluster-api.sigs.k8s.io/"
+title = "The Cluster API Book"
+defaultContentLanguage = "it"
+
+[languages]
+[languages.en]
+title = "The Cluster API Book"
+weight = 2
+[languages.fr]
+weight = 3
+contentDir = "content-fr"
+[languages.it]
+title = "Il libro di Cluster API"
+weight = 1
+contentDir = "content/it/docs"
+
+[[menu.main]]
+name = "Docs"
+url = "/docs/"
+`)
+
+	// The config is outside root.
+	*hugoConfigFlag = filepath.Join(os.TempDir(), hugoConfigFile)
+	g.Expect(applyHugoConfig()).ToNot(Succeed())
+
+	*hugoConfigFlag = configPath
+	g.Expect(applyHugoConfig()).To(Succeed())
+	g.Expect(*hugoFolder).To(Equal(filepath.Join("docs", "book")))
+	g.Expect(*hugoLanguages).To(Equal([]string{"it", "en", "fr"}))
+	g.Expect(hugoConfigPath()).To(Equal(configPath))
+
+	g.Expect(loadLanguageContentDirs()).To(Succeed())
+	g.Expect(languageContentDir("it")).To(Equal(filepath.Join(root, "docs", "book", "content", "it", "docs")))
+	g.Expect(languageContentDir("en")).To(Equal(filepath.Join(root, "docs", "book", "content", "en")))
+	g.Expect(languageContentDir("fr")).To(Equal(filepath.Join(root, "docs", "book", "content-fr")))
+
+	// The top level content dir applies to single language websites.
+	write(g, configPath, `contentDir = "content"
+`)
+	g.Expect(applyHugoConfig()).To(Succeed())
+	g.Expect(*hugoLanguages).To(Equal([]string{"en"}))
+	g.Expect(loadLanguageContentDirs()).To(Succeed())
+	g.Expect(languageContentDir("en")).To(Equal(filepath.Join(root, "docs", "book", "content")))
+}
@@ -0,0 +1,109 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	staticFolder = "static"
+	assetsFolder = "assets"
+)
+
+// Search for images in the format ![alt](addr) or ![alt](addr "title"), captures addr value.
+var imageRx = regexp.MustCompile(`!\[[^\]]*\]\(\s*([^\)\s]+)(?:\s+"[^"]*")?\s*\)`)
+
+func readMarkdownLineImages(line string) (images []string) {
+	for _, m := range imageRx.FindAllStringSubmatch(line, -1) {
+		images = append(images, m[1])
+	}
+	return
+}
+
+// addImage adds an image to the links of the page.
+// Images are resolved as in hugo, looking first in the page bundle (the content folder), then
+// in the static folder and finally in the assets folder.
+func (p *page) addImage(i string, lineNumber int) {
+	u, err := url.Parse(i)
+	if err != nil {
+		p.links = append(p.links, link{rawLink: i, lineNumber: lineNumber, isImage: true, fatalError: newLinkcheckError(invalidLinkErrorCategory, "error parsing url: %v", err)})
+		return
+	}
+
+	// Protocol-relative urls, e.g. //cdn.example.com/logo.png, are external images; assume https.
+	if u.Scheme == "" && u.Host != "" {
+		u.Scheme = "https"
+	}
+
+	// If it is an http/https url, use as it is.
+	if u.Scheme != "" {
+		p.links = append(p.links, link{rawLink: i, lineNumber: lineNumber, isImage: true, URL: u})
+		return
+	}
+
+	// Error if file url is used in pages outside the hugo website.
+	if !p.isHugoPage {
+		p.links = append(p.links, link{rawLink: i, lineNumber: lineNumber, isImage: true, fatalError: newLinkcheckError(invalidLinkErrorCategory, "scheme is required on links outside the hugo website")})
+		return
+	}
+
+	// Compute the path of the image, transforming relative paths to absolute ones.
+	target := u.Path
+	if !path.IsAbs(target) {
+		target = path.Join(path.Dir(filepath.ToSlash(p.hugoPath)), target)
+	}
+
+	hugoDir := filepath.Join(*root, *hugoFolder)
+	candidates := []string{
+		filepath.Join(languageContentDir(p.hugoLanguage), target),
+		filepath.Join(hugoDir, staticFolder, target),
+		filepath.Join(hugoDir, assetsFolder, target),
+	}
+	for _, c := range candidates {
+		if info, err := statFile(c); err == nil {
+			debugf("%s line %d, %s: resolved to %s", p.logPath(), lineNumber, i, c)
+
+			// If a size budget for images is set, report images exceeding it.
+			if *maxImageBytes > 0 && info.Size() > *maxImageBytes {
+				p.links = append(p.links, link{rawLink: i, lineNumber: lineNumber, isImage: true, URL: &url.URL{Path: c}, fatalError: newLinkcheckError(oversizedImageErrorCategory, "the image %s is %d bytes, bigger than the %d bytes allowed", strings.TrimPrefix(c, *root), info.Size(), *maxImageBytes)})
+				return
+			}
+
+			// If an assets manifest is set, report images missing from it.
+			if !isInAssetsManifest(c) {
+				p.links = append(p.links, link{rawLink: i, lineNumber: lineNumber, isImage: true, URL: &url.URL{Path: c}, fatalError: newLinkcheckError(missingFileErrorCategory, "the image %s is not listed in the assets manifest", strings.TrimPrefix(c, *root))})
+				return
+			}
+			p.links = append(p.links, link{rawLink: i, lineNumber: lineNumber, isImage: true, URL: &url.URL{Path: c}})
+			return
+		}
+	}
+
+	logCandidates := []string{}
+	for _, c := range candidates {
+		logCandidates = append(logCandidates, strings.TrimPrefix(c, *root))
+	}
+	p.links = append(p.links, link{rawLink: i, lineNumber: lineNumber, isImage: true, fatalError: newLinkcheckError(missingFileErrorCategory, "the image resolves to none of %s", strings.Join(logCandidates, ", "))})
+}
@@ -0,0 +1,226 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_readMarkdownLineImages(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		wantImages []string
+	}{
+		{
+			name:       "no images",
+			line:       "Read the [page](page).",
+			wantImages: nil,
+		},
+		{
+			name:       "image without alt text",
+			line:       "![](/images/logo.png)",
+			wantImages: []string{"/images/logo.png"},
+		},
+		{
+			name:       "images with alt text and title",
+			line:       `See ![logo](logo.png "The logo") and ![diagram](diagram.svg).`,
+			wantImages: []string{"logo.png", "diagram.svg"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(readMarkdownLineImages(tt.line)).To(Equal(tt.wantImages))
+		})
+	}
+}
+
+func Test_addImage(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	hugoDir := filepath.Join(root, "hugo")
+	touch(g, filepath.Join(hugoDir, "content/en/folder/bundle.png"))
+	touch(g, filepath.Join(hugoDir, "content/en/images/both.png"))
+	touch(g, filepath.Join(hugoDir, "static/images/both.png"))
+	touch(g, filepath.Join(hugoDir, "static/images/static.png"))
+	touch(g, filepath.Join(hugoDir, "assets/images/assets.png"))
+
+	tests := []struct {
+		name     string
+		path     string
+		image    string
+		wantLink link
+	}{
+		{
+			name:  "image in the page bundle",
+			path:  filepath.Join(hugoDir, "content/en/folder/_index.md"),
+			image: "bundle.png",
+			wantLink: link{
+				rawLink:    "bundle.png",
+				lineNumber: 1,
+				isImage:    true,
+				URL:        mustParseUrl(filepath.Join(hugoDir, "content/en/folder/bundle.png")),
+			},
+		},
+		{
+			name:  "image found only in static",
+			path:  filepath.Join(hugoDir, "content/en/folder/_index.md"),
+			image: "/images/static.png",
+			wantLink: link{
+				rawLink:    "/images/static.png",
+				lineNumber: 1,
+				isImage:    true,
+				URL:        mustParseUrl(filepath.Join(hugoDir, "static/images/static.png")),
+			},
+		},
+		{
+			name:  "image found only in assets",
+			path:  filepath.Join(hugoDir, "content/en/folder/_index.md"),
+			image: "/images/assets.png",
+			wantLink: link{
+				rawLink:    "/images/assets.png",
+				lineNumber: 1,
+				isImage:    true,
+				URL:        mustParseUrl(filepath.Join(hugoDir, "assets/images/assets.png")),
+			},
+		},
+		{
+			name:  "image in the page bundle takes precedence over static",
+			path:  filepath.Join(hugoDir, "content/en/_index.md"),
+			image: "images/both.png",
+			wantLink: link{
+				rawLink:    "images/both.png",
+				lineNumber: 1,
+				isImage:    true,
+				URL:        mustParseUrl(filepath.Join(hugoDir, "content/en/images/both.png")),
+			},
+		},
+		{
+			name:  "missing image",
+			path:  filepath.Join(hugoDir, "content/en/_index.md"),
+			image: "/images/missing.png",
+			wantLink: link{
+				rawLink:    "/images/missing.png",
+				lineNumber: 1,
+				isImage:    true,
+				fatalError: newLinkcheckError(missingFileErrorCategory, "the image resolves to none of /hugo/content/en/images/missing.png, /hugo/static/images/missing.png, /hugo/assets/images/missing.png"),
+			},
+		},
+		{
+			name:  "external image",
+			path:  filepath.Join(hugoDir, "content/en/_index.md"),
+			image: "https://example.com/logo.png",
+			wantLink: link{
+				rawLink:    "https://example.com/logo.png",
+				lineNumber: 1,
+				isImage:    true,
+				URL:        mustParseUrl("https://example.com/logo.png"),
+			},
+		},
+		{
+			name:  "protocol-relative image",
+			path:  filepath.Join(hugoDir, "content/en/_index.md"),
+			image: "//cdn.example.com/logo.png",
+			wantLink: link{
+				rawLink:    "//cdn.example.com/logo.png",
+				lineNumber: 1,
+				isImage:    true,
+				URL:        mustParseUrl("https://cdn.example.com/logo.png"),
+			},
+		},
+		{
+			name:  "image in a page outside the hugo website",
+			path:  filepath.Join(root, "README.md"),
+			image: "logo.png",
+			wantLink: link{
+				rawLink:    "logo.png",
+				lineNumber: 1,
+				isImage:    true,
+				fatalError: newLinkcheckError(invalidLinkErrorCategory, "scheme is required on links outside the hugo website"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			p := newPage(tt.path)
+			p.addImage(tt.image, 1)
+			g.Expect(p.links).To(Equal([]link{tt.wantLink}))
+		})
+	}
+}
+
+func Test_addImage_maxImageBytes(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	maxImageBytesBefore := *maxImageBytes
+	defer func() { *maxImageBytes = maxImageBytesBefore }()
+	*maxImageBytes = 10
+
+	hugoDir := filepath.Join(root, "hugo")
+	write(g, filepath.Join(hugoDir, "static/images/small.png"), "0123456789")
+	write(g, filepath.Join(hugoDir, "static/images/big.png"), "0123456789a")
+
+	p := newPage(filepath.Join(hugoDir, "content/en/_index.md"))
+	p.addImage("/images/small.png", 1)
+	p.addImage("/images/big.png", 2)
+	g.Expect(p.links).To(Equal([]link{
+		{
+			rawLink:    "/images/small.png",
+			lineNumber: 1,
+			isImage:    true,
+			URL:        mustParseUrl(filepath.Join(hugoDir, "static/images/small.png")),
+		},
+		{
+			rawLink:    "/images/big.png",
+			lineNumber: 2,
+			isImage:    true,
+			URL:        mustParseUrl(filepath.Join(hugoDir, "static/images/big.png")),
+			fatalError: newLinkcheckError(oversizedImageErrorCategory, "the image /hugo/static/images/big.png is 11 bytes, bigger than the 10 bytes allowed"),
+		},
+	}))
+
+	// With the check disabled, images of any size are accepted.
+	*maxImageBytes = 0
+	p = newPage(filepath.Join(hugoDir, "content/en/_index.md"))
+	p.addImage("/images/big.png", 1)
+	g.Expect(p.links[0].fatalError).To(BeNil())
+}
@@ -0,0 +1,77 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Search for fragments referencing lines of a source file, e.g. L10 or L10-L20, captures first and last line.
+var lineRangeRx = regexp.MustCompile(`^L(\d+)(?:-L(\d+))?$`)
+
+// checkLineRange checks a fragment referencing lines of a source file, e.g. file.go#L10-L20, returning the error
+// category and the message if the fragment does not reference existing lines; fragments in other forms are not checked.
+func checkLineRange(targetPath, fragment string) (errorCategory, string) {
+	first, last, ok := parseLineRange(fragment)
+	if !ok {
+		return "", ""
+	}
+	if first < 1 || last < first {
+		return invalidLinkErrorCategory, fmt.Sprintf("%s%s is not a valid line range, lines start from 1 and the first line must not be after the last line", *anchorSep, fragment)
+	}
+
+	content, err := readFile(targetPath)
+	if err != nil {
+		return missingFileErrorCategory, fmt.Sprintf("error reading %s: %v", strings.TrimPrefix(targetPath, *root), err)
+	}
+	if lines := countLines(content); last > lines {
+		return missingAnchorErrorCategory, fmt.Sprintf("%s%s is out of range, %s has %d lines", *anchorSep, fragment, strings.TrimPrefix(targetPath, *root), lines)
+	}
+	return "", ""
+}
+
+// parseLineRange returns the first and the last line referenced by a fragment, e.g. 10 and 20 for L10-L20, and
+// whether the fragment references lines of a source file.
+func parseLineRange(fragment string) (int, int, bool) {
+	m := lineRangeRx.FindStringSubmatch(fragment)
+	if m == nil {
+		return 0, 0, false
+	}
+
+	first, _ := strconv.Atoi(m[1])
+	last := first
+	if m[2] != "" {
+		last, _ = strconv.Atoi(m[2])
+	}
+	return first, last, true
+}
+
+// countLines returns the number of lines in a file, counting the last line even if not terminated by a new line.
+func countLines(content []byte) int {
+	lines := bytes.Count(content, []byte("\n"))
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		lines++
+	}
+	return lines
+}
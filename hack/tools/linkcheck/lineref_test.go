@@ -0,0 +1,79 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_countLines(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(countLines([]byte(""))).To(Equal(0))
+	g.Expect(countLines([]byte("a"))).To(Equal(1))
+	g.Expect(countLines([]byte("a\n"))).To(Equal(1))
+	g.Expect(countLines([]byte("a\nb"))).To(Equal(2))
+	g.Expect(countLines([]byte("a\n\n"))).To(Equal(2))
+}
+
+func Test_readAllAndLinkcheckAll_lineRanges(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	write(g, filepath.Join(root, "README.md"), `# Project
+
+See [main](main.go#L3), [the function](main.go#L3-L5) or [the package](main.go#package).
+See [out of range](main.go#L5-L6), [after the end](main.go#L10) or [invalid](main.go#L5-L3) and [line zero](main.go#L0).
+`)
+	write(g, filepath.Join(root, "main.go"), "package main\n\nfunc main() {\n\tprintln()\n}\n")
+	write(g, filepath.Join(root, "hugo", contentFolder, "en/_index.md"), "# Home\n")
+
+	repoDocsBefore := *repoDocs
+	defer func() { *repoDocs = repoDocsBefore }()
+	*repoDocs = true
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+	g.Expect(collectErrors(root)).To(Equal([]string{
+		"/README.md:4: main.go#L0: #L0 is not a valid line range, lines start from 1 and the first line must not be after the last line",
+		"/README.md:4: main.go#L10: #L10 is out of range, /main.go has 5 lines",
+		"/README.md:4: main.go#L5-L3: #L5-L3 is not a valid line range, lines start from 1 and the first line must not be after the last line",
+		"/README.md:4: main.go#L5-L6: #L5-L6 is out of range, /main.go has 5 lines",
+	}))
+}
@@ -20,29 +20,108 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"io/fs"
 	"net/url"
 	"os"
+	"os/signal"
+	"path"
 	"path/filepath"
 	"regexp"
-	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/pflag"
+	"golang.org/x/text/unicode/norm"
 	"k8s.io/utils/pointer"
 )
 
 const (
 	contentFolder   = "content"
 	anchorSeparator = "#"
+
+	// timeoutExitCode is the exit code used when --timeout-total expires.
+	timeoutExitCode = 2
 )
 
 var (
-	root          = pflag.String("root", ".", "root path to walk for linting .m files")
-	hugoFolder    = pflag.String("hugo-folder", "", "path to the folder contaning the hugo website")
-	hugoLanguages = pflag.StringSlice("hugo-languages", []string{"en"}, "list of languages supported by the hugo website") // TODO: infer from config.toml.
-	verbose       = pflag.Bool("verbose", false, "verbose")
+	root              = pflag.String("root", ".", "root path to walk for linting .m files")
+	hugoFolder        = pflag.String("hugo-folder", "", "path to the folder contaning the hugo website")
+	hugoLanguages     = pflag.StringSlice("hugo-languages", []string{"en"}, "list of languages supported by the hugo website")
+	hugoConfigFlag    = pflag.String("hugo-config", "", "path to the hugo website config; if set, hugo-folder, hugo-languages and the content dir of each language are derived from it")
+	logLevelName      = pflag.String("log-level", "normal", "granularity of the output, one of quiet, normal, verbose, debug")
+	verbosity         = verbosityFlagP("verbose", "v", "increase the log level, can be repeated (e.g. -vv for debug)")
+	quiet             = pflag.BoolP("quiet", "q", false, "print only the final summary, same as --log-level=quiet; the exit code reports if there are errors, and --summary-json is still written")
+	groupBy           = pflag.String("group-by", groupByPage, fmt.Sprintf("how to group errors in the report, one of %s, %s", groupByPage, groupByError))
+	format            = pflag.String("format", formatText, fmt.Sprintf("format of the report, one of %s, %s (a path:line:col: severity: message line for each error, for editors integration), %s (a SARIF document, for code scanning integration)", formatText, formatGCC, formatSARIF))
+	includeDrafts     = pflag.Bool("include-drafts", false, "allow links to draft pages")
+	checkPublishDates = pflag.Bool("check-publish-dates", false, "report links to pages not yet published or expired according to publishDate and expiryDate")
+	now               = pflag.String("now", "", "time, in RFC3339 format, used when checking publish dates; defaults to the current time")
+	headingShortcodes = pflag.StringSlice("heading-shortcodes", []string{}, "list of shortcodes rendering a heading; the title parameter, or the first positional parameter, generates an anchor")
+	assetExtensions   = pflag.StringSlice("asset-extensions", []string{".pdf", ".yaml", ".yml", ".json", ".svg", ".png", ".jpg", ".jpeg", ".gif", ".txt", ".zip"}, "list of file extensions of assets linked directly from pages")
+	checkExternal     = pflag.Bool("check-external", false, "check http and https links")
+	externalTimeout   = pflag.Duration("external-timeout", 10*time.Second, "timeout for checking an http or https link")
+	externalRulesFile = pflag.String("external-rules", "", "path to a YAML file with rules for checking http and https links, e.g. timeouts by host")
+	netrcFile         = pflag.String("netrc", "", "path to the netrc file supplying credentials for http and https links; defaults to $HOME/.netrc")
+	disableNetrc      = pflag.Bool("disable-netrc", false, "do not use a netrc file for http and https links")
+	reportTemplate    = pflag.String("report-template", "", "path to a go text/template file used for printing the report instead of the built-in text report, or \"default\" for the default template printing a line for each error")
+	summaryJSON       = pflag.String("summary-json", "", "path to a file where to write the final summary in JSON format")
+	stream            = pflag.Bool("stream", false, "print the result of each page as soon as it is checked, instead of a sorted report at the end")
+	fix               = pflag.Bool("fix", false, "rewrite forbidden links in place using the suggested form")
+	dryRun            = pflag.Bool("dry-run", false, "with --fix, print a diff of the rewrites instead of applying them, and fail if there are links to fix")
+	opaqueFragments   = pflag.StringSlice("opaque-fragments", []string{}, "list of site path patterns, e.g. /app/*, of pages using fragments for client side routing; fragments of links to those pages are not checked")
+	unusedAnchors     = pflag.Bool("report-unused-anchors", false, "warn about anchors not referenced by any link in the website")
+	checkMenuEntries  = pflag.Bool("check-menus", false, "check the url of the menu entries defined in the hugo website config")
+	dualValidate      = pflag.Bool("dual-validate", false, "warn about internal links working only on the hugo website or only in the source repository, e.g. on GitHub")
+	timeoutTotal      = pflag.Duration("timeout-total", 0, "maximum time for reading pages and checking links; when expired, the results already collected are reported and linkcheck exits with code 2; 0 means no limit")
+	versions          = pflag.StringSlice("versions", []string{}, "list of version=folder values, e.g. v1.6=docs/book-v1.6, defining the hugo folder of each known version; links starting with a version segment, e.g. /v1.6/tasks, are resolved in the content of that version")
+	checkBareURLs     = pflag.Bool("check-bare-urls", false, "read bare http and https urls in text, outside code, as links; they are checked when --check-external is set")
+	onlyExternal      = pflag.Bool("only-external", false, "check only http and https links, skipping the checks on links to files, pages and anchors; implies --check-external")
+	onlyInternal      = pflag.Bool("only-internal", false, "check only links to files, pages and anchors, skipping the checks on http and https links")
+	redirectsFile     = pflag.String("redirects", "", "path to a file with redirects in the _redirects format, one \"/from /to [status]\" rule for each line; links to redirected paths are checked against the target of the redirect")
+	requireSingleH1   = pflag.Bool("require-single-h1", false, "warn about pages without exactly one H1 header")
+	workers           = pflag.Int("workers", 1, "number of pages checked in parallel; the final report is the same regardless of the number of workers")
+	renderedBaseURL   = pflag.String("rendered-base-url", "", "url of a running hugo server, e.g. http://localhost:1313; if set, anchors and internal links are also checked against the rendered HTML")
+	severities        = pflag.StringSlice("severity", []string{}, "list of category=level values, e.g. missing-anchor=warning, setting the severity of an error category to one of error, warning, ignore")
+	fuzzyAnchors      = pflag.Bool("fuzzy-anchors", false, "ignore hyphens and spaces when comparing link fragments with page anchors")
+	reservedAnchors   = pflag.StringSlice("reserved-anchors", []string{"TableOfContents"}, "list of ids reserved by hugo or by the theme, e.g. the id of the table of contents; warn about pages generating an anchor with one of those ids")
+	linkShortcodes    = pflag.StringSlice("link-shortcodes", []string{}, "list of shortcode.param values, e.g. button.href, for shortcode parameters carrying a link to be checked")
+	escapeFolders     = pflag.StringSlice("escape-folders", []string{"static", "assets"}, "list of folders of the hugo website that relative links are allowed to reach escaping the content folder, e.g. ../../static/x.png")
+	repoDocs          = pflag.Bool("repo-docs", false, "check markdown pages outside the hugo website as rendered by GitHub, allowing relative links between them")
+	requireHTTPS      = pflag.Bool("require-https", false, "report external links using http instead of https, with the insecure-link error category")
+	httpAllowedHosts  = pflag.StringSlice("http-allowed-hosts", []string{"localhost", "127.0.0.1"}, "list of hosts allowed to be linked with http when --require-https is set")
+	skipBeginMarker   = pflag.String("skip-begin-marker", "", "marker starting a region of the pages where links are not checked, e.g. \"<!-- BEGIN GENERATED -->\"")
+	skipEndMarker     = pflag.String("skip-end-marker", "", "marker ending a region of the pages where links are not checked, e.g. \"<!-- END GENERATED -->\"")
+	tabShortcodes     = pflag.StringSlice("tab-shortcodes", []string{}, "list of shortcodes rendering tab panels, e.g. tab; headings inside tab panels are handled according to --tab-headings")
+	tabHeadings       = pflag.String("tab-headings", tabHeadingsAnchor, fmt.Sprintf("how to handle headings inside tab panels, one of %s (headings generate anchors), %s (headings do not generate anchors, and links to them are not checked)", tabHeadingsAnchor, tabHeadingsSkip))
+	maxImageBytes     = pflag.Int64("max-image-bytes", 0, "size budget, in bytes, for local images; bigger images are reported with the oversized-image error category; 0 disables the check")
+	trailingSlash     = pflag.String("trailing-slash", "", fmt.Sprintf("trailing slash policy for links to folders, one of %s, %s; links not following it are reported as warnings", trailingSlashAlways, trailingSlashNever))
+	maxLineLength     = pflag.Int("max-line-length", 0, "length of the lines, in bytes, above which links are read with a simpler linear scan instead of regexes, with a warning, e.g. for minified tables; 0 disables the check")
+	ignoreAnchors     = pflag.StringSlice("ignore-anchor", []string{}, "list of page#anchor patterns, e.g. /reference/api/*#spec-*, of anchors generated at runtime; links to matching anchors are not checked, while the page is")
+	followSymlinks    = pflag.Bool("follow-symlinks", false, "read pages in symlinked folders, e.g. docs included from a git submodule, as part of the folder containing the symlink")
+	stripPrefixes     = pflag.StringSlice("strip-link-prefixes", []string{}, "list of path prefixes, e.g. /src/, dropped from absolute links before resolving them, as done for links to sources of mdbook books")
+	assetsManifest    = pflag.String("assets-manifest", "", "path to a file listing the processed assets, one path relative to the hugo folder for each line, e.g. static/images/logo.png; images missing from the manifest, and assets in the manifest missing on disk, are reported")
+	listItemAnchors   = pflag.String("list-item-anchors", "", "format of the ids assigned by the theme to the items of ordered lists, e.g. step-%d for step-1, step-2, with the position of the item in its list")
+	tocFile           = pflag.String("toc-file", "", "path to the markdown page defining the navigation of the website, e.g. docs/book/content/en/SUMMARY.md; its links are checked like any other page, and the pages of its language not linked from it are reported as warnings")
+	githubToken       = pflag.String("github-token", "", "token for the GitHub API, e.g. $GITHUB_TOKEN; if set, links to GitHub blobs, e.g. https://github.com/org/repo/blob/main/file.go#L10, are checked verifying the file exists at the ref and the lines are within the file")
+	absCrossVersion   = pflag.Bool("absolute-cross-version-links", false, "warn about relative links leaving the version folder of the page, e.g. ../../v1.5/tasks from /v1.6/tasks/page, which must be absolute")
+	checkYAMLComments = pflag.Bool("check-yaml-comments", false, "read http and https urls in the # comments of yaml code fences as links; they are checked when --check-external is set")
+	anchorSep         = pflag.String("anchor-separator", anchorSeparator, "separator between the path and the anchor of internal links, e.g. ! for page!section with custom renderers")
+	topBrokenTargets  = pflag.Int("top-broken-targets", 0, "number of missing files, targeted by the most links, to list at the end of the report, e.g. a renamed page linked from many pages; 0 disables the list")
+	forbidLinkText    = pflag.StringSlice("forbid-link-text", []string{}, "list of link texts, e.g. \"click here\",here,\"read more\", not describing the target of the link; links using them are reported as warnings, case insensitive")
+	cacheFile         = pflag.String("cache-file", "", "path to a file where to cache the links and anchors extracted from each page, so unchanged pages are not parsed again on the next run; links are still resolved and checked")
+	allowRefs         = pflag.Bool("allow-ref-shortcodes", false, "check the target of ref/refLink shortcodes, e.g. {{< ref \"page.md#anchor\" >}}, instead of reporting them as forbidden links")
+	languageFallback  = pflag.Bool("language-fallback", false, "check links to missing translations, and their anchors, against the page in the default language, i.e. the first of --hugo-languages, as served by hugo falling back to the default language")
+	indexCycles       = pflag.Bool("report-index-cycles", false, "warn about _index.md pages linking to each other in a cycle, e.g. a folder page linking to a sub folder whose page links back, which create navigation loops")
+	externalRequests  = pflag.Int("external-requests", 0, "maximum number of http and https links checked in parallel; 0 means one for each of the --workers")
+	externalQueue     = pflag.Int("external-queue-size", 0, "number of http and https links buffered while pages are read, so they are checked as soon as pages are read by --external-requests checkers instead of after reading all the pages; 0 disables checking links while reading pages; only buffered links and requests in flight are bounded, pages and results are kept in memory until the report")
+	codeFenceAnchors  = pflag.String("code-fence-anchors", "", "format of the ids assigned by the theme to code fences with a title, e.g. code-%s for code-main.go with ```go title=\"main.go\", with the anchor generated from the title as for headings")
+	watch             = pflag.Duration("watch", 0, "after the report, check again the pages changed on disk every interval, e.g. 1s, and print the report again, until interrupted; only the links affected by the change are checked again; 0 means no watch")
+	allowSchemeless   = pflag.StringSlice("allow-schemeless-paths", []string{}, "list of path patterns relative to the root, e.g. hack/*.md, of pages outside the hugo website allowed to use relative links, which are checked as with --repo-docs")
 )
 
 var (
@@ -57,7 +136,7 @@ type page struct {
 	path string
 
 	// fatalError if set, defines an error in reading or processing the page that prevents further processing.
-	fatalError string
+	fatalError *linkcheckError
 
 	// isHugoPage is true when the page is defined inside content/language folder of the hugo website.
 	isHugoPage bool
@@ -65,7 +144,7 @@ type page struct {
 	// hugoLanguage is the language of the page in the hugo website.
 	hugoLanguage string
 
-	// hugoPath is path of the page relative to the content/language folder of the hugo website.
+	// hugoPath is path of the page relative to the content dir of the page language, e.g. content/en.
 	hugoPath string
 
 	// links contains the list of links defined in the page.
@@ -73,6 +152,27 @@ type page struct {
 
 	// anchors contains the list of anchors (~headers) defined in the page.
 	anchors []string
+
+	// tabAnchors contains the list of anchors of headers inside tab panels, when they are not considered anchors.
+	tabAnchors []string
+
+	// frontMatter contains the front matter defined in the page.
+	frontMatter frontMatter
+
+	// publishDate and expiryDate are the dates the page is published between, read from the front matter
+	// only when --check-publish-dates is set.
+	publishDate time.Time
+	expiryDate  time.Time
+
+	// terms contains the terms of each taxonomy defined in the front matter of the page, e.g. tags: [foo].
+	terms map[string][]string
+
+	// warnings contains issues detected on the page that do not prevent further processing.
+	warnings []warning
+
+	// streamedWarnings is the number of warnings printed when the page has been streamed; warnings added
+	// afterwards, e.g. by checks on all the pages, are printed with the report.
+	streamedWarnings int
 }
 
 // link define a link on a page validated by linkcheck.
@@ -84,43 +184,152 @@ type link struct {
 	lineNumber int
 
 	// fatalError if set, defines an error in reading or processing the link that prevents further processing.
-	fatalError string
+	fatalError *linkcheckError
+
+	// suggestedFix if set, defines the form that should be used instead of a forbidden link.
+	suggestedFix string
+
+	// isImage is true for image links, e.g. ![alt](addr).
+	isImage bool
 
 	// URL derived from the link.
 	// NOTE: for localLinks (link to files) the link path is translated to an absolute path.
 	URL *url.URL
+
+	// targetPath is the path a link to a file resolves to when checked, after following permalinks, language
+	// fallbacks, redirects and casing differences.
+	targetPath string
+}
+
+// errorCategory defines the category of an error reported by linkcheck.
+type errorCategory string
+
+// linkcheckError defines an error reported by linkcheck for a page or a link.
+type linkcheckError struct {
+	// category of the error.
+	category errorCategory
+
+	// message describing the error, as reported to the users.
+	message string
+}
+
+func newLinkcheckError(category errorCategory, format string, a ...interface{}) *linkcheckError {
+	return &linkcheckError{category: category, message: fmt.Sprintf(format, a...)}
+}
+
+func (e *linkcheckError) Error() string {
+	return e.message
+}
+
+// warning defines an issue detected on a page, or on a line or a link of the page, that does not prevent
+// further processing.
+type warning struct {
+	// lineNumber the warning is about, if any.
+	lineNumber int
+
+	// rawLink the warning is about, if any.
+	rawLink string
+
+	// message describing the warning, as reported to the users.
+	message string
+}
+
+// String returns the warning as reported to the users, e.g. line 3, rawLink: message.
+func (w warning) String() string {
+	switch {
+	case w.rawLink != "":
+		return fmt.Sprintf("line %d, %s: %s", w.lineNumber, w.rawLink, w.message)
+	case w.lineNumber > 0:
+		return fmt.Sprintf("line %d, %s", w.lineNumber, w.message)
+	default:
+		return w.message
+	}
+}
+
+const (
+	// pageErrorCategory applies to errors in reading or processing a page.
+	pageErrorCategory errorCategory = "page error"
+
+	// invalidLinkErrorCategory applies to links that cannot be parsed or resolved.
+	invalidLinkErrorCategory errorCategory = "invalid link"
+
+	// forbiddenLinkErrorCategory applies to links using a forbidden form, e.g. ref shortcodes or .md extensions.
+	forbiddenLinkErrorCategory errorCategory = "forbidden link"
+
+	// missingFileErrorCategory applies to links pointing to a file that does not exist.
+	missingFileErrorCategory errorCategory = "missing file"
+
+	// missingAnchorErrorCategory applies to links pointing to an anchor that does not exist.
+	missingAnchorErrorCategory errorCategory = "missing anchor"
+
+	// unpublishedPageErrorCategory applies to links pointing to a page that is not published, e.g. a draft page.
+	unpublishedPageErrorCategory errorCategory = "unpublished page"
+
+	// externalErrorCategory applies to external links that cannot be reached or return an error.
+	externalErrorCategory errorCategory = "external failure"
+
+	// unknownVersionErrorCategory applies to links pointing to a version of the website that is not known.
+	unknownVersionErrorCategory errorCategory = "unknown version"
+
+	// renderedErrorCategory applies to anchors and links in the HTML rendered by a running hugo server.
+	renderedErrorCategory errorCategory = "rendered page"
+
+	// insecureLinkErrorCategory applies to external links using http instead of https.
+	insecureLinkErrorCategory errorCategory = "insecure link"
+
+	// oversizedImageErrorCategory applies to local images bigger than --max-image-bytes.
+	oversizedImageErrorCategory errorCategory = "oversized image"
+)
+
+// errorCategories defines the order in which error categories are reported.
+var errorCategories = []errorCategory{
+	pageErrorCategory,
+	invalidLinkErrorCategory,
+	forbiddenLinkErrorCategory,
+	missingFileErrorCategory,
+	missingAnchorErrorCategory,
+	unpublishedPageErrorCategory,
+	unknownVersionErrorCategory,
+	externalErrorCategory,
+	renderedErrorCategory,
+	insecureLinkErrorCategory,
+	oversizedImageErrorCategory,
 }
 
 func newPage(path string) page {
 	p := page{path: path}
 
-	// If the page is inside the hugo content dir.
+	// Identify the page language when the page is inside the content dir of one of the known languages.
+	for _, l := range *hugoLanguages {
+		languageDir := languageContentDir(l)
+		if path == languageDir || strings.HasPrefix(path, languageDir+string(filepath.Separator)) {
+			p.isHugoPage = true
+			p.hugoLanguage = l
+			p.hugoPath = hugoPathOf(path, languageDir)
+		}
+	}
+
+	// If the page is inside the hugo content dir, error out if the page does not belong to one of the know languages.
 	contentDir := filepath.Join(*root, *hugoFolder, contentFolder)
-	if strings.HasPrefix(path, contentDir) {
+	if !p.isHugoPage && strings.HasPrefix(path, contentDir) {
 		p.isHugoPage = true
 
-		// Identify the page language or error out if the page does not belong to one of the know languages.
 		switch len(*hugoLanguages) {
 		case 0:
 			// TODO: handle non localized hugo websites
 		default:
-			for _, l := range *hugoLanguages {
-				languageDir := filepath.Join(contentDir, l)
-				if strings.HasPrefix(path, languageDir) {
-					p.hugoLanguage = l
-					p.hugoPath = strings.TrimPrefix(path, languageDir)
-				}
-			}
 			if p.hugoLanguage == "" {
-				p.fatalError = fmt.Sprintf("hugo page %s does not belong to one of the know languages: %s", strings.TrimPrefix(path, contentDir), strings.Join(*hugoLanguages, ", "))
+				p.fatalError = newLinkcheckError(pageErrorCategory, "hugo page %s does not belong to one of the know languages: %s", strings.TrimPrefix(path, contentDir), strings.Join(*hugoLanguages, ", "))
 			}
 		}
 	}
 	return p
 }
 
-func newPageWithFatalError(path string, error string) page {
-	return page{path: path, fatalError: error}
+func newPageWithFatalError(path string, message string) page {
+	p := newPage(path)
+	p.fatalError = newLinkcheckError(pageErrorCategory, "%s", message)
+	return p
 }
 
 func addPage(p page) {
@@ -134,40 +343,94 @@ func addPage(p page) {
 func (p *page) addLink(l string, lineNumber int) {
 	u, err := url.Parse(l)
 	if err != nil {
-		p.links = append(p.links, link{rawLink: l, lineNumber: lineNumber, fatalError: fmt.Sprintf("error parsing url: %v", err)})
+		p.links = append(p.links, link{rawLink: l, lineNumber: lineNumber, fatalError: newLinkcheckError(invalidLinkErrorCategory, "error parsing url: %v", err)})
 		return
 	}
 
+	// Protocol-relative urls, e.g. //cdn.example.com/lib.js, are external links using the protocol of the page; assume https.
+	if u.Scheme == "" && u.Host != "" {
+		u.Scheme = "https"
+	}
+
 	// if it is a file url (no scheme is considered file url)
 	if u.Scheme == "" {
 		// Error if file url is used in pages outside the hugo website.
 		// TODO: think about pages outside hugo content/language folder, should we support file url? how this behaves in github?
+		// NOTE: with --repo-docs, pages outside the hugo website are considered as rendered by GitHub, and file urls are allowed;
+		// the same applies to pages matching --allow-schemeless-paths.
 		if !p.isHugoPage {
-			p.links = append(p.links, link{rawLink: l, lineNumber: lineNumber, fatalError: "scheme is required on links outside the hugo website"})
+			if *repoDocs || allowsSchemelessLinks(p) {
+				p.addRepoDocLink(l, lineNumber, u)
+				return
+			}
+			p.links = append(p.links, link{rawLink: l, lineNumber: lineNumber, fatalError: newLinkcheckError(invalidLinkErrorCategory, "scheme is required on links outside the hugo website")})
 			return
 		}
 
 		// Parse the link extracting the key parts.
 		path, fragment, language, err := parseLink(l)
 		if err != nil {
-			p.links = append(p.links, link{rawLink: l, lineNumber: lineNumber, fatalError: err.Error()})
+			forbiddenLink := link{rawLink: l, lineNumber: lineNumber, fatalError: newLinkcheckError(forbiddenLinkErrorCategory, "%s", err.Error())}
+
+			// If the suggested form is a valid link, it can be used to fix the link.
+			var forbiddenErr *forbiddenLinkError
+			if errors.As(err, &forbiddenErr) {
+				if _, _, _, err := parseLink(forbiddenErr.suggestion); err == nil {
+					forbiddenLink.suggestedFix = forbiddenErr.suggestion
+				}
+			}
+			p.links = append(p.links, forbiddenLink)
 			return
 		}
+		debugf("%s line %d, %s: parsed path %q, fragment %q, language %q", p.logPath(), lineNumber, l, path, fragment, language)
+
+		// Drop prefixes not part of the website paths, e.g. /src/ in links to the sources of a mdbook book.
+		path = stripLinkPrefixes(path)
+		linkPath := path
+
+		// Hugo generates lowercase anchors with hyphens instead of spaces, so fragments not using this form are a
+		// frequent mistake; give a hint about it, in addition to checking the fragment exists.
+		if hint := fragmentHint(fragment); hint != "" {
+			p.warnings = append(p.warnings, warning{lineNumber: lineNumber, rawLink: l, message: hint})
+		}
+
 		if path == "" {
 			// if path is empty the link is a fragment pointing to an anchor on the current page (e.g. #anchor).
-			// NOTE: drop .md from the page name so it aligns to how links behaves in hugo
+			// NOTE: the target is the page itself; resolving the page name from the page folder instead would point to the
+			// folder for _index.md pages, or to a folder with the same name of the page, e.g. page/ for page.md.
 			// TODO: think about pages outside hugo content/language folder, should we support file url? how this behaves in github?
-			path = strings.TrimSuffix(filepath.Base(p.hugoPath), ".md")
+			URL, err := url.Parse(p.path + urlFragment(fragment))
+			if err != nil {
+				p.links = append(p.links, link{rawLink: l, lineNumber: lineNumber, fatalError: newLinkcheckError(invalidLinkErrorCategory, "error parsing url: %v", err)})
+				return
+			}
+			debugf("%s line %d, %s: resolved to %s", p.logPath(), lineNumber, l, URL)
+			p.links = append(p.links, link{URL: URL, rawLink: l, lineNumber: lineNumber})
+			return
 		}
 
 		// Compute the path of the target page, transforming relative paths to absolute ones.
 		if !filepath.IsAbs(path) {
-			// TODO: validate the relative path is contained in the hugo root
+			// If the relative path escapes the content dir of the page language, e.g. ../../static/x.png, check it is allowed.
+			if target := filepath.Join(languageContentDir(p.hugoLanguage), filepath.Dir(p.hugoPath), path); !isInFolder(target, languageContentDir(p.hugoLanguage)) {
+				p.addEscapingLink(l, lineNumber, target)
+				return
+			}
+
+			// If required, warn about relative links leaving the version folder of the page, e.g. /v1.6.
+			if *absCrossVersion {
+				if hint := crossVersionHint(p, path, fragment); hint != "" {
+					p.warnings = append(p.warnings, warning{lineNumber: lineNumber, rawLink: l, message: hint})
+				}
+			}
 			path = filepath.Join(filepath.Dir(p.hugoPath), path)
 		}
 
-		// Compute the content dir where the target page will be hosted.
-		contentDir := filepath.Join(*root, *hugoFolder, contentFolder)
+		// Links to the html files generated by hugo, e.g. page.html, point to the corresponding page.
+		isHTML := false
+		if !isAssetPath(path) {
+			path, isHTML = trimHTMLExtension(path)
+		}
 
 		// Compute the language of the target page.
 		// Use the language detected from the hugoRef or default to the same language of the page where the link is defined.
@@ -175,48 +438,113 @@ func (p *page) addLink(l string, lineNumber int) {
 			language = p.hugoLanguage
 		}
 
-		// Compute the url pointing to the target page.
-		rawURL := filepath.Join(contentDir, language, path)
+		// Compute the content dir where the target page will be hosted.
+		languageDir := languageContentDir(language)
 
-		// If the target page is a directory, add _index.md
-		isDir, err := isDirectory(rawURL)
-		if err != nil {
-			p.links = append(p.links, link{rawLink: l, lineNumber: lineNumber, fatalError: fmt.Sprintf("error checking if path is a directory: %v", err)})
-			return
-		}
-		if isDir {
-			rawURL = filepath.Join(rawURL, "_index.md")
+		// If the target page is in a versioned copy of the website, e.g. /v1.6/tasks, use the content dir of that version.
+		if version, rest, ok := splitVersion(path); ok {
+			folder, ok := versionFolder(version)
+			if !ok {
+				p.links = append(p.links, link{rawLink: l, lineNumber: lineNumber, fatalError: newLinkcheckError(unknownVersionErrorCategory, "the link points to version %s which is not one of the known versions", version)})
+				return
+			}
+			languageDir = filepath.Join(*root, folder, contentFolder, language)
+			path = rest
 		}
 
-		// if it is not a dirctory, then it is an .md file
-		// TODO: what about html files
-		if !isDir {
-			rawURL += ".md"
+		// Compute the url pointing to the target page.
+		rawURL := filepath.Join(languageDir, path)
+
+		// If the target is an asset, e.g. a .pdf file, the url points directly to the file; otherwise it points to a page.
+		if !isAssetPath(path) {
+			// If the target page is a directory, add _index.md
+			isDir, err := isDirectory(rawURL)
+			if err != nil {
+				p.links = append(p.links, link{rawLink: l, lineNumber: lineNumber, fatalError: newLinkcheckError(invalidLinkErrorCategory, "error checking if path is a directory: %v", err)})
+				return
+			}
+			if isDir {
+				rawURL = filepath.Join(rawURL, "_index.md")
+
+				// If a trailing slash policy is set, warn about links to folders not following it, because they are redirected.
+				if hint := trailingSlashHint(linkPath); hint != "" {
+					p.warnings = append(p.warnings, warning{lineNumber: lineNumber, rawLink: l, message: hint})
+				}
+			}
+
+			// Warn about links not served with the url style of the hugo website, e.g. page.html with pretty urls.
+			if hint := urlStyleHint(linkPath, isDir, isHTML); hint != "" {
+				p.warnings = append(p.warnings, warning{lineNumber: lineNumber, rawLink: l, message: hint})
+			}
+
+			// if it is not a dirctory, then it is an .md file
+			// TODO: what about html files
+			if !isDir {
+				rawURL += ".md"
+			}
 		}
 
 		if fragment != "" {
-			rawURL += fragment
+			rawURL += urlFragment(fragment)
 		}
 		URL, err := url.Parse(rawURL)
 		if err != nil {
-			p.links = append(p.links, link{rawLink: l, lineNumber: lineNumber, fatalError: fmt.Sprintf("error parsing url: %v", err)})
+			p.links = append(p.links, link{rawLink: l, lineNumber: lineNumber, fatalError: newLinkcheckError(invalidLinkErrorCategory, "error parsing url: %v", err)})
 			return
 		}
+		debugf("%s line %d, %s: resolved to %s", p.logPath(), lineNumber, l, URL)
 		p.links = append(p.links, link{URL: URL, rawLink: l, lineNumber: lineNumber})
 		return
 	}
 
 	// otherwise it is an http/https url, use as it is.
 	// TODO: link title, e.g. [Duck Duck Go](https://duckduckgo.com "The best search engine for privacy")
-	p.links = append(p.links, link{rawLink: l, lineNumber: 1, URL: u})
+	if *requireHTTPS && strings.EqualFold(u.Scheme, "http") && !containsString(*httpAllowedHosts, strings.ToLower(u.Hostname())) {
+		secureURL := *u
+		secureURL.Scheme = "https"
+		p.links = append(p.links, link{rawLink: l, lineNumber: lineNumber, URL: u, fatalError: newLinkcheckError(insecureLinkErrorCategory, "http links are not allowed, use %q instead", secureURL.String())})
+		return
+	}
+	p.links = append(p.links, link{rawLink: l, lineNumber: lineNumber, URL: u})
+}
+
+// urlFragment returns a fragment using the --anchor-separator, e.g. !section, in the url form, e.g. #section.
+func urlFragment(fragment string) string {
+	return anchorSeparator + strings.TrimPrefix(fragment, *anchorSep)
+}
+
+// stripLinkPrefixes drops from an absolute link path the first of the --strip-link-prefixes matching it,
+// e.g. /src/tasks/page becomes /tasks/page.
+func stripLinkPrefixes(linkPath string) string {
+	for _, prefix := range *stripPrefixes {
+		prefix = "/" + strings.Trim(prefix, "/") + "/"
+		if strings.HasPrefix(linkPath, prefix) {
+			return "/" + strings.TrimPrefix(linkPath, prefix)
+		}
+	}
+	return linkPath
+}
+
+// isAssetPath returns true if the path points to an asset, e.g. a .pdf file, instead of a page.
+func isAssetPath(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == "" {
+		return false
+	}
+	for _, e := range *assetExtensions {
+		if strings.ToLower(e) == ext {
+			return true
+		}
+	}
+	return false
 }
 
 // isDirectory determines if a file represented
 // by `path` is a directory or not
 func isDirectory(path string) (bool, error) {
-	fileInfo, err := os.Stat(path)
+	fileInfo, err := statFile(path)
 	if err != nil {
-		if os.IsNotExist(err) {
+		if errors.Is(err, fs.ErrNotExist) {
 			return false, nil
 		}
 		return false, err
@@ -225,11 +553,89 @@ func isDirectory(path string) (bool, error) {
 	return fileInfo.IsDir(), err
 }
 
+// hugoPathOf returns the path of a page relative to the content dir of its language, always in the /folder/page.md form,
+// so links resolved from the page dir, e.g. same page fragments, do not depend on how the page path has been built.
+func hugoPathOf(pagePath, languageDir string) string {
+	return path.Join("/", filepath.ToSlash(strings.TrimPrefix(filepath.Clean(pagePath), filepath.Clean(languageDir))))
+}
+
+// sitePath returns the path where the page is published, e.g. /folder/page for <content>/folder/page.md.
+func (p *page) sitePath() string {
+	s := strings.TrimSuffix(p.hugoPath, ".md")
+	s = strings.TrimSuffix(s, "/_index")
+	if s == "" {
+		return "/"
+	}
+	return s
+}
+
+// hasOpaqueFragments returns true if the page uses fragments for client side routing, e.g. single page apps.
+func hasOpaqueFragments(p *page) bool {
+	for _, pattern := range *opaqueFragments {
+		if ok, _ := path.Match(pattern, p.sitePath()); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// anchorPattern defines anchors matching a pattern on pages whose site path matches a pattern.
+type anchorPattern struct {
+	page   string
+	anchor string
+}
+
+// parseIgnoreAnchors returns the anchor patterns defined by a list of page#anchor patterns.
+// NOTE: page and anchor are separated by --anchor-separator, the same used in links.
+func parseIgnoreAnchors(values []string) ([]anchorPattern, error) {
+	patterns := []anchorPattern{}
+	for _, v := range values {
+		page, anchor, ok := strings.Cut(v, *anchorSep)
+		if !ok || page == "" || anchor == "" {
+			return nil, errors.Errorf("invalid anchor pattern %q, must be in the page%sanchor form", v, *anchorSep)
+		}
+		for _, pattern := range []string{page, anchor} {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, errors.Wrapf(err, "invalid anchor pattern %q", v)
+			}
+		}
+		patterns = append(patterns, anchorPattern{page: page, anchor: anchor})
+	}
+	return patterns, nil
+}
+
+// ignoredAnchorPatterns defines the anchors not checked, as set by --ignore-anchor.
+var ignoredAnchorPatterns = []anchorPattern{}
+
+// loadIgnoreAnchors parses --ignore-anchor, so errors are reported before checking links.
+func loadIgnoreAnchors() error {
+	patterns, err := parseIgnoreAnchors(*ignoreAnchors)
+	if err != nil {
+		return err
+	}
+	ignoredAnchorPatterns = patterns
+	return nil
+}
+
+// isIgnoredAnchor returns true if a fragment of a link to a page matches one of the --ignore-anchor patterns,
+// e.g. anchors generated at runtime by javascript.
+func isIgnoredAnchor(p *page, fragment string) bool {
+	for _, pattern := range ignoredAnchorPatterns {
+		if ok, _ := path.Match(pattern.page, p.sitePath()); !ok {
+			continue
+		}
+		if ok, _ := path.Match(pattern.anchor, fragment); ok {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *page) logPath() string {
 	if p.isHugoPage {
-		return fmt.Sprintf("<site>/content/%s%s", p.hugoLanguage, p.hugoPath)
+		return fmt.Sprintf("<site>/%s%s", filepath.ToSlash(strings.TrimPrefix(languageContentDir(p.hugoLanguage), filepath.Join(*root, *hugoFolder)+string(filepath.Separator))), p.hugoPath)
 	}
-	return fmt.Sprintf("<root>/%s", strings.TrimPrefix(p.path, *root))
+	return fmt.Sprintf("<root>/%s", strings.TrimPrefix(p.path, *root+string(filepath.Separator)))
 }
 
 // This pattern applies to the addr part of [text](addr) and searches for {{< tag "value" >}}, captures both tag and value values.
@@ -240,31 +646,76 @@ func parseLink(rawLink string) (path, fragment, language string, err error) {
 	// if the rawLink is a ref/refLink shortcode.
 	// NOTE: this makes .md files easier to write/read; it is also aligned with common practice in use for the K8s website.
 	refs := refRx.FindAllStringSubmatch(rawLink, -1)
+	// NOTE: with --allow-ref-shortcodes, the target of the shortcode is checked instead.
 	if len(refs) == 1 && (refs[0][1] == "ref" || refs[0][1] == "refLink") {
-		return "", "", "", errors.Errorf("ref/refLink shortcodes must not be used, use %q instead", refs[0][2])
+		if *allowRefs {
+			path, fragment = parseRefTarget(refs[0][2])
+			return path, fragment, "", nil
+		}
+		return "", "", "", newForbiddenLinkError("ref/refLink shortcodes must not be used, use %q instead", refs[0][2])
 	}
 
 	// Otherwise it is a plain markdown link.
 	path, fragment = splitPathAndFragment(rawLink)
 
+	// The fragment must not carry the page file name, e.g. page#_index or page#section.md, which is a frequent mistake
+	// when mixing the path of the page and the anchor.
+	if f := strings.TrimPrefix(fragment, *anchorSep); f == "_index" || filepath.Ext(f) == ".md" {
+		suggestion := path
+		if f = strings.TrimSuffix(f, ".md"); f != "_index" {
+			suggestion = fmt.Sprintf("%s%s%s", path, *anchorSep, f)
+		}
+		if suggestion != "" {
+			return "", "", "", newForbiddenLinkError("link fragments must not reference page files, use %q instead", suggestion)
+		}
+	}
+
 	// In hugo the folder name must be used when referring to "_index.md"
 	if filepath.Base(path) == "_index.md" {
-		return "", "", "", errors.Errorf("links must not end with _index.md, use \"%s/\" instead", filepath.Dir(path))
+		return "", "", "", newForbiddenLinkError("links must not end with _index.md, use %q instead", fmt.Sprintf("%s/%s", filepath.Dir(path), fragment))
 	}
 
 	// In hugo the file name must not have the .md extension.
 	if filepath.Ext(path) == ".md" {
-		return "", "", "", errors.Errorf("links must not have .md extension, use \"%s%s\" instead", strings.TrimSuffix(path, ".md"), fragment)
+		return "", "", "", newForbiddenLinkError("links must not have .md extension, use %q instead", fmt.Sprintf("%s%s", strings.TrimSuffix(path, ".md"), fragment))
 	}
 
 	return path, fragment, "", nil
 }
 
+// parseRefTarget returns the path and the fragment of the page targeted by a ref/refLink shortcode, e.g. "page.md#anchor",
+// in the same form used by plain markdown links, e.g. page and #anchor; as in hugo, paths are relative to the page,
+// the .md extension is optional, and _index.md targets the folder.
+func parseRefTarget(target string) (string, string) {
+	path, fragment := splitPathAndFragment(target)
+	path = strings.TrimSuffix(path, ".md")
+	if filepath.Base(path) == "_index" {
+		path = strings.TrimSuffix(filepath.Dir(path), "/") + "/"
+	}
+	return path, fragment
+}
+
+// forbiddenLinkError is returned by parseLink for links using a forbidden form.
+type forbiddenLinkError struct {
+	message string
+
+	// suggestion is the form that should be used instead of the forbidden one.
+	suggestion string
+}
+
+func newForbiddenLinkError(format, suggestion string) *forbiddenLinkError {
+	return &forbiddenLinkError{message: fmt.Sprintf(format, suggestion), suggestion: suggestion}
+}
+
+func (e *forbiddenLinkError) Error() string {
+	return e.message
+}
+
 func splitPathAndFragment(addr string) (string, string) {
 	path := addr
 	fragment := ""
-	if strings.Contains(path, anchorSeparator) {
-		s := strings.Split(path, anchorSeparator)
+	if strings.Contains(path, *anchorSep) {
+		s := strings.Split(path, *anchorSep)
 		fragment = strings.TrimPrefix(path, s[0])
 		path = s[0]
 	}
@@ -275,152 +726,744 @@ func splitPathAndFragment(addr string) (string, string) {
 
 // readAll markdown pages from the root folder.
 func readAll() error {
-	if err := filepath.Walk(*root,
-		func(path string, info os.FileInfo, err error) error {
+	if err := walkFiles(*root,
+		func(path string, d fs.DirEntry, err error) error {
+			// Errors are reported on the page, so a file or folder that cannot be read never aborts the walk.
 			if err != nil {
+				// NOTE: a folder is visited twice when it can be read but its content cannot; in this
+				// case the error is attributed to the page already added for the first visit, if any.
+				if p, ok := pagesByPath[path]; ok {
+					if p.fatalError == nil {
+						p.fatalError = newLinkcheckError(pageErrorCategory, "Error walking path %s: %v", path, err)
+					}
+					return nil
+				}
 				addPage(newPageWithFatalError(path, fmt.Sprintf("Error walking path %s: %v", path, err)))
 				return nil
 			}
 
 			if filepath.Ext(path) == ".md" {
 				addPage(readMarkdownPage(path))
+				enqueueExternalLinks(pagesByPath[path])
 			}
 			return nil
 		}); err != nil {
 		return errors.Errorf("Error walking path %s: %v", *root, err)
 	}
+
+	// Index the pages served at permalinks and the taxonomy pages, which depend on the front matter of the pages.
+	indexPermalinks()
+	indexTaxonomies()
 	return nil
 }
 
+// checkLanguageIndexes checks that every language of the hugo website has its root _index.md page.
+func checkLanguageIndexes() {
+	if *hugoFolder == "" {
+		return
+	}
+
+	for _, l := range *hugoLanguages {
+		indexPath := filepath.Join(languageContentDir(l), "_index.md")
+		if _, err := statFile(indexPath); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				addPage(newPageWithFatalError(indexPath, fmt.Sprintf("language %s does not have a root _index.md page", l)))
+				continue
+			}
+			addPage(newPageWithFatalError(indexPath, fmt.Sprintf("Error checking root _index.md page for language %s: %v", l, err)))
+		}
+	}
+}
+
 // readMarkdownPage reads a markdown page
 func readMarkdownPage(path string) page {
 	p := newPage(path)
 
 	// Gets the page content.
-	content, err := os.ReadFile(path)
+	content, err := readFile(path)
 	if err != nil {
-		p.fatalError = fmt.Sprintf("Error reading content: %v", err)
+		p.fatalError = newLinkcheckError(pageErrorCategory, "Error reading content: %v", err)
 		return p
 	}
 
+	// Extracts anchors, links and warnings from the page content, unless the page is unchanged since the
+	// run writing the --cache-file; then links are resolved, because targets could be changed.
+	e, ok := cachedExtraction(path, content)
+	if !ok {
+		var extractErr *linkcheckError
+		e, extractErr = extractMarkdownPage(content)
+		if extractErr != nil {
+			p.fatalError = extractErr
+			return p
+		}
+		cacheExtraction(path, content, e)
+	}
+	p.applyExtraction(e)
+
+	// Gets the dates the page is published between, if required.
+	if *checkPublishDates {
+		p.readPublishDates()
+	}
+	return p
+}
+
+// extractMarkdownPage extracts the front matter, the anchors, the links and the warnings from the content of a markdown page.
+func extractMarkdownPage(content []byte) (*extraction, *linkcheckError) {
+	e := &extraction{}
+
+	// Normalize CRLF line endings, so trailing \r are not captured in links or anchors.
+	body := strings.ReplaceAll(string(content), "\r\n", "\n")
+
+	// Gets the page front matter.
+	var err error
+	e.FrontMatter, err = readFrontMatter(body)
+	if err != nil {
+		return nil, newLinkcheckError(pageErrorCategory, "Error reading front matter: %v", err)
+	}
+	e.Terms, err = readFrontMatterTerms(body)
+	if err != nil {
+		return nil, newLinkcheckError(pageErrorCategory, "Error reading front matter: %v", err)
+	}
+
 	// Gets the list of anchors in the page.
-	p.anchors = readMarkdownAnchors(string(content))
+	var levels []int
+	e.Anchors, levels, e.TabAnchors = readTabPanelAnchors(body)
+	e.Anchors = append(e.Anchors, readShortcodeAnchors(body)...)
+	e.Anchors = append(e.Anchors, readBlockAttributeAnchors(body)...)
+	e.Anchors = append(e.Anchors, readHTMLAnchors(body)...)
+	e.Anchors = append(e.Anchors, readListItemAnchors(body)...)
+	e.Anchors = append(e.Anchors, readCodeFenceAnchors(body)...)
+
+	// Gets warnings for pages without exactly one H1 header, if required.
+	if *requireSingleH1 {
+		e.addWarnings(readH1Warnings(levels)...)
+	}
+
+	// Gets warnings for anchors colliding with ids reserved by hugo or by the theme.
+	e.addWarnings(readReservedAnchorWarnings(e.Anchors)...)
+
+	// Gets warnings for footnotes without a definition or never referenced.
+	e.addWarnings(readFootnoteWarnings(body)...)
 
 	// Gets the list of links in the page.
-	for i, line := range strings.Split(string(content), "\n") {
-		links := readMarkdownLineLinks(line)
+	inCodeFence := false
+	inYAMLCodeFence := false
+	inComment := false
+	inSkippedRegion := false
+	for i, line := range strings.Split(body, "\n") {
+		// Skip regions between the skip markers, e.g. generated content where links are validated elsewhere.
+		// NOTE: markers are often html comments, so they are searched before dropping html comments.
+		if *skipBeginMarker != "" {
+			if inSkippedRegion {
+				inSkippedRegion = !strings.Contains(line, *skipEndMarker)
+				continue
+			}
+			if strings.Contains(line, *skipBeginMarker) {
+				inSkippedRegion = !strings.Contains(line[strings.Index(line, *skipBeginMarker):], *skipEndMarker)
+				continue
+			}
+		}
+
+		if codeFenceRx.MatchString(line) && !inComment {
+			inCodeFence = !inCodeFence
+			inYAMLCodeFence = inCodeFence && isYAMLCodeFence(line)
+		}
+
+		// Drop the parts of the line inside html comments, e.g. commented out markdown.
+		if !inCodeFence {
+			line, inComment = stripHTMLComments(line, inComment)
+		}
+
+		// Read links from lines too long for regexes with a linear scan, to protect runtime on pathological content.
+		if *maxLineLength > 0 && len(line) > *maxLineLength {
+			e.addWarnings(warning{lineNumber: i + 1, message: fmt.Sprintf("the line is %d bytes long, more than the %d allowed; only [text](addr) links and ![alt](addr) images are checked", len(line), *maxLineLength)})
+			links, images := readLongLineLinks(line)
+			for _, l := range links {
+				e.addLink(l, i+1)
+			}
+			for _, image := range images {
+				e.addImage(image, i+1)
+			}
+			continue
+		}
+
+		links := readLineLinks(line)
 		for _, l := range links {
-			p.addLink(l, i+1)
+			e.addLink(l, i+1)
+		}
+		if !inCodeFence {
+			for _, l := range readShortcodeLinks(line, linkShortcodeParams) {
+				e.addLink(l, i+1)
+			}
+		}
+		for _, image := range readMarkdownLineImages(line) {
+			e.addImage(image, i+1)
+		}
+		for _, m := range readUnterminatedLinks(line) {
+			e.addWarnings(warning{lineNumber: i + 1, message: fmt.Sprintf("malformed link %s is missing a closing parenthesis", m)})
+		}
+		if m := baseRx.FindStringSubmatch(line); m != nil && !inCodeFence {
+			e.addWarnings(warning{lineNumber: i + 1, message: fmt.Sprintf("base element with href %q found; relative links are checked ignoring it, but they could resolve differently in the browser", m[1])})
+		}
+		if !inCodeFence {
+			e.addWarnings(readLinkTextWarnings(line, i+1)...)
+		}
+		if *checkBareURLs && !inCodeFence {
+			for _, u := range readBareURLs(line) {
+				e.addLink(u, i+1)
+			}
+		}
+		if *checkYAMLComments && inYAMLCodeFence {
+			for _, u := range readYAMLCommentURLs(line) {
+				e.addLink(u, i+1)
+			}
 		}
 	}
-	return p
+	return e, nil
+}
+
+// extractor extracts links from a line of a page.
+type extractor interface {
+	// extractLinks returns the links defined in a line.
+	extractLinks(line string) []string
+}
+
+// extractors is the list of extractors used for reading links from pages.
+var extractors []extractor
+
+// registerExtractor adds an extractor to the list of extractors used for reading links from pages.
+func registerExtractor(e extractor) {
+	extractors = append(extractors, e)
+}
+
+func init() {
+	registerExtractor(markdownExtractor{})
+}
+
+// markdownExtractor extracts markdown links from a line of a page.
+type markdownExtractor struct{}
+
+func (markdownExtractor) extractLinks(line string) []string {
+	return readMarkdownLineLinks(line)
+}
+
+// readLineLinks returns the links defined in a line using all the registered extractors.
+func readLineLinks(line string) (links []string) {
+	for _, e := range extractors {
+		links = append(links, e.extractLinks(line)...)
+	}
+	return
 }
 
 // Search for markdown headers.
 // (?m) is required to force multiline search due to ^ and $ used to exclude other things on the same line.
-var anchorRx = regexp.MustCompile(`(?m)^\s*\#+\s*(.+)$`)
+// (?:[ \t]*>)* is required to capture headers in blockquotes, e.g. > ## header.
+var anchorRx = regexp.MustCompile(`(?m)^(?:[ \t]*>)*\s*(\#+)\s*(.+)$`)
 
-func readMarkdownAnchors(body string) (anchors []string) {
+// readMarkdownAnchors returns the anchors generated by markdown headers, and the level of each header,
+// e.g. 1 for # header, 2 for ## header.
+func readMarkdownAnchors(body string) (anchors []string, levels []int) {
 	// TODO: check if we need to do something for repeated anchors
 	mv := anchorRx.FindAllStringSubmatch(body, -1)
 	for _, m := range mv {
-		ref := strings.ToLower(strings.TrimSpace(m[1]))
-		ref = strings.ReplaceAll(ref, " ", "-")
-		ref = strings.ReplaceAll(ref, "/", "")
-		anchors = append(anchors, ref)
+		levels = append(levels, len(m[1]))
+
+		// If the heading has an id attribute, e.g. ## heading {#id}, the id is the anchor.
+		if id, _ := readAttributeID(m[2]); id != "" {
+			anchors = append(anchors, id)
+			continue
+		}
+		anchors = append(anchors, anchorFromHeading(stripInlineMarkdown(m[2])))
 	}
 	return
 }
 
-// Search for links in the format [text](addr), captures addr value.
-// [^\!] is required to drop image links ![]()
-var lRx = regexp.MustCompile(`[^\!]\[[^\]]+\]\(([^\)]+)\)`)
-
-// Search for reference links in the format [text]: addr, captures addr value.
-var referencelRx = regexp.MustCompile(`^\s+\[[^\]]+\]\:\s+(.+)$`)
-
-func readMarkdownLineLinks(line string) (links []string) {
-	mv := lRx.FindAllStringSubmatch(line, -1)
-	for _, m := range mv {
-		links = append(links, m[1])
+// readH1Warnings returns a warning if a page does not have exactly one H1 header.
+func readH1Warnings(levels []int) []warning {
+	h1s := 0
+	for _, l := range levels {
+		if l == 1 {
+			h1s++
+		}
 	}
-	mv = referencelRx.FindAllStringSubmatch(line, -1)
-	for _, m := range mv {
-		links = append(links, m[1])
+	switch h1s {
+	case 0:
+		return []warning{{message: "the page does not have an H1 header"}}
+	case 1:
+		return nil
+	default:
+		return []warning{{message: fmt.Sprintf("the page has %d H1 headers, only one is allowed", h1s)}}
 	}
-	return
 }
 
-// linkcheckAll all pages.
-func linkcheckAll() error {
-	for i := range pages {
-		p := pages[i]
+// readReservedAnchorWarnings returns a warning for each anchor colliding with an id reserved by hugo or by the theme.
+// NOTE: ids are case sensitive in HTML, so anchors are compared with reserved ids as they are.
+func readReservedAnchorWarnings(anchors []string) (warnings []warning) {
+	for _, a := range anchors {
+		for _, r := range *reservedAnchors {
+			if a == r {
+				warnings = append(warnings, warning{message: fmt.Sprintf("anchor #%s collides with an id reserved by hugo or by the theme", a)})
+			}
+		}
+	}
+	return warnings
+}
 
-		// Perform page link check, which can take some time depending by the number of urls.
-		linkcheckPage(p.path)
+// Search for inline code spans in the format `code`, captures code value.
+var inlineCodeRx = regexp.MustCompile("(`+)([^`]*)`+")
 
-		// When page validation is completed, update page.
-		// TODO: check if we need this because linkcheckPage changes the page in place...
-		pages[i] = p
+// Search for inline links and images in the format [text](addr) or ![text](addr), captures text value.
+var inlineLinkRx = regexp.MustCompile(`!?\[([^\]]*)\]\([^\)]*\)`)
+
+// Search for emphasis in the format **text**, __text__, *text* or _text_, captures text value.
+// NOTE: underscores inside words, e.g. snake_case, are not emphasis.
+var (
+	strongAsteriskRx   = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	strongUnderscoreRx = regexp.MustCompile(`(^|\W)__(.+?)__(\W|$)`)
+	emAsteriskRx       = regexp.MustCompile(`\*([^\*]+)\*`)
+	emUnderscoreRx     = regexp.MustCompile(`(^|\W)_([^_]+)_(\W|$)`)
+)
+
+// stripInlineMarkdown returns the text of a heading without inline markdown, e.g. code spans, emphasis or links,
+// like hugo does before generating the anchor for a heading.
+// NOTE: the text of code spans is kept as is.
+func stripInlineMarkdown(heading string) string {
+	text := ""
+	for {
+		m := inlineCodeRx.FindStringSubmatchIndex(heading)
+		if m == nil {
+			return text + stripInlineFormatting(heading)
+		}
+		text += stripInlineFormatting(heading[:m[0]]) + heading[m[4]:m[5]]
+		heading = heading[m[1]:]
 	}
-	return nil
 }
 
-func linkcheckPage(path string) {
-	p, ok := pagesByPath[path]
-	if !ok {
-		panic(fmt.Sprintf("linkcheckPage %s which has not been read before", path))
+// stripInlineFormatting returns a text without emphasis and links.
+func stripInlineFormatting(text string) string {
+	text = inlineLinkRx.ReplaceAllString(text, "$1")
+	text = strongAsteriskRx.ReplaceAllString(text, "$1")
+	text = strongUnderscoreRx.ReplaceAllString(text, "$1$2$3")
+	text = emAsteriskRx.ReplaceAllString(text, "$1")
+	return emUnderscoreRx.ReplaceAllString(text, "$1$2$3")
+}
+
+// fragmentHint returns a hint for fragments containing uppercase letters or spaces, e.g. #My Section.
+func fragmentHint(fragment string) string {
+	if strings.ToLower(fragment) == fragment && !strings.Contains(fragment, " ") {
+		return ""
 	}
+	return fmt.Sprintf("fragments should be lowercase with hyphens instead of spaces, e.g. %s", anchorFromHeading(fragment))
+}
 
-	// If the page already has been marked with a fatal error, link should not be checked.
-	if p.fatalError != "" {
-		return
+const (
+	// trailingSlashAlways requires links to folders to end with a trailing slash, e.g. /folder/.
+	trailingSlashAlways = "always"
+
+	// trailingSlashNever requires links to folders to not end with a trailing slash, e.g. /folder.
+	trailingSlashNever = "never"
+)
+
+// trailingSlashHint returns a hint for links to folders not following the --trailing-slash policy.
+func trailingSlashHint(linkPath string) string {
+	switch {
+	case *trailingSlash == trailingSlashAlways && !strings.HasSuffix(linkPath, "/"):
+		return fmt.Sprintf("links to folders should end with a trailing slash, e.g. %s/", linkPath)
+	case *trailingSlash == trailingSlashNever && strings.HasSuffix(linkPath, "/") && linkPath != "/":
+		return fmt.Sprintf("links to folders should not end with a trailing slash, e.g. %s", strings.TrimRight(linkPath, "/"))
 	}
+	return ""
+}
 
-	for i, l := range p.links {
-		// If the link already has been marked with a fatal error, skip it.
-		if l.fatalError != "" {
-			continue
-		}
+// anchorFromHeading returns the anchor generated for a heading.
+func anchorFromHeading(heading string) string {
+	ref := strings.ToLower(strings.TrimSpace(heading))
+	ref = strings.ReplaceAll(ref, " ", "-")
+	ref = strings.ReplaceAll(ref, "/", "")
+	return ref
+}
+
+// Search for links in the format [text](addr), captures text and addr values.
+// [^\!] is required to drop image links ![](); ^ is required to capture links at the beginning of the line.
+var lRx = regexp.MustCompile(`(?:^|[^\!])\[([^\]]+)\]\(([^\)]+)\)`)
+
+const (
+	htmlCommentStart = "<!--"
+	htmlCommentEnd   = "-->"
+)
+
+// stripHTMLComments returns a line without the parts inside html comments, and whether the line
+// ends inside a comment; inComment defines whether the line starts inside a comment.
+func stripHTMLComments(line string, inComment bool) (string, bool) {
+	visible := ""
+	for {
+		if inComment {
+			end := strings.Index(line, htmlCommentEnd)
+			if end < 0 {
+				return visible, true
+			}
+			line = line[end+len(htmlCommentEnd):]
+			inComment = false
+			continue
+		}
+		start := strings.Index(line, htmlCommentStart)
+		if start < 0 {
+			return visible + line, false
+		}
+		visible += line[:start]
+		line = line[start+len(htmlCommentStart):]
+		inComment = true
+	}
+}
+
+// Search for html base elements in the format <base href="addr">, captures addr value.
+var baseRx = regexp.MustCompile(`(?i)<base\s[^>]*href\s*=\s*["']([^"']*)["']`)
+
+// Search for link openers in the format [text](, which are not matched by lRx when the closing parenthesis is missing.
+var linkOpenerRx = regexp.MustCompile(`\[[^\]]+\]\(`)
+
+// readUnterminatedLinks returns links in the format [text](addr without a closing parenthesis on the same line.
+func readUnterminatedLinks(line string) (links []string) {
+	openers := linkOpenerRx.FindAllStringIndex(line, -1)
+	for i, o := range openers {
+		// The link ends at the next opener or at the end of the line.
+		end := len(line)
+		if i+1 < len(openers) {
+			end = openers[i+1][0]
+		}
+		if !strings.Contains(line[o[1]:end], ")") {
+			links = append(links, strings.TrimSpace(line[o[0]:end]))
+		}
+	}
+	return
+}
+
+// Search for reference links in the format [text]: addr, captures addr value.
+// (?:\s*>)* is required to capture reference links in blockquotes, e.g. > [text]: addr.
+// NOTE: footnote definitions, e.g. [^1]: text, are not reference links.
+var referencelRx = regexp.MustCompile(`^(?:\s*>)*\s+\[[^\^\]][^\]]*\]\:\s+(.+)$`)
+
+// readMarkdownLineLinks returns the links and reference links defined in a line.
+// NOTE: most of the lines do not contain links, so regexes are used only when the line contains
+// the "](" or "]:" delimiters required for a match; this is way faster on big pages.
+func readMarkdownLineLinks(line string) (links []string) {
+	if strings.IndexByte(line, ']') < 0 {
+		return
+	}
+	if strings.Contains(line, "](") {
+		for _, m := range lRx.FindAllStringSubmatch(line, -1) {
+			links = append(links, m[2])
+		}
+	}
+	if strings.Contains(line, "]:") {
+		for _, m := range referencelRx.FindAllStringSubmatch(line, -1) {
+			links = append(links, m[1])
+		}
+	}
+	return
+}
+
+// linkcheckAll all pages.
+// NOTE: when streaming, the result of each page is printed to w as soon as the page is checked.
+func linkcheckAll(ctx context.Context, w io.Writer) error {
+	if *workers < 1 {
+		return errors.Errorf("invalid workers value %d, must be at least 1", *workers)
+	}
+
+	// Folders are listed again for each run, because files could be changed since the previous one.
+	resetDirNames()
+
+	// Pages are checked in parallel by a pool of workers; each page is checked by a single
+	// worker, so links are always processed in the order they are defined in the page.
+	// NOTE: when streaming, pages are printed as soon as they are checked, so the order depends on the workers.
+	var wg sync.WaitGroup
+	var streamLock sync.Mutex
+	queue := make(chan *page)
+	for n := 0; n < *workers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range queue {
+				// Perform page link check, which can take some time depending by the number of urls.
+				linkcheckPage(ctx, p.path)
+
+				// NOTE: SARIF documents are printed as a whole, so they can't be streamed.
+				if *stream && currentLogLevel() > quietLogLevel && *format != formatSARIF {
+					streamLock.Lock()
+					if *format == formatGCC {
+						printPageGCC(w, p)
+					} else {
+						printPage(w, p)
+					}
+					p.streamedWarnings = len(p.warnings)
+					streamLock.Unlock()
+				}
+			}
+		}()
+	}
+
+	// If the context is done, e.g. because --timeout-total expired, the remaining pages are not checked,
+	// and the results already collected are reported.
+	for i := range pages {
+		select {
+		case queue <- pages[i]:
+		case <-ctx.Done():
+			pages[i].warnings = append(pages[i].warnings, warning{message: fmt.Sprintf("links have not been checked: %v", ctx.Err())})
+		}
+	}
+	close(queue)
+	wg.Wait()
+	return nil
+}
+
+func linkcheckPage(ctx context.Context, path string) {
+	linkcheckPageLinks(ctx, path, nil)
+}
+
+// linkcheckPageLinks checks the links of a page accepted by filter, or all the links if filter is nil.
+func linkcheckPageLinks(ctx context.Context, path string, filter func(l link) bool) {
+	p, ok := pagesByPath[path]
+	if !ok {
+		panic(fmt.Sprintf("linkcheckPage %s which has not been read before", path))
+	}
+
+	// If the page already has been marked with a fatal error, link should not be checked.
+	if p.fatalError != nil {
+		return
+	}
+
+	for i, l := range p.links {
+		// If the link already has been marked with a fatal error, skip it.
+		if l.fatalError != nil {
+			continue
+		}
+
+		// If the link is not accepted by the filter, skip it.
+		if filter != nil && !filter(l) {
+			continue
+		}
 
 		// If it is a file url (no scheme is considered file url)
 		if l.URL.Scheme == "" {
+			// If only external links are checked, skip it.
+			if !checksInternal() {
+				continue
+			}
+
 			// Check the links targets an existing page.
-			if _, err := os.Stat(l.URL.Path); errors.Is(err, os.ErrNotExist) {
-				l.fatalError = fmt.Sprintf("the link resolves to %s which does not exist", strings.TrimPrefix(l.URL.Path, *root))
-				p.links[i] = l
+			// NOTE: if the link targets a redirected path, the target of the redirect is checked instead; if the link
+			// targets the permalink of a page, e.g. /blog/:slug, the page is checked instead; with --language-fallback, if the
+			// link targets a missing translation, the page in the default language is checked instead, including anchors.
+			targetPath := l.URL.Path
+			if _, err := statFile(targetPath); errors.Is(err, fs.ErrNotExist) && isTaxonomyPath(targetPath) {
+				// Taxonomy and term pages are generated by hugo, so there is nothing else to check.
+				debugf("%s line %d, %s: taxonomy page generated by hugo", p.logPath(), l.lineNumber, l.rawLink)
 				continue
 			}
+			if _, err := statFile(targetPath); errors.Is(err, fs.ErrNotExist) {
+				if permalinkPath, ok := resolvePermalink(targetPath); ok {
+					debugf("%s line %d, %s: permalink of %s", p.logPath(), l.lineNumber, l.rawLink, permalinkPath)
+					targetPath = permalinkPath
+				}
+			}
+			if _, err := statFile(targetPath); errors.Is(err, fs.ErrNotExist) && *languageFallback {
+				if fallbackPath, ok := resolveLanguageFallback(targetPath); ok {
+					debugf("%s line %d, %s: missing translation, falling back to %s", p.logPath(), l.lineNumber, l.rawLink, fallbackPath)
+					targetPath = fallbackPath
+				}
+			}
+			if _, err := statFile(targetPath); errors.Is(err, fs.ErrNotExist) {
+				redirectedPath, ok := resolveRedirect(targetPath)
+				if !ok {
+					l.targetPath = targetPath
+					l.fatalError = newLinkcheckError(missingFileErrorCategory, "the link resolves to %s which does not exist", strings.TrimPrefix(targetPath, *root))
+					p.links[i] = l
+					continue
+				}
+				if _, err := statFile(redirectedPath); errors.Is(err, fs.ErrNotExist) {
+					l.targetPath = redirectedPath
+					l.fatalError = newLinkcheckError(missingFileErrorCategory, "the link resolves to %s which redirects to %s which does not exist", strings.TrimPrefix(targetPath, *root), strings.TrimPrefix(redirectedPath, *root))
+					p.links[i] = l
+					continue
+				}
+				debugf("%s line %d, %s: redirected to %s", p.logPath(), l.lineNumber, l.rawLink, redirectedPath)
+				targetPath = redirectedPath
+			}
 
-			targetp, ok := pagesByPath[l.URL.Path]
+			// If the link uses a casing different from the target on disk, e.g. on case insensitive filesystems, report it
+			// because the link does not work on case sensitive hosts; then continue checking the target on disk.
+			if canonicalTargetPath, err := canonicalPath(targetPath); err == nil && canonicalTargetPath != targetPath {
+				p.warnings = append(p.warnings, casingWarning(l, canonicalTargetPath))
+				targetPath = canonicalTargetPath
+			}
+			l.targetPath = targetPath
+			p.links[i] = l
+
+			// If the link targets an image or an asset, e.g. a .pdf file, or a file other than pages outside the hugo
+			// website, there is nothing else to check, except fragments referencing lines, e.g. file.go#L10-L20.
+			if l.isImage || isAssetPath(targetPath) || isRepoDocFile(p, targetPath) {
+				if category, message := checkLineRange(targetPath, l.URL.Fragment); message != "" {
+					l.fatalError = newLinkcheckError(category, "%s", message)
+					p.links[i] = l
+				}
+				continue
+			}
+
+			targetp, ok := pagesByPath[targetPath]
 			if !ok {
-				// TODO: this should never happen (if we protect from link outside root). Might be we should panic here...
-				l.fatalError = fmt.Sprintf("the link resolves to %s which has not been processed by linkcheck", l.URL.Path)
+				// The target exists but has not been read, e.g. it is not a markdown page nor an asset, so report why.
+				l.fatalError = unprocessedTargetError(targetPath)
+				p.links[i] = l
+				continue
+			}
+
+			// If the link targets a draft page, which is not published, report it.
+			if targetp.frontMatter.Draft && !*includeDrafts {
+				l.fatalError = newLinkcheckError(unpublishedPageErrorCategory, "the link resolves to %s which is a draft page", targetp.logPath())
 				p.links[i] = l
 				continue
 			}
 
+			// If the link targets a page not yet published or expired, report it.
+			if *checkPublishDates {
+				t := referenceTime()
+				if d := targetp.publishDate; !d.IsZero() && d.After(t) {
+					l.fatalError = newLinkcheckError(unpublishedPageErrorCategory, "the link resolves to %s which will be published on %s", targetp.logPath(), d.Format(time.RFC3339))
+					p.links[i] = l
+					continue
+				}
+				if d := targetp.expiryDate; !d.IsZero() && !d.After(t) {
+					l.fatalError = newLinkcheckError(unpublishedPageErrorCategory, "the link resolves to %s which expired on %s", targetp.logPath(), d.Format(time.RFC3339))
+					p.links[i] = l
+					continue
+				}
+			}
+
 			// If the link targets an anchor, check it exists.
-			if l.URL.Fragment != "" {
+			// NOTE: fragments on pages using them for client side routing are opaque, and thus not checked.
+			if l.URL.Fragment != "" && !hasOpaqueFragments(targetp) {
 				found := false
 				for _, a := range targetp.anchors {
-					if l.URL.Fragment == a {
+					if anchorMatches(l.URL.Fragment, a) {
 						found = true
 						break
 					}
 				}
+				if !found && isIgnoredAnchor(targetp, l.URL.Fragment) {
+					debugf("%s line %d, %s: %s%s matches --ignore-anchor, not checked", p.logPath(), l.lineNumber, l.rawLink, *anchorSep, l.URL.Fragment)
+					continue
+				}
+				if !found && isTabPanelAnchor(targetp, l.URL.Fragment) {
+					debugf("%s line %d, %s: %s%s is a heading inside a tab panel, not checked", p.logPath(), l.lineNumber, l.rawLink, *anchorSep, l.URL.Fragment)
+					continue
+				}
+				// A page without headings has no anchors at all, which is a clearer reason than a generic missing anchor.
+				if !found && len(targetp.anchors) == 0 && len(targetp.tabAnchors) == 0 {
+					l.fatalError = newLinkcheckError(missingAnchorErrorCategory, "%s%s does not exist, the target page %s has no headings/anchors", *anchorSep, l.URL.Fragment, targetp.logPath())
+					p.links[i] = l
+					continue
+				}
 				if !found {
-					l.fatalError = fmt.Sprintf("%s%s does exists in %s", anchorSeparator, l.URL.Fragment, targetp.logPath())
+					l.fatalError = newLinkcheckError(missingAnchorErrorCategory, "%s%s does exists in %s", *anchorSep, l.URL.Fragment, targetp.logPath())
 					p.links[i] = l
 					continue
 				}
 			}
 		}
-		// TODO: handle http or https; use a map of links to avoid duplicated http calls.
+
+		// If it is an http/https url, check it if required.
+		if checksExternal() && isExternalLink(l.URL) {
+			if err := checkExternalLink(ctx, l.URL); err != "" {
+				l.fatalError = newLinkcheckError(externalErrorCategory, "%s", err)
+				p.links[i] = l
+			}
+		}
 	}
 	return
 }
 
+// reportUnusedAnchors warns about anchors that are not targeted by any link in the website.
+func reportUnusedAnchors() {
+	fragmentsByPath := map[string][]string{}
+	for i := range pages {
+		for _, l := range pages[i].links {
+			if l.URL != nil && l.URL.Scheme == "" && l.URL.Fragment != "" {
+				fragmentsByPath[l.URL.Path] = append(fragmentsByPath[l.URL.Path], l.URL.Fragment)
+			}
+		}
+	}
+
+	for i := range pages {
+		p := pages[i]
+		for _, a := range p.anchors {
+			used := false
+			for _, f := range fragmentsByPath[p.path] {
+				if anchorMatches(f, a) {
+					used = true
+					break
+				}
+			}
+			if !used {
+				p.warnings = append(p.warnings, warning{message: fmt.Sprintf("anchor %s%s is not referenced by any link", *anchorSep, a)})
+			}
+		}
+	}
+}
+
+// checksInternal returns true if links to files, pages and anchors are checked.
+func checksInternal() bool {
+	return !*onlyExternal
+}
+
+// checksExternal returns true if http and https links are checked.
+func checksExternal() bool {
+	return (*checkExternal || *onlyExternal) && !*onlyInternal
+}
+
+// nowTime is the time set by --now, if any.
+var nowTime time.Time
+
+// loadNow parses --now, so errors are reported before checking links.
+func loadNow() error {
+	nowTime = time.Time{}
+	if *now == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, *now)
+	if err != nil {
+		return err
+	}
+	nowTime = t
+	return nil
+}
+
+// referenceTime returns the time to be used when checking publish dates.
+func referenceTime() time.Time {
+	if !nowTime.IsZero() {
+		return nowTime
+	}
+	return time.Now()
+}
+
+// anchorMatches returns true if a link fragment matches a page anchor.
+// NOTE: when fuzzy anchors are enabled, hyphens and spaces are dropped from both sides before comparing, so
+// differences in how authors/renderers are handling spaces in headers are tolerated.
+// NOTE: both sides are NFC normalized, so accented characters match no matter if they are encoded as
+// precomposed or decomposed characters.
+func anchorMatches(fragment, anchor string) bool {
+	fragment = norm.NFC.String(fragment)
+	anchor = norm.NFC.String(anchor)
+	if *fuzzyAnchors {
+		return normalizeFuzzyAnchor(fragment) == normalizeFuzzyAnchor(anchor)
+	}
+	return fragment == anchor
+}
+
+func normalizeFuzzyAnchor(a string) string {
+	a = strings.ReplaceAll(a, "-", "")
+	return strings.ReplaceAll(a, " ", "")
+}
+
 func main() {
 	pflag.Parse()
 	if *root == "." {
@@ -440,68 +1483,295 @@ func main() {
 		root = pointer.String(path)
 	}
 
+	if *hugoConfigFlag != "" {
+		if err := applyHugoConfig(); err != nil {
+			fmt.Printf("ERROR: failed to apply --hugo-config: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if err := loadLogLevel(); err != nil {
+		fmt.Printf("ERROR: failed to parse --log-level: %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, pattern := range *opaqueFragments {
+		if _, err := path.Match(pattern, ""); err != nil {
+			fmt.Printf("ERROR: failed to parse --opaque-fragments pattern %q: %v\n", pattern, err)
+			os.Exit(1)
+		}
+	}
+
+	if *tabHeadings != tabHeadingsAnchor && *tabHeadings != tabHeadingsSkip {
+		fmt.Printf("ERROR: invalid --tab-headings value %q, must be one of %s, %s\n", *tabHeadings, tabHeadingsAnchor, tabHeadingsSkip)
+		os.Exit(1)
+	}
+
+	if *trailingSlash != "" && *trailingSlash != trailingSlashAlways && *trailingSlash != trailingSlashNever {
+		fmt.Printf("ERROR: invalid --trailing-slash value %q, must be one of %s, %s\n", *trailingSlash, trailingSlashAlways, trailingSlashNever)
+		os.Exit(1)
+	}
+
+	if *watch < 0 {
+		fmt.Printf("ERROR: invalid --watch value %s, must not be negative\n", *watch)
+		os.Exit(1)
+	}
+
+	if *externalRequests < 0 {
+		fmt.Printf("ERROR: invalid --external-requests value %d, must not be negative\n", *externalRequests)
+		os.Exit(1)
+	}
+
+	if *externalQueue < 0 {
+		fmt.Printf("ERROR: invalid --external-queue-size value %d, must not be negative\n", *externalQueue)
+		os.Exit(1)
+	}
+
+	for _, pattern := range *allowSchemeless {
+		if _, err := path.Match(pattern, ""); err != nil {
+			fmt.Printf("ERROR: failed to parse --allow-schemeless-paths pattern %q: %v\n", pattern, err)
+			os.Exit(1)
+		}
+	}
+
+	if err := validateFormat(*format); err != nil {
+		fmt.Printf("ERROR: failed to parse --format: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := validateGroupBy(*groupBy); err != nil {
+		fmt.Printf("ERROR: failed to parse --group-by: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := validateListItemAnchors(*listItemAnchors); err != nil {
+		fmt.Printf("ERROR: failed to parse --list-item-anchors: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := validateCodeFenceAnchors(*codeFenceAnchors); err != nil {
+		fmt.Printf("ERROR: failed to parse --code-fence-anchors: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *anchorSep == "" {
+		fmt.Printf("ERROR: --anchor-separator must not be empty\n")
+		os.Exit(1)
+	}
+
+	if err := loadIgnoreAnchors(); err != nil {
+		fmt.Printf("ERROR: failed to parse --ignore-anchor: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := loadSeverities(); err != nil {
+		fmt.Printf("ERROR: failed to parse --severity: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := loadLinkShortcodes(); err != nil {
+		fmt.Printf("ERROR: failed to parse --link-shortcodes: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := loadVersions(); err != nil {
+		fmt.Printf("ERROR: failed to parse --versions: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *renderedBaseURL != "" {
+		if _, err := parseRenderedBaseURL(); err != nil {
+			fmt.Printf("ERROR: failed to parse --rendered-base-url: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if err := loadNow(); err != nil {
+		fmt.Printf("ERROR: failed to parse --now: %v\n", err)
+		os.Exit(1)
+	}
+
+	if (*skipBeginMarker == "") != (*skipEndMarker == "") {
+		fmt.Printf("ERROR: --skip-begin-marker and --skip-end-marker must be set together\n")
+		os.Exit(1)
+	}
+
+	if *dryRun && !*fix {
+		fmt.Printf("ERROR: --dry-run requires --fix\n")
+		os.Exit(1)
+	}
+
+	if *onlyExternal && *onlyInternal {
+		fmt.Printf("ERROR: --only-external and --only-internal are mutually exclusive\n")
+		os.Exit(1)
+	}
+
+	if checksExternal() {
+		if err := loadNetrc(); err != nil {
+			fmt.Printf("ERROR: failed to load netrc: %v\n", err)
+			os.Exit(1)
+		}
+		if err := loadExternalRules(); err != nil {
+			fmt.Printf("ERROR: failed to load external rules: %v\n", err)
+			os.Exit(1)
+		}
+		loadExternalResolvers()
+	}
+
+	if err := loadLanguageContentDirs(); err != nil {
+		fmt.Printf("ERROR: failed to load language content dirs: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := loadRedirects(); err != nil {
+		fmt.Printf("ERROR: failed to load redirects: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := loadPermalinks(); err != nil {
+		fmt.Printf("ERROR: failed to load permalinks: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := loadTaxonomies(); err != nil {
+		fmt.Printf("ERROR: failed to load taxonomies: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := loadUglyURLs(); err != nil {
+		fmt.Printf("ERROR: failed to load uglyURLs: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := loadAssetsManifest(); err != nil {
+		fmt.Printf("ERROR: failed to load assets manifest: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := loadReportTemplate(); err != nil {
+		fmt.Printf("ERROR: failed to load report template: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := loadPageCache(); err != nil {
+		fmt.Printf("ERROR: failed to load cache: %v\n", err)
+		os.Exit(1)
+	}
+
+	// NOTE: --timeout-total starts before reading pages, because external links are checked while pages are read
+	// when --external-queue-size is set.
+	ctx := context.Background()
+	if *timeoutTotal > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeoutTotal)
+		defer cancel()
+	}
+
+	startExternalChecks(ctx)
+
 	if err := readAll(); err != nil {
 		fmt.Printf("ERROR: failed to read pages: %v\n", err)
 		os.Exit(1)
 	}
+	closeExternalPipeline()
 
-	if err := linkcheckAll(); err != nil {
-		fmt.Printf("ERROR: failed to check links on pages: %v\n", err)
+	if err := savePageCache(); err != nil {
+		fmt.Printf("ERROR: failed to save cache: %v\n", err)
 		os.Exit(1)
 	}
 
-	sort.SliceStable(pages, func(i, j int) bool { return pages[i].path < pages[j].path })
+	checkLanguageIndexes()
+	checkAssetsManifest()
+	checkAliasCollisions()
 
-	fmt.Println()
+	if *checkMenuEntries {
+		if err := checkMenus(); err != nil {
+			fmt.Printf("ERROR: failed to check menus: %v\n", err)
+			os.Exit(1)
+		}
+	}
 
-	a := 0
-	l := 0
-	for i := range pages {
-		p := pages[i]
-		a += len(p.anchors)
-		l += len(p.links)
+	if err := checkTOC(); err != nil {
+		fmt.Printf("ERROR: failed to check toc: %v\n", err)
+		os.Exit(1)
+	}
 
-		s := ""
-		prints := false
-		s += fmt.Sprintf("PAGE: %s\n", p.logPath())
+	pendingFixes := 0
+	if *fix {
 		switch {
-		case p.fatalError != "":
-			prints = true
-			s += fmt.Sprintln()
-			s += fmt.Sprintf(" - ERROR: %s\n", p.fatalError)
-			break
-		default:
-			t := ""
-			errorst := 0
-			for _, l := range p.links {
-				switch {
-				case l.fatalError != "":
-					prints = true
-					errorst++
-					t += fmt.Sprintf(" - ERROR: line %d, %s: %s\n", l.lineNumber, l.rawLink, l.fatalError)
-					break
-				default:
-					if *verbose {
-						t += fmt.Sprintf(" - OK: line %d, %s\n", l.lineNumber, l.rawLink)
-					}
-				}
-			}
-			switch errorst {
-			case 0:
-				s += fmt.Sprintf("      %d links, no errors\n\n", len(p.links))
-				break
-			default:
-				s += fmt.Sprintf("      %d links, %d errors\n\n", len(p.links), errorst)
+		case *dryRun:
+			n, err := diffAll(os.Stdout)
+			if err != nil {
+				fmt.Printf("ERROR: failed to compute fixes for links on pages: %v\n", err)
+				os.Exit(1)
 			}
-			if t != "" {
-				s += fmt.Sprintf("%s\n", t)
+			pendingFixes = n
+		default:
+			if err := fixAll(os.Stdout); err != nil {
+				fmt.Printf("ERROR: failed to fix links on pages: %v\n", err)
+				os.Exit(1)
 			}
 		}
+	}
+
+	if *renderedBaseURL != "" {
+		if err := checkRenderedAll(); err != nil {
+			fmt.Printf("ERROR: failed to check rendered pages: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if err := linkcheckAll(ctx, os.Stdout); err != nil {
+		fmt.Printf("ERROR: failed to check links on pages: %v\n", err)
+		os.Exit(1)
+	}
+	stopExternalPipeline()
+
+	if *unusedAnchors {
+		reportUnusedAnchors()
+	}
+
+	if *indexCycles {
+		reportIndexCycles()
+	}
+
+	if *dualValidate {
+		dualValidateAll()
+	}
+
+	if err := printReport(os.Stdout); err != nil {
+		fmt.Printf("ERROR: failed to print report: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *summaryJSON != "" {
+		if err := writeSummaryJSON(*summaryJSON); err != nil {
+			fmt.Printf("ERROR: failed to write summary: %v\n", err)
+			os.Exit(1)
+		}
+	}
 
-		if *verbose || prints {
-			fmt.Print(s)
+	if *watch > 0 {
+		watchCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		err := watchPages(watchCtx, os.Stdout, *watch)
+		stop()
+		if err != nil {
+			fmt.Printf("ERROR: failed to watch pages: %v\n", err)
+			os.Exit(1)
 		}
 	}
-	fmt.Printf("Total page processed: %d links: %d anchors: %d \n", len(pages), l, a)
 
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		fmt.Printf("ERROR: --timeout-total %s expired before all links were checked\n", *timeoutTotal)
+		os.Exit(timeoutExitCode)
+	}
+
+	if pendingFixes > 0 {
+		fmt.Printf("ERROR: %d links must be fixed, run with --fix\n", pendingFixes)
+		os.Exit(1)
+	}
+
+	if hasErrors() {
+		os.Exit(1)
+	}
 }
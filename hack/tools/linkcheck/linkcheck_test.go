@@ -20,11 +20,18 @@ limitations under the License.
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 )
@@ -56,12 +63,32 @@ func Test_newPage(t *testing.T) {
 				hugoPath:     "/test.md",
 			},
 		},
+		{
+			name: "page in a nested folder of the hugo website",
+			path: "/root/hugo/content/en/folder/test.md",
+			wantPage: page{
+				path:         "/root/hugo/content/en/folder/test.md",
+				isHugoPage:   true,
+				hugoLanguage: "en",
+				hugoPath:     "/folder/test.md",
+			},
+		},
+		{
+			name: "page in the hugo website - path not clean",
+			path: "/root/hugo/content/en//folder/./test.md",
+			wantPage: page{
+				path:         "/root/hugo/content/en//folder/./test.md",
+				isHugoPage:   true,
+				hugoLanguage: "en",
+				hugoPath:     "/folder/test.md",
+			},
+		},
 		{
 			name: "page in the hugo website - invalid language",
 			path: "/root/hugo/content/it/test.md",
 			wantPage: page{
 				path:       "/root/hugo/content/it/test.md",
-				fatalError: "hugo page /it/test.md does not belong to one of the know languages: en",
+				fatalError: newLinkcheckError(pageErrorCategory, "hugo page /it/test.md does not belong to one of the know languages: en"),
 				isHugoPage: true,
 			},
 		},
@@ -94,7 +121,7 @@ func Test_addUrl(t *testing.T) {
 			wantUrl: link{
 				rawLink:    "$$$%%%???",
 				lineNumber: 1,
-				fatalError: "error parsing url: parse \"$$$%%%???\": invalid URL escape \"%%%\"",
+				fatalError: newLinkcheckError(invalidLinkErrorCategory, "%s", "error parsing url: parse \"$$$%%%???\": invalid URL escape \"%%%\""),
 			},
 		},
 		{
@@ -107,6 +134,26 @@ func Test_addUrl(t *testing.T) {
 				URL:        mustParseUrl("https://www.google.com"),
 			},
 		},
+		{
+			name: "protocol-relative url",
+			path: "/root/test.md",
+			url:  "//cdn.example.com/lib.js",
+			wantUrl: link{
+				rawLink:    "//cdn.example.com/lib.js",
+				lineNumber: 1,
+				URL:        mustParseUrl("https://cdn.example.com/lib.js"),
+			},
+		},
+		{
+			name: "protocol-relative url in the hugo website",
+			path: "/root/hugo/content/en/test.md",
+			url:  "//cdn.example.com/lib.js#section",
+			wantUrl: link{
+				rawLink:    "//cdn.example.com/lib.js#section",
+				lineNumber: 1,
+				URL:        mustParseUrl("https://cdn.example.com/lib.js#section"),
+			},
+		},
 		{
 			name: "file url",
 			path: "/root/test.md",
@@ -114,7 +161,7 @@ func Test_addUrl(t *testing.T) {
 			wantUrl: link{
 				rawLink:    "another-page.md",
 				lineNumber: 1,
-				fatalError: "scheme is required on links outside the hugo website",
+				fatalError: newLinkcheckError(invalidLinkErrorCategory, "scheme is required on links outside the hugo website"),
 			},
 		},
 
@@ -126,7 +173,7 @@ func Test_addUrl(t *testing.T) {
 			wantUrl: link{
 				rawLink:    "$$$%%%???",
 				lineNumber: 1,
-				fatalError: "error parsing url: parse \"$$$%%%???\": invalid URL escape \"%%%\"",
+				fatalError: newLinkcheckError(invalidLinkErrorCategory, "%s", "error parsing url: parse \"$$$%%%???\": invalid URL escape \"%%%\""),
 			},
 		},
 		{
@@ -134,9 +181,10 @@ func Test_addUrl(t *testing.T) {
 			path: "/root/hugo/content/en/test.md",
 			url:  "{{< ref \"something\" >}}",
 			wantUrl: link{
-				rawLink:    "{{< ref \"something\" >}}",
-				lineNumber: 1,
-				fatalError: "ref/refLink shortcodes must not be used, use \"something\" instead",
+				rawLink:      "{{< ref \"something\" >}}",
+				lineNumber:   1,
+				fatalError:   newLinkcheckError(forbiddenLinkErrorCategory, "ref/refLink shortcodes must not be used, use \"something\" instead"),
+				suggestedFix: "something",
 			},
 		},
 		{
@@ -144,9 +192,10 @@ func Test_addUrl(t *testing.T) {
 			path: "/root/hugo/content/en/test.md",
 			url:  "something/_index.md",
 			wantUrl: link{
-				rawLink:    "something/_index.md",
-				lineNumber: 1,
-				fatalError: "links must not end with _index.md, use \"something/\" instead",
+				rawLink:      "something/_index.md",
+				lineNumber:   1,
+				fatalError:   newLinkcheckError(forbiddenLinkErrorCategory, "links must not end with _index.md, use \"something/\" instead"),
+				suggestedFix: "something/",
 			},
 		},
 		{
@@ -199,6 +248,16 @@ func Test_addUrl(t *testing.T) {
 				URL:        mustParseUrl("/root/hugo/content/en/folder/another.md#anchor"),
 			},
 		},
+		{
+			name: "relative path url linking an asset",
+			path: "/root/hugo/content/en/folder/test.md",
+			url:  "files/doc.pdf",
+			wantUrl: link{
+				rawLink:    "files/doc.pdf",
+				lineNumber: 1,
+				URL:        mustParseUrl("/root/hugo/content/en/folder/files/doc.pdf"),
+			},
+		},
 		{
 			name: "absolute path url linking a file in content/en",
 			path: "/root/hugo/content/en/folder/test.md",
@@ -221,6 +280,64 @@ func Test_addUrl(t *testing.T) {
 	}
 }
 
+func Test_addUrl_requireHTTPS(t *testing.T) {
+	cancel := setFlags("/root", "hugo", []string{"en"})
+	defer cancel()
+
+	tests := []struct {
+		name    string
+		url     string
+		wantUrl link
+	}{
+		{
+			name: "https link",
+			url:  "https://example.com/docs",
+			wantUrl: link{
+				rawLink:    "https://example.com/docs",
+				lineNumber: 1,
+				URL:        mustParseUrl("https://example.com/docs"),
+			},
+		},
+		{
+			name: "http link",
+			url:  "http://example.com/docs?q=1#section",
+			wantUrl: link{
+				rawLink:    "http://example.com/docs?q=1#section",
+				lineNumber: 1,
+				URL:        mustParseUrl("http://example.com/docs?q=1#section"),
+				fatalError: newLinkcheckError(insecureLinkErrorCategory, "http links are not allowed, use \"https://example.com/docs?q=1#section\" instead"),
+			},
+		},
+		{
+			name: "http link to an allowed host",
+			url:  "http://localhost:1313/docs",
+			wantUrl: link{
+				rawLink:    "http://localhost:1313/docs",
+				lineNumber: 1,
+				URL:        mustParseUrl("http://localhost:1313/docs"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			requireHTTPSBefore := *requireHTTPS
+			defer func() { *requireHTTPS = requireHTTPSBefore }()
+
+			p := newPage("/root/hugo/content/en/test.md")
+
+			*requireHTTPS = false
+			p.addLink(tt.url, 1)
+			g.Expect(p.links[0].fatalError).To(BeNil())
+
+			*requireHTTPS = true
+			p.addLink(tt.url, 1)
+			g.Expect(p.links[1]).To(Equal(tt.wantUrl))
+		})
+	}
+}
+
 func Test_linkcheckPage(t *testing.T) {
 	g := NewWithT(t)
 
@@ -246,10 +363,17 @@ func Test_linkcheckPage(t *testing.T) {
 	indexp := newPage(filepath.Join(contentDir, "en/folder/_index.md"))
 	indexp.anchors = []string{"anchor"}
 
+	touch(g, filepath.Join(contentDir, "en/files/doc.pdf"))
+
+	touch(g, filepath.Join(contentDir, "en/draft.md"))
+	draftp := newPage(filepath.Join(contentDir, "en/draft.md"))
+	draftp.frontMatter.Draft = true
+
 	tests := []struct {
-		name      string
-		page      func() page
-		wantLinks []link
+		name          string
+		includeDrafts bool
+		page          func() page
+		wantLinks     []link
 	}{
 		// TODO: pages outside the hugo website
 
@@ -266,6 +390,7 @@ func Test_linkcheckPage(t *testing.T) {
 					rawLink:    "test",
 					lineNumber: 1,
 					URL:        mustParseUrl(filepath.Join(contentDir, "en/test.md")),
+					targetPath: filepath.Join(contentDir, "en/test.md"),
 				},
 			},
 		},
@@ -281,6 +406,7 @@ func Test_linkcheckPage(t *testing.T) {
 					rawLink:    "another",
 					lineNumber: 1,
 					URL:        mustParseUrl(filepath.Join(contentDir, "en/another.md")),
+					targetPath: filepath.Join(contentDir, "en/another.md"),
 				},
 			},
 		},
@@ -297,6 +423,7 @@ func Test_linkcheckPage(t *testing.T) {
 					rawLink:    "test#anchor",
 					lineNumber: 1,
 					URL:        mustParseUrl(filepath.Join(contentDir, "en/test.md#anchor")),
+					targetPath: filepath.Join(contentDir, "en/test.md"),
 				},
 			},
 		},
@@ -313,6 +440,7 @@ func Test_linkcheckPage(t *testing.T) {
 					rawLink:    "#anchor",
 					lineNumber: 1,
 					URL:        mustParseUrl(filepath.Join(contentDir, "en/test.md#anchor")),
+					targetPath: filepath.Join(contentDir, "en/test.md"),
 				},
 			},
 		},
@@ -328,6 +456,7 @@ func Test_linkcheckPage(t *testing.T) {
 					rawLink:    "another#anchor",
 					lineNumber: 1,
 					URL:        mustParseUrl(filepath.Join(contentDir, "en/another.md#anchor")),
+					targetPath: filepath.Join(contentDir, "en/another.md"),
 				},
 			},
 		},
@@ -343,6 +472,7 @@ func Test_linkcheckPage(t *testing.T) {
 					rawLink:    "/folder",
 					lineNumber: 1,
 					URL:        mustParseUrl(filepath.Join(contentDir, "en/folder/_index.md")),
+					targetPath: filepath.Join(contentDir, "en/folder/_index.md"),
 				},
 			},
 		},
@@ -358,6 +488,7 @@ func Test_linkcheckPage(t *testing.T) {
 					rawLink:    "/folder#anchor",
 					lineNumber: 1,
 					URL:        mustParseUrl(filepath.Join(contentDir, "en/folder/_index.md#anchor")),
+					targetPath: filepath.Join(contentDir, "en/folder/_index.md"),
 				},
 			},
 		},
@@ -373,6 +504,7 @@ func Test_linkcheckPage(t *testing.T) {
 					rawLink:    "../folder#anchor",
 					lineNumber: 1,
 					URL:        mustParseUrl(filepath.Join(contentDir, "en/folder/_index.md#anchor")),
+					targetPath: filepath.Join(contentDir, "en/folder/_index.md"),
 				},
 			},
 		},
@@ -388,7 +520,8 @@ func Test_linkcheckPage(t *testing.T) {
 					rawLink:    "invalid",
 					lineNumber: 1,
 					URL:        mustParseUrl(filepath.Join(contentDir, "en/invalid.md")),
-					fatalError: fmt.Sprintf("the link resolves to %s which does not exist", "/hugo/content/en/invalid.md"),
+					targetPath: filepath.Join(contentDir, "en/invalid.md"),
+					fatalError: newLinkcheckError(missingFileErrorCategory, "the link resolves to %s which does not exist", "/hugo/content/en/invalid.md"),
 				},
 			},
 		},
@@ -404,7 +537,8 @@ func Test_linkcheckPage(t *testing.T) {
 					rawLink:    "#invalid",
 					lineNumber: 1,
 					URL:        mustParseUrl(filepath.Join(contentDir, "en/test.md#invalid")),
-					fatalError: "#invalid does exists in <site>/content/en/test.md",
+					targetPath: filepath.Join(contentDir, "en/test.md"),
+					fatalError: newLinkcheckError(missingAnchorErrorCategory, "#invalid does not exist, the target page <site>/content/en/test.md has no headings/anchors"),
 				},
 			},
 		},
@@ -420,7 +554,75 @@ func Test_linkcheckPage(t *testing.T) {
 					rawLink:    "another#invalid",
 					lineNumber: 1,
 					URL:        mustParseUrl(filepath.Join(contentDir, "en/another.md#invalid")),
-					fatalError: "#invalid does exists in <site>/content/en/another.md",
+					targetPath: filepath.Join(contentDir, "en/another.md"),
+					fatalError: newLinkcheckError(missingAnchorErrorCategory, "#invalid does exists in <site>/content/en/another.md"),
+				},
+			},
+		},
+		{
+			name: "page with a link to a draft page",
+			page: func() page {
+				p := newPage(filepath.Join(contentDir, "en/test.md"))
+				p.addLink("draft", 1)
+				return p
+			},
+			wantLinks: []link{
+				{
+					rawLink:    "draft",
+					lineNumber: 1,
+					URL:        mustParseUrl(filepath.Join(contentDir, "en/draft.md")),
+					targetPath: filepath.Join(contentDir, "en/draft.md"),
+					fatalError: newLinkcheckError(unpublishedPageErrorCategory, "the link resolves to <site>/content/en/draft.md which is a draft page"),
+				},
+			},
+		},
+		{
+			name:          "page with a link to a draft page when drafts are included",
+			includeDrafts: true,
+			page: func() page {
+				p := newPage(filepath.Join(contentDir, "en/test.md"))
+				p.addLink("draft", 1)
+				return p
+			},
+			wantLinks: []link{
+				{
+					rawLink:    "draft",
+					lineNumber: 1,
+					URL:        mustParseUrl(filepath.Join(contentDir, "en/draft.md")),
+					targetPath: filepath.Join(contentDir, "en/draft.md"),
+				},
+			},
+		},
+		{
+			name: "page with a link to an asset",
+			page: func() page {
+				p := newPage(filepath.Join(contentDir, "en/test.md"))
+				p.addLink("files/doc.pdf", 1)
+				return p
+			},
+			wantLinks: []link{
+				{
+					rawLink:    "files/doc.pdf",
+					lineNumber: 1,
+					URL:        mustParseUrl(filepath.Join(contentDir, "en/files/doc.pdf")),
+					targetPath: filepath.Join(contentDir, "en/files/doc.pdf"),
+				},
+			},
+		},
+		{
+			name: "page with a link to a missing asset",
+			page: func() page {
+				p := newPage(filepath.Join(contentDir, "en/test.md"))
+				p.addLink("files/missing.yaml", 1)
+				return p
+			},
+			wantLinks: []link{
+				{
+					rawLink:    "files/missing.yaml",
+					lineNumber: 1,
+					URL:        mustParseUrl(filepath.Join(contentDir, "en/files/missing.yaml")),
+					targetPath: filepath.Join(contentDir, "en/files/missing.yaml"),
+					fatalError: newLinkcheckError(missingFileErrorCategory, "the link resolves to /hugo/content/en/files/missing.yaml which does not exist"),
 				},
 			},
 		},
@@ -430,41 +632,1880 @@ func Test_linkcheckPage(t *testing.T) {
 			g := NewWithT(t)
 
 			p := tt.page()
-			pages = []*page{&p, &anotherp, &indexp}
-			pagesByPath = map[string]*page{p.path: &p, anotherp.path: &anotherp, indexp.path: &indexp}
+			includeDraftsBefore := *includeDrafts
+			defer func() { *includeDrafts = includeDraftsBefore }()
+			*includeDrafts = tt.includeDrafts
+
+			pages = []*page{&p, &anotherp, &indexp, &draftp}
+			pagesByPath = map[string]*page{p.path: &p, anotherp.path: &anotherp, indexp.path: &indexp, draftp.path: &draftp}
 
-			linkcheckPage(p.path)
+			linkcheckPage(context.Background(), p.path)
 
 			g.Expect(p.links).To(Equal(tt.wantLinks))
 		})
 	}
 }
 
-func setFlags(rootValue, hugoFolderValue string, hugoLanguagesValue []string) (resetFlags func()) {
-	rootBefore := root
-	hugoFolderBefore := hugoFolder
-	hugoLanguagesBefore := hugoLanguages
+func Test_linkcheckPage_publishDates(t *testing.T) {
+	g := NewWithT(t)
 
-	root = &rootValue
-	hugoFolder = &hugoFolderValue
-	hugoLanguages = &hugoLanguagesValue
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
 
-	return func() {
-		root = rootBefore
-		hugoFolder = hugoFolderBefore
-		hugoLanguages = hugoLanguagesBefore
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	checkPublishDatesBefore := *checkPublishDates
+	nowBefore := *now
+	nowTimeBefore := nowTime
+	defer func() {
+		*checkPublishDates = checkPublishDatesBefore
+		*now = nowBefore
+		nowTime = nowTimeBefore
+	}()
+	*checkPublishDates = true
+	*now = "2022-06-01T00:00:00Z"
+	g.Expect(loadNow()).To(Succeed())
+
+	contentDir := filepath.Join(root, "hugo", contentFolder)
+
+	touch(g, filepath.Join(contentDir, "en/expired.md"))
+	expiredp := newPage(filepath.Join(contentDir, "en/expired.md"))
+	expiredp.expiryDate = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	touch(g, filepath.Join(contentDir, "en/future.md"))
+	futurep := newPage(filepath.Join(contentDir, "en/future.md"))
+	futurep.publishDate = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	touch(g, filepath.Join(contentDir, "en/published.md"))
+	publishedp := newPage(filepath.Join(contentDir, "en/published.md"))
+	publishedp.publishDate = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	publishedp.expiryDate = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	p := newPage(filepath.Join(contentDir, "en/test.md"))
+	p.addLink("expired", 1)
+	p.addLink("future", 2)
+	p.addLink("published", 3)
+
+	pages = []*page{&p, &expiredp, &futurep, &publishedp}
+	pagesByPath = map[string]*page{p.path: &p, expiredp.path: &expiredp, futurep.path: &futurep, publishedp.path: &publishedp}
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	linkcheckPage(context.Background(), p.path)
+
+	g.Expect(p.links).To(Equal([]link{
+		{
+			rawLink:    "expired",
+			lineNumber: 1,
+			URL:        mustParseUrl(filepath.Join(contentDir, "en/expired.md")),
+			targetPath: filepath.Join(contentDir, "en/expired.md"),
+			fatalError: newLinkcheckError(unpublishedPageErrorCategory, "the link resolves to <site>/content/en/expired.md which expired on 2022-01-01T00:00:00Z"),
+		},
+		{
+			rawLink:    "future",
+			lineNumber: 2,
+			URL:        mustParseUrl(filepath.Join(contentDir, "en/future.md")),
+			targetPath: filepath.Join(contentDir, "en/future.md"),
+			fatalError: newLinkcheckError(unpublishedPageErrorCategory, "the link resolves to <site>/content/en/future.md which will be published on 2023-01-01T00:00:00Z"),
+		},
+		{
+			rawLink:    "published",
+			lineNumber: 3,
+			URL:        mustParseUrl(filepath.Join(contentDir, "en/published.md")),
+			targetPath: filepath.Join(contentDir, "en/published.md"),
+		},
+	}))
+}
+
+func Test_sitePath(t *testing.T) {
+	cancel := setFlags("/root", "hugo", []string{"en"})
+	defer cancel()
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/root/hugo/content/en/_index.md", want: "/"},
+		{path: "/root/hugo/content/en/page.md", want: "/page"},
+		{path: "/root/hugo/content/en/folder/_index.md", want: "/folder"},
+		{path: "/root/hugo/content/en/folder/page.md", want: "/folder/page"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			g := NewWithT(t)
+
+			p := newPage(tt.path)
+			g.Expect(p.sitePath()).To(Equal(tt.want))
+		})
 	}
 }
 
-func mustParseUrl(l string) *url.URL {
-	u, err := url.Parse(l)
-	if err != nil {
-		panic(err.Error())
+func Test_linkcheckPage_opaqueFragments(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	contentDir := filepath.Join(root, "hugo", contentFolder)
+
+	touch(g, filepath.Join(contentDir, "en/app.md"))
+	appp := newPage(filepath.Join(contentDir, "en/app.md"))
+
+	touch(g, filepath.Join(contentDir, "en/spa/console.md"))
+	consolep := newPage(filepath.Join(contentDir, "en/spa/console.md"))
+
+	tests := []struct {
+		name            string
+		opaqueFragments []string
+		wantErrors      []string
+	}{
+		{
+			name:            "fragments are checked by default",
+			opaqueFragments: []string{},
+			wantErrors: []string{
+				"#/route/x does not exist, the target page <site>/content/en/app.md has no headings/anchors",
+				"#/clusters does not exist, the target page <site>/content/en/spa/console.md has no headings/anchors",
+			},
+		},
+		{
+			name:            "fragments are not checked on pages with opaque fragments",
+			opaqueFragments: []string{"/app", "/spa/*"},
+			wantErrors:      []string{"", ""},
+		},
+		{
+			name:            "fragments are checked on pages not matching opaque fragments",
+			opaqueFragments: []string{"/spa/*"},
+			wantErrors: []string{
+				"#/route/x does not exist, the target page <site>/content/en/app.md has no headings/anchors",
+				"",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			opaqueFragmentsBefore := *opaqueFragments
+			defer func() { *opaqueFragments = opaqueFragmentsBefore }()
+			*opaqueFragments = tt.opaqueFragments
+
+			p := newPage(filepath.Join(contentDir, "en/test.md"))
+			p.addLink("app#/route/x", 1)
+			p.addLink("/spa/console#/clusters", 2)
+
+			pages = []*page{&p, &appp, &consolep}
+			pagesByPath = map[string]*page{p.path: &p, appp.path: &appp, consolep.path: &consolep}
+			defer func() {
+				pages = nil
+				pagesByPath = nil
+			}()
+
+			linkcheckPage(context.Background(), p.path)
+
+			errs := []string{}
+			for _, l := range p.links {
+				msg := ""
+				if l.fatalError != nil {
+					msg = l.fatalError.Error()
+				}
+				errs = append(errs, msg)
+			}
+			g.Expect(errs).To(Equal(tt.wantErrors))
+		})
 	}
-	return u
 }
 
-func touch(g *WithT, path string) {
-	g.Expect(os.MkdirAll(filepath.Dir(path), os.ModePerm)).ToNot(HaveOccurred())
-	g.Expect(os.WriteFile(path, []byte(""), 0600)).To(Succeed())
+func Test_parseIgnoreAnchors(t *testing.T) {
+	g := NewWithT(t)
+
+	patterns, err := parseIgnoreAnchors([]string{"/reference/api/*#spec-*", "/*#field-*"})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(patterns).To(Equal([]anchorPattern{{page: "/reference/api/*", anchor: "spec-*"}, {page: "/*", anchor: "field-*"}}))
+
+	_, err = parseIgnoreAnchors([]string{"spec-*"})
+	g.Expect(err).To(HaveOccurred())
+	_, err = parseIgnoreAnchors([]string{"/reference#"})
+	g.Expect(err).To(HaveOccurred())
+	_, err = parseIgnoreAnchors([]string{"/reference/[#spec"})
+	g.Expect(err).To(HaveOccurred())
+
+	// Page and anchor are separated by --anchor-separator.
+	anchorSepBefore := *anchorSep
+	defer func() { *anchorSep = anchorSepBefore }()
+	*anchorSep = "!"
+
+	patterns, err = parseIgnoreAnchors([]string{"/reference/api/*!spec-*"})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(patterns).To(Equal([]anchorPattern{{page: "/reference/api/*", anchor: "spec-*"}}))
+
+	_, err = parseIgnoreAnchors([]string{"/reference/api/*#spec-*"})
+	g.Expect(err).To(MatchError(`invalid anchor pattern "/reference/api/*#spec-*", must be in the page!anchor form`))
+}
+
+func Test_readAllAndLinkcheckAll_ignoreAnchors(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	contentDir := filepath.Join(root, "hugo", contentFolder, "en")
+	write(g, filepath.Join(contentDir, "_index.md"), `# Home
+
+See [spec](/reference/api/cluster#spec-replicas), [status](/reference/api/cluster#status-ready) or [missing page](/reference/api/missing#spec-replicas).
+See [spec on another page](/guide#spec-replicas).
+`)
+	write(g, filepath.Join(contentDir, "reference/api/cluster.md"), "# Cluster\n")
+	write(g, filepath.Join(contentDir, "guide.md"), "# Guide\n")
+
+	ignoreAnchorsBefore := *ignoreAnchors
+	ignoredAnchorPatternsBefore := ignoredAnchorPatterns
+	defer func() {
+		*ignoreAnchors = ignoreAnchorsBefore
+		ignoredAnchorPatterns = ignoredAnchorPatternsBefore
+	}()
+	*ignoreAnchors = []string{"/reference/api/*#spec-*"}
+	g.Expect(loadIgnoreAnchors()).To(Succeed())
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+
+	// Ignored anchors are not checked, but the page must exist; non-matching anchors and pages are still checked.
+	g.Expect(collectErrors(root)).To(Equal([]string{
+		"/hugo/content/en/_index.md:3: /reference/api/cluster#status-ready: #status-ready does exists in <site>/content/en/reference/api/cluster.md",
+		"/hugo/content/en/_index.md:3: /reference/api/missing#spec-replicas: the link resolves to /hugo/content/en/reference/api/missing.md which does not exist",
+		"/hugo/content/en/_index.md:4: /guide#spec-replicas: #spec-replicas does exists in <site>/content/en/guide.md",
+	}))
+}
+
+func Test_reportUnusedAnchors(t *testing.T) {
+	g := NewWithT(t)
+
+	cancel := setFlags("/root", "hugo", []string{"en"})
+	defer cancel()
+
+	a := newPage("/root/hugo/content/en/a.md")
+	a.anchors = []string{"a", "referenced", "unreferenced"}
+	a.addLink("#a", 1)
+
+	b := newPage("/root/hugo/content/en/b.md")
+	b.anchors = []string{"b"}
+	b.addLink("a#referenced", 1)
+	b.addLink("https://example.com/a.md#unreferenced", 2)
+
+	pages = []*page{&a, &b}
+	defer func() { pages = nil }()
+
+	reportUnusedAnchors()
+
+	g.Expect(a.warnings).To(Equal([]warning{{message: "anchor #unreferenced is not referenced by any link"}}))
+	g.Expect(b.warnings).To(Equal([]warning{{message: "anchor #b is not referenced by any link"}}))
+}
+
+func Test_reportUnusedAnchors_stream(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		want   string
+	}{
+		{
+			name:   "text format",
+			format: formatText,
+			want: `PAGE: <site>/content/en/a.md
+      2 links, 1 errors
+
+ - ERROR: line 3, missing: the link resolves to /hugo/content/en/missing.md which does not exist
+
+
+PAGE: <site>/content/en/a.md
+
+ - WARNING: anchor #unreferenced is not referenced by any link
+
+Total page processed: 1 links: 2 anchors: 2 
+`,
+		},
+		{
+			name:   "gcc format",
+			format: formatGCC,
+			want: `hugo/content/en/a.md:3:15: error: missing: the link resolves to /hugo/content/en/missing.md which does not exist
+hugo/content/en/a.md:1:1: warning: anchor #unreferenced is not referenced by any link
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			root, err := os.MkdirTemp("", "linkcheck")
+			g.Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(root)
+
+			cancel := setFlags(root, "hugo", []string{"en"})
+			defer cancel()
+
+			streamBefore := *stream
+			formatBefore := *format
+			defer func() {
+				*stream = streamBefore
+				*format = formatBefore
+			}()
+			*stream = true
+			*format = tt.format
+
+			write(g, filepath.Join(root, "hugo", contentFolder, "en/a.md"), "# A\n\nSee [missing](missing) and [a](#a).\n\n## Unreferenced\n")
+
+			pages = nil
+			pagesByPath = nil
+			defer func() {
+				pages = nil
+				pagesByPath = nil
+			}()
+
+			g.Expect(readAll()).To(Succeed())
+
+			var out strings.Builder
+			g.Expect(linkcheckAll(context.Background(), &out)).To(Succeed())
+			reportUnusedAnchors()
+			g.Expect(printReport(&out)).To(Succeed())
+
+			// Warnings added after the pages have been streamed are printed with the report.
+			g.Expect(out.String()).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_anchorMatches(t *testing.T) {
+	tests := []struct {
+		name         string
+		fuzzyAnchors bool
+		fragment     string
+		anchor       string
+		want         bool
+	}{
+		{
+			name:         "exact match",
+			fuzzyAnchors: false,
+			fragment:     "my-heading",
+			anchor:       "my-heading",
+			want:         true,
+		},
+		{
+			name:         "spaces do not match hyphens",
+			fuzzyAnchors: false,
+			fragment:     "my-heading",
+			anchor:       "my heading",
+			want:         false,
+		},
+		{
+			name:         "spaces match hyphens with fuzzy anchors",
+			fuzzyAnchors: true,
+			fragment:     "my-heading",
+			anchor:       "my heading",
+			want:         true,
+		},
+		{
+			name:         "dropped hyphens match with fuzzy anchors",
+			fuzzyAnchors: true,
+			fragment:     "my-heading",
+			anchor:       "myheading",
+			want:         true,
+		},
+		{
+			name:         "different anchors does not match with fuzzy anchors",
+			fuzzyAnchors: true,
+			fragment:     "my-heading",
+			anchor:       "another-heading",
+			want:         false,
+		},
+		{
+			name:         "precomposed accent in the fragment matches decomposed accent in the heading",
+			fuzzyAnchors: false,
+			fragment:     "caf\u00e9-au-lait",
+			anchor:       anchorFromHeading("Cafe\u0301 au lait"),
+			want:         true,
+		},
+		{
+			name:         "decomposed accent in the fragment matches precomposed accent in the heading",
+			fuzzyAnchors: false,
+			fragment:     "cafe\u0301-au-lait",
+			anchor:       anchorFromHeading("Caf\u00e9 au lait"),
+			want:         true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			fuzzyAnchorsBefore := *fuzzyAnchors
+			defer func() { *fuzzyAnchors = fuzzyAnchorsBefore }()
+			*fuzzyAnchors = tt.fuzzyAnchors
+
+			g.Expect(anchorMatches(tt.fragment, tt.anchor)).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_checkLanguageIndexes(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	hugoFolder := "hugo"
+	hugoLanguages := []string{"en", "it"}
+
+	cancel := setFlags(root, hugoFolder, hugoLanguages)
+	defer cancel()
+
+	contentDir := filepath.Join(root, hugoFolder, contentFolder)
+
+	touch(g, filepath.Join(contentDir, "en/_index.md"))
+	touch(g, filepath.Join(contentDir, "it/test.md"))
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	checkLanguageIndexes()
+
+	g.Expect(pages).To(HaveLen(1))
+	g.Expect(pages[0].path).To(Equal(filepath.Join(contentDir, "it/_index.md")))
+	g.Expect(pages[0].fatalError.Error()).To(Equal("language it does not have a root _index.md page"))
+}
+
+func Test_readMarkdownPage_detailsBlock(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	path := filepath.Join(root, "hugo", contentFolder, "en/test.md")
+	write(g, path, `# Test
+
+See the [details](#collapsed-heading).
+
+<details>
+<summary>Click to expand</summary>
+
+## Collapsed heading
+
+</details>
+`)
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	addPage(readMarkdownPage(path))
+	linkcheckPage(context.Background(), path)
+
+	p := pagesByPath[path]
+	g.Expect(p.anchors).To(ContainElement("collapsed-heading"))
+	g.Expect(p.links).To(HaveLen(1))
+	g.Expect(p.links[0].fatalError).To(BeNil())
+}
+
+func Test_readMarkdownPage_CRLF(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	path := filepath.Join(root, "hugo", contentFolder, "en/test.md")
+	write(g, path, "---\r\ntitle: Test\r\ndraft: true\r\n---\r\n# Test\r\n\r\n## My heading\r\n\r\nSee [my heading](#my-heading).\r\n [reference]: https://example.com\r\n")
+
+	p := readMarkdownPage(path)
+	g.Expect(p.fatalError).To(BeNil())
+	g.Expect(p.frontMatter.Draft).To(BeTrue())
+	g.Expect(p.anchors).To(Equal([]string{"test", "my-heading"}))
+	g.Expect(p.links).To(HaveLen(2))
+	g.Expect(p.links[0].rawLink).To(Equal("#my-heading"))
+	g.Expect(p.links[0].lineNumber).To(Equal(9))
+	g.Expect(p.links[0].URL.Fragment).To(Equal("my-heading"))
+	g.Expect(p.links[1].rawLink).To(Equal("https://example.com"))
+}
+
+func Test_readMarkdownLineLinks(t *testing.T) {
+	tests := []struct {
+		name      string
+		line      string
+		wantLinks []string
+	}{
+		{
+			name:      "link in a sentence",
+			line:      "Read the [page](page).",
+			wantLinks: []string{"page"},
+		},
+		{
+			name:      "link at the beginning of the line",
+			line:      "[page](page) is a page.",
+			wantLinks: []string{"page"},
+		},
+		{
+			name:      "toc bullet list item",
+			line:      "- [Section](#section)",
+			wantLinks: []string{"#section"},
+		},
+		{
+			name:      "nested toc bullet list item",
+			line:      "  * [Sub section](#sub-section)",
+			wantLinks: []string{"#sub-section"},
+		},
+		{
+			name:      "image at the beginning of the line",
+			line:      "![image](image.png)",
+			wantLinks: nil,
+		},
+		{
+			name:      "reference link",
+			line:      " [page]: https://example.com",
+			wantLinks: []string{"https://example.com"},
+		},
+		{
+			name:      "link in a blockquote",
+			line:      "> see [page](page)",
+			wantLinks: []string{"page"},
+		},
+		{
+			name:      "link at the beginning of a blockquote",
+			line:      ">[page](page) is a page",
+			wantLinks: []string{"page"},
+		},
+		{
+			name:      "reference link in a blockquote",
+			line:      "> [page]: https://example.com",
+			wantLinks: []string{"https://example.com"},
+		},
+		{
+			name:      "link in a definition list",
+			line:      ": the definition, see [page](page)",
+			wantLinks: []string{"page"},
+		},
+		{
+			name:      "footnote definition",
+			line:      " [^1]: see the documentation",
+			wantLinks: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(readMarkdownLineLinks(tt.line)).To(Equal(tt.wantLinks))
+		})
+	}
+}
+
+// benchmarkCorpus returns a large synthetic corpus of markdown lines, mostly without links like real pages.
+func benchmarkCorpus() []string {
+	lines := []string{}
+	for i := 0; i < 10000; i++ {
+		switch i % 10 {
+		case 0:
+			lines = append(lines, fmt.Sprintf("See [page %d](/docs/page-%d#section) and [another page](../another-%d).", i, i, i))
+		case 1:
+			lines = append(lines, fmt.Sprintf("[reference %d]: https://example.com/%d", i, i))
+		case 2:
+			lines = append(lines, fmt.Sprintf("![image %d](/images/%d.png)", i, i))
+		case 3:
+			lines = append(lines, "")
+		default:
+			lines = append(lines, fmt.Sprintf("Line %d of a paragraph of text, long enough to be representative of real pages, without any link in it.", i))
+		}
+	}
+	return lines
+}
+
+// Results on a single core linux/amd64 machine:
+//
+//	before prefiltering: BenchmarkReadMarkdownLineLinks     32    35950797 ns/op
+//	after prefiltering:  BenchmarkReadMarkdownLineLinks    303     3857043 ns/op
+func BenchmarkReadMarkdownLineLinks(b *testing.B) {
+	lines := benchmarkCorpus()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for _, line := range lines {
+			readMarkdownLineLinks(line)
+		}
+	}
+}
+
+func Test_readMarkdownLineLinks_prefiltering(t *testing.T) {
+	g := NewWithT(t)
+
+	// readMarkdownLineLinks must return the same links returned by running the regexes on every line.
+	lines := append(benchmarkCorpus(),
+		"[text](addr)",
+		"> [text]: addr",
+		"  [text]: addr and [text](addr)",
+		"[text] (addr) and [text] : addr",
+		"![image](addr)",
+	)
+	for _, line := range lines {
+		var want []string
+		for _, m := range lRx.FindAllStringSubmatch(line, -1) {
+			want = append(want, m[2])
+		}
+		for _, m := range referencelRx.FindAllStringSubmatch(line, -1) {
+			want = append(want, m[1])
+		}
+		g.Expect(readMarkdownLineLinks(line)).To(Equal(want), line)
+	}
+}
+
+func Test_readUnterminatedLinks(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want []string
+	}{
+		{
+			name: "no links",
+			line: "some text (with parenthesis",
+			want: nil,
+		},
+		{
+			name: "terminated links",
+			line: "Read the [page](page) and the [other page](other).",
+			want: nil,
+		},
+		{
+			name: "unterminated link",
+			line: "Read the [page](page",
+			want: []string{"[page](page"},
+		},
+		{
+			name: "unterminated link followed by a link",
+			line: "Read the [page](page and the [other page](other).",
+			want: []string{"[page](page and the"},
+		},
+		{
+			name: "unterminated image",
+			line: "![image](image.png",
+			want: []string{"[image](image.png"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(readUnterminatedLinks(tt.line)).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_readMarkdownPage_unterminatedLink(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	path := filepath.Join(root, "hugo", contentFolder, "en", "a.md")
+	write(g, path, "# A\n\nRead the [page](b\nand the [other page](c).\n")
+
+	p := readMarkdownPage(path)
+	g.Expect(p.fatalError).To(BeNil())
+	g.Expect(p.links).To(HaveLen(1))
+	g.Expect(p.links[0].rawLink).To(Equal("c"))
+	g.Expect(p.warnings).To(Equal([]warning{{lineNumber: 3, message: "malformed link [page](b is missing a closing parenthesis"}}))
+}
+
+func Test_readMarkdownPage_blockquote(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	path := filepath.Join(root, "hugo", contentFolder, "en/test.md")
+	write(g, path, `# Test
+
+> ## Quoted heading
+>
+> See the [page](page) or the
+> [other page](other#quoted-heading).
+>> Nested [link](#quoted-heading).
+> [reference]: https://example.com
+`)
+
+	p := readMarkdownPage(path)
+	g.Expect(p.fatalError).To(BeNil())
+	g.Expect(p.anchors).To(Equal([]string{"test", "quoted-heading"}))
+
+	got := []string{}
+	for _, l := range p.links {
+		got = append(got, fmt.Sprintf("%d: %s", l.lineNumber, l.rawLink))
+	}
+	g.Expect(got).To(Equal([]string{
+		"5: page",
+		"6: other#quoted-heading",
+		"7: #quoted-heading",
+		"8: https://example.com",
+	}))
+}
+
+func Test_stripInlineMarkdown(t *testing.T) {
+	tests := []struct {
+		name    string
+		heading string
+		want    string
+	}{
+		{
+			name:    "plain text",
+			heading: "Use the foo command",
+			want:    "Use the foo command",
+		},
+		{
+			name:    "inline code",
+			heading: "Use the `foo` command",
+			want:    "Use the foo command",
+		},
+		{
+			name:    "inline code with emphasis markers",
+			heading: "The ``__init__`` and `*args` params",
+			want:    "The __init__ and *args params",
+		},
+		{
+			name:    "link",
+			heading: "Install [clusterctl](https://example.com/clusterctl) first",
+			want:    "Install clusterctl first",
+		},
+		{
+			name:    "image",
+			heading: "![logo](logo.png) Cluster API",
+			want:    "logo Cluster API",
+		},
+		{
+			name:    "emphasis",
+			heading: "**Strong**, __strong__, *em* and _em_ text",
+			want:    "Strong, strong, em and em text",
+		},
+		{
+			name:    "underscores inside words",
+			heading: "The snake_case_name param",
+			want:    "The snake_case_name param",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(stripInlineMarkdown(tt.heading)).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_readMarkdownPage_inlineMarkdownHeadings(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	path := filepath.Join(root, "hugo", contentFolder, "en/test.md")
+	write(g, path, "# Test\n\n## Use the `foo` command\n\n## Install [clusterctl](https://example.com)\n")
+
+	p := readMarkdownPage(path)
+	g.Expect(p.fatalError).To(BeNil())
+	g.Expect(p.anchors).To(Equal([]string{"test", "use-the-foo-command", "install-clusterctl"}))
+}
+
+func Test_readAllAndLinkcheckAll_fragmentHints(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	path := filepath.Join(root, "hugo", contentFolder, "en/_index.md")
+	write(g, path, `# Home
+
+## My Section
+
+See [space](#My Section), [uppercase](#My-Section) or [valid](#my-section).
+`)
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+
+	g.Expect(pagesByPath[path].warnings).To(Equal([]warning{
+		{lineNumber: 5, rawLink: "#My Section", message: "fragments should be lowercase with hyphens instead of spaces, e.g. #my-section"},
+		{lineNumber: 5, rawLink: "#My-Section", message: "fragments should be lowercase with hyphens instead of spaces, e.g. #my-section"},
+	}))
+	g.Expect(collectErrors(root)).To(Equal([]string{
+		"/hugo/content/en/_index.md:5: #My Section: #My Section does exists in <site>/content/en/_index.md",
+		"/hugo/content/en/_index.md:5: #My-Section: #My-Section does exists in <site>/content/en/_index.md",
+	}))
+}
+
+func Test_readAllAndLinkcheckAll_samePageFragments(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	content := `# Title
+
+## Section
+
+See [valid](#section) and [broken](#missing).
+`
+	write(g, filepath.Join(root, "hugo", contentFolder, "en/_index.md"), content)
+	write(g, filepath.Join(root, "hugo", contentFolder, "en/page.md"), content)
+	write(g, filepath.Join(root, "hugo", contentFolder, "en/folder/_index.md"), content)
+	write(g, filepath.Join(root, "hugo", contentFolder, "en/folder/nested/page.md"), content)
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+
+	g.Expect(collectErrors(root)).To(Equal([]string{
+		"/hugo/content/en/_index.md:5: #missing: #missing does exists in <site>/content/en/_index.md",
+		"/hugo/content/en/folder/_index.md:5: #missing: #missing does exists in <site>/content/en/folder/_index.md",
+		"/hugo/content/en/folder/nested/page.md:5: #missing: #missing does exists in <site>/content/en/folder/nested/page.md",
+		"/hugo/content/en/page.md:5: #missing: #missing does exists in <site>/content/en/page.md",
+	}))
+}
+
+func Test_readAllAndLinkcheckAll_samePageFragmentsWithFolders(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	// The root _index.md page and a page with a folder with the same name, e.g. page.md and page/, link their own anchors.
+	contentDir := filepath.Join(root, "hugo", contentFolder, "en")
+	write(g, filepath.Join(contentDir, "_index.md"), "# Home\n\n## Home Section\n\nSee [section](#home-section).\n")
+	write(g, filepath.Join(contentDir, "page.md"), "# Page\n\n## Page Section\n\nSee [section](#page-section).\n")
+	write(g, filepath.Join(contentDir, "page/_index.md"), "# Folder\n")
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+
+	g.Expect(pagesByPath[filepath.Join(contentDir, "_index.md")].links[0].URL).To(Equal(mustParseUrl(filepath.Join(contentDir, "_index.md#home-section"))))
+	g.Expect(pagesByPath[filepath.Join(contentDir, "page.md")].links[0].URL).To(Equal(mustParseUrl(filepath.Join(contentDir, "page.md#page-section"))))
+	g.Expect(collectErrors(root)).To(BeEmpty())
+}
+
+func Test_readMarkdownPage_skipMarkers(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	skipBeginMarkerBefore := *skipBeginMarker
+	skipEndMarkerBefore := *skipEndMarker
+	defer func() {
+		*skipBeginMarker = skipBeginMarkerBefore
+		*skipEndMarker = skipEndMarkerBefore
+	}()
+
+	path := filepath.Join(root, "hugo", contentFolder, "en/_index.md")
+	write(g, path, `# Home
+
+See [before](#home).
+<!-- BEGIN GENERATED -->
+See [broken](missing) and ![broken](missing.png).
+<!-- END GENERATED -->
+See [after](missing-after).
+<!-- BEGIN GENERATED --> [broken](missing-inline) <!-- END GENERATED -->
+`)
+
+	readLinks := func() []string {
+		got := []string{}
+		for _, l := range readMarkdownPage(path).links {
+			got = append(got, fmt.Sprintf("%d: %s", l.lineNumber, l.rawLink))
+		}
+		return got
+	}
+
+	*skipBeginMarker = ""
+	*skipEndMarker = ""
+	g.Expect(readLinks()).To(Equal([]string{"3: #home", "5: missing", "5: missing.png", "7: missing-after", "8: missing-inline"}))
+
+	*skipBeginMarker = "<!-- BEGIN GENERATED -->"
+	*skipEndMarker = "<!-- END GENERATED -->"
+	g.Expect(readLinks()).To(Equal([]string{"3: #home", "7: missing-after"}))
+}
+
+func Test_readMarkdownPage_baseElement(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	path := filepath.Join(root, "hugo", contentFolder, "en/test.md")
+	write(g, path, "# Test\n\n<BASE target=\"_blank\" href=\"/docs/\">\n\nSee the [page](page).\n\n```html\n<base href=\"/example/\">\n```\n")
+
+	p := readMarkdownPage(path)
+	g.Expect(p.fatalError).To(BeNil())
+	g.Expect(p.links).To(HaveLen(1))
+	g.Expect(p.warnings).To(Equal([]warning{
+		{lineNumber: 3, message: "base element with href \"/docs/\" found; relative links are checked ignoring it, but they could resolve differently in the browser"},
+	}))
+}
+
+func Test_stripHTMLComments(t *testing.T) {
+	tests := []struct {
+		name          string
+		line          string
+		inComment     bool
+		wantLine      string
+		wantInComment bool
+	}{
+		{
+			name:          "no comments",
+			line:          "See [page](page).",
+			wantLine:      "See [page](page).",
+			wantInComment: false,
+		},
+		{
+			name:          "comment in the line",
+			line:          "See <!-- [old](old) --> [page](page).",
+			wantLine:      "See  [page](page).",
+			wantInComment: false,
+		},
+		{
+			name:          "comment starting in the line",
+			line:          "See [page](page). <!-- [old](old)",
+			wantLine:      "See [page](page). ",
+			wantInComment: true,
+		},
+		{
+			name:          "comment ending in the line",
+			line:          "[old](old) --> See [page](page).",
+			inComment:     true,
+			wantLine:      " See [page](page).",
+			wantInComment: false,
+		},
+		{
+			name:          "line inside a comment",
+			line:          "[old](old)",
+			inComment:     true,
+			wantLine:      "",
+			wantInComment: true,
+		},
+		{
+			name:          "many comments in the line",
+			line:          "<!-- a --> [page](page) <!-- b --> [other](other) <!-- c",
+			wantLine:      " [page](page)  [other](other) ",
+			wantInComment: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			gotLine, gotInComment := stripHTMLComments(tt.line, tt.inComment)
+			g.Expect(gotLine).To(Equal(tt.wantLine))
+			g.Expect(gotInComment).To(Equal(tt.wantInComment))
+		})
+	}
+}
+
+func Test_readMarkdownPage_htmlComments(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	path := filepath.Join(root, "hugo", contentFolder, "en/test.md")
+	write(g, path, `# Test
+
+See the [page](page) <!-- and the [broken page](broken) -->.
+
+<!--
+See the [other broken page](other-broken.md) and ![image](missing.png).
+-->
+
+`+"```html\n<!-- [code](code) -->\n```\n")
+
+	p := readMarkdownPage(path)
+	g.Expect(p.fatalError).To(BeNil())
+
+	got := []string{}
+	for _, l := range p.links {
+		got = append(got, l.rawLink)
+	}
+	g.Expect(got).To(Equal([]string{"page", "code"}))
+}
+
+func Test_readMarkdownPage_requireSingleH1(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      string
+		wantWarnings []warning
+	}{
+		{
+			name:         "no H1",
+			content:      "## Section\n\n### Sub section\n",
+			wantWarnings: []warning{{message: "the page does not have an H1 header"}},
+		},
+		{
+			name:         "one H1",
+			content:      "# Title\n\n## Section\n",
+			wantWarnings: nil,
+		},
+		{
+			name:         "two H1",
+			content:      "# Title\n\n## Section\n\n# Another title\n",
+			wantWarnings: []warning{{message: "the page has 2 H1 headers, only one is allowed"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			root, err := os.MkdirTemp("", "linkcheck")
+			g.Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(root)
+
+			cancel := setFlags(root, "hugo", []string{"en"})
+			defer cancel()
+
+			requireSingleH1Before := *requireSingleH1
+			defer func() { *requireSingleH1 = requireSingleH1Before }()
+
+			path := filepath.Join(root, "hugo", contentFolder, "en/test.md")
+			write(g, path, tt.content)
+
+			*requireSingleH1 = false
+			g.Expect(readMarkdownPage(path).warnings).To(BeEmpty())
+
+			*requireSingleH1 = true
+			g.Expect(readMarkdownPage(path).warnings).To(Equal(tt.wantWarnings))
+		})
+	}
+}
+
+func Test_readMarkdownPage_reservedAnchors(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      string
+		wantWarnings []warning
+	}{
+		{
+			name:         "no collisions",
+			content:      "# Title\n\n## Table of contents\n",
+			wantWarnings: nil,
+		},
+		{
+			name:         "heading slugging to a reserved id",
+			content:      "# Title\n\n## TOC\n",
+			wantWarnings: []warning{{message: "anchor #toc collides with an id reserved by hugo or by the theme"}},
+		},
+		{
+			name:         "heading with a reserved custom id",
+			content:      "# Title\n\n## Contents {#TableOfContents}\n",
+			wantWarnings: []warning{{message: "anchor #TableOfContents collides with an id reserved by hugo or by the theme"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			root, err := os.MkdirTemp("", "linkcheck")
+			g.Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(root)
+
+			cancel := setFlags(root, "hugo", []string{"en"})
+			defer cancel()
+
+			reservedAnchorsBefore := *reservedAnchors
+			defer func() { *reservedAnchors = reservedAnchorsBefore }()
+			*reservedAnchors = []string{"TableOfContents", "toc"}
+
+			path := filepath.Join(root, "hugo", contentFolder, "en/test.md")
+			write(g, path, tt.content)
+
+			g.Expect(readMarkdownPage(path).warnings).To(Equal(tt.wantWarnings))
+		})
+	}
+}
+
+func Test_readMarkdownPage_toc(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	path := filepath.Join(root, "hugo", contentFolder, "en/test.md")
+	write(g, path, `# Test
+
+- [First section](#first-section)
+  - [Sub section](#sub-section)
+- [Missing section](#missing-section)
+
+## First section
+
+### Sub section
+`)
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	addPage(readMarkdownPage(path))
+	linkcheckPage(context.Background(), path)
+
+	g.Expect(collectErrors(root)).To(Equal([]string{
+		"/hugo/content/en/test.md:5: #missing-section: #missing-section does exists in <site>/content/en/test.md",
+	}))
+	g.Expect(pagesByPath[path].links).To(HaveLen(3))
+}
+
+// wikiExtractor is a test extractor for wiki links in the format [[addr]].
+type wikiExtractor struct{}
+
+func (wikiExtractor) extractLinks(line string) (links []string) {
+	for _, m := range regexp.MustCompile(`\[\[([^\]]+)\]\]`).FindAllStringSubmatch(line, -1) {
+		links = append(links, m[1])
+	}
+	return
+}
+
+func Test_registerExtractor(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	extractorsBefore := extractors
+	defer func() { extractors = extractorsBefore }()
+
+	registerExtractor(wikiExtractor{})
+
+	path := filepath.Join(root, "hugo", contentFolder, "en/test.md")
+	write(g, path, `# Test
+
+Read the [page](page) and the [[another-page]].
+`)
+
+	p := readMarkdownPage(path)
+
+	rawLinks := []string{}
+	for _, l := range p.links {
+		rawLinks = append(rawLinks, l.rawLink)
+	}
+	g.Expect(rawLinks).To(Equal([]string{"page", "another-page"}))
+}
+
+func Test_readAllAndLinkcheckAll(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	hugoFolder := "hugo"
+	hugoLanguages := []string{"en"}
+
+	cancel := setFlags(root, hugoFolder, hugoLanguages)
+	defer cancel()
+
+	contentDir := filepath.Join(root, hugoFolder, contentFolder)
+
+	// pages outside the hugo website
+	write(g, filepath.Join(root, "README.md"), `# Readme
+
+See the [book](https://example.com) and the [guide](docs/guide.md).
+`)
+
+	// pages inside the hugo website
+	write(g, filepath.Join(contentDir, "en/_index.md"), `# Home
+
+## Getting started
+
+Read the [docs](docs/), jump to [getting started](#getting-started) or to [missing](#missing).
+Look at the ![logo](images/logo.png) image.
+`)
+	write(g, filepath.Join(contentDir, "en/docs/_index.md"), `# Docs
+
+Go back [home](/), read the [page](page), the [section](page#section) or the [broken section](page#broken).
+Do not use [md links](page.md), [index links](/docs/_index.md) or [refs]({{< ref "page" >}}).
+This is a [missing page](missing) and this an [invalid url](%zz).
+`)
+	write(g, filepath.Join(contentDir, "en/docs/page.md"), `# Page
+
+## Section
+`)
+	write(g, filepath.Join(contentDir, "it/test.md"), `# Test
+`)
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+
+	g.Expect(collectErrors(root)).To(Equal([]string{
+		"/README.md:3: docs/guide.md: scheme is required on links outside the hugo website",
+		"/hugo/content/en/_index.md:5: #missing: #missing does exists in <site>/content/en/_index.md",
+		"/hugo/content/en/_index.md:6: images/logo.png: the image resolves to none of /hugo/content/en/images/logo.png, /hugo/static/images/logo.png, /hugo/assets/images/logo.png",
+		"/hugo/content/en/docs/_index.md:3: page#broken: #broken does exists in <site>/content/en/docs/page.md",
+		"/hugo/content/en/docs/_index.md:4: /docs/_index.md: links must not end with _index.md, use \"/docs/\" instead",
+		"/hugo/content/en/docs/_index.md:4: page.md: links must not have .md extension, use \"page\" instead",
+		"/hugo/content/en/docs/_index.md:4: {{< ref \"page\" >}}: ref/refLink shortcodes must not be used, use \"page\" instead",
+		"/hugo/content/en/docs/_index.md:5: %zz: error parsing url: parse \"%zz\": invalid URL escape \"%zz\"",
+		"/hugo/content/en/docs/_index.md:5: missing: the link resolves to /hugo/content/en/docs/missing.md which does not exist",
+		"/hugo/content/it/test.md: hugo page /it/test.md does not belong to one of the know languages: en",
+	}))
+}
+
+func Test_readAll_unreadableFiles(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	contentDir := filepath.Join(root, "hugo", contentFolder)
+
+	// A folder named like a markdown page cannot be read as a page.
+	g.Expect(os.MkdirAll(filepath.Join(contentDir, "en/folder.md"), os.ModePerm)).To(Succeed())
+	write(g, filepath.Join(contentDir, "en/folder.md/test.md"), "# Test\n")
+	write(g, filepath.Join(contentDir, "en/valid.md"), "# Valid\n\nSee [test](folder.md/test).\n")
+
+	// A file without read permissions cannot be read (NOTE: this does not apply when running as root).
+	canReadAll := os.Geteuid() == 0
+	write(g, filepath.Join(contentDir, "en/unreadable.md"), "# Unreadable\n")
+	g.Expect(os.Chmod(filepath.Join(contentDir, "en/unreadable.md"), 0)).To(Succeed())
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	g.Expect(readAll()).To(Succeed())
+
+	folderp, ok := pagesByPath[filepath.Join(contentDir, "en/folder.md")]
+	g.Expect(ok).To(BeTrue())
+	g.Expect(folderp.fatalError.Error()).To(HavePrefix("Error reading content: "))
+	g.Expect(folderp.fatalError.Error()).To(HaveSuffix("is a directory"))
+	g.Expect(folderp.logPath()).To(Equal("<site>/content/en/folder.md"))
+
+	unreadablep, ok := pagesByPath[filepath.Join(contentDir, "en/unreadable.md")]
+	g.Expect(ok).To(BeTrue())
+	if !canReadAll {
+		g.Expect(unreadablep.fatalError.Error()).To(HaveSuffix("permission denied"))
+	}
+
+	// Pages after the unreadable ones are still read.
+	g.Expect(pagesByPath).To(HaveKey(filepath.Join(contentDir, "en/folder.md/test.md")))
+	g.Expect(pagesByPath).To(HaveKey(filepath.Join(contentDir, "en/valid.md")))
+	g.Expect(pages).To(HaveLen(4))
+}
+
+// collectErrors returns all the errors reported on pages and links, sorted.
+func collectErrors(root string) []string {
+	errs := []string{}
+	for _, p := range pages {
+		path := strings.TrimPrefix(p.path, root)
+		if p.fatalError != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", path, p.fatalError))
+			continue
+		}
+		for _, l := range p.links {
+			if l.fatalError != nil {
+				errs = append(errs, fmt.Sprintf("%s:%d: %s: %s", path, l.lineNumber, l.rawLink, l.fatalError))
+			}
+		}
+	}
+	sort.Strings(errs)
+	return errs
+}
+
+func setFlags(rootValue, hugoFolderValue string, hugoLanguagesValue []string) (resetFlags func()) {
+	rootBefore := root
+	hugoFolderBefore := hugoFolder
+	hugoLanguagesBefore := hugoLanguages
+
+	root = &rootValue
+	hugoFolder = &hugoFolderValue
+	hugoLanguages = &hugoLanguagesValue
+
+	return func() {
+		root = rootBefore
+		hugoFolder = hugoFolderBefore
+		hugoLanguages = hugoLanguagesBefore
+	}
+}
+
+func mustParseUrl(l string) *url.URL {
+	u, err := url.Parse(l)
+	if err != nil {
+		panic(err.Error())
+	}
+	return u
+}
+
+func touch(g *WithT, path string) {
+	g.Expect(os.MkdirAll(filepath.Dir(path), os.ModePerm)).ToNot(HaveOccurred())
+	g.Expect(os.WriteFile(path, []byte(""), 0600)).To(Succeed())
+}
+
+func write(g *WithT, path, content string) {
+	g.Expect(os.MkdirAll(filepath.Dir(path), os.ModePerm)).ToNot(HaveOccurred())
+	g.Expect(os.WriteFile(path, []byte(content), 0600)).To(Succeed())
+}
+
+func Test_parseLink(t *testing.T) {
+	tests := []struct {
+		name         string
+		rawLink      string
+		wantPath     string
+		wantFragment string
+		wantErr      string
+	}{
+		{
+			name:         "page with fragment",
+			rawLink:      "page#section",
+			wantPath:     "page",
+			wantFragment: "#section",
+		},
+		{
+			name:         "fragment only",
+			rawLink:      "#section",
+			wantPath:     "",
+			wantFragment: "#section",
+		},
+		{
+			name:    "page with .md extension and fragment",
+			rawLink: "page.md#section",
+			wantErr: "links must not have .md extension, use \"page#section\" instead",
+		},
+		{
+			name:    "_index.md with fragment",
+			rawLink: "folder/_index.md#section",
+			wantErr: "links must not end with _index.md, use \"folder/#section\" instead",
+		},
+		{
+			name:    "fragment referencing _index",
+			rawLink: "page#_index",
+			wantErr: "link fragments must not reference page files, use \"page\" instead",
+		},
+		{
+			name:    "fragment referencing _index.md",
+			rawLink: "folder#_index.md",
+			wantErr: "link fragments must not reference page files, use \"folder\" instead",
+		},
+		{
+			name:    "fragment with .md extension",
+			rawLink: "page#section.md",
+			wantErr: "link fragments must not reference page files, use \"page#section\" instead",
+		},
+		{
+			name:         "fragment only referencing _index",
+			rawLink:      "#_index",
+			wantPath:     "",
+			wantFragment: "#_index",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			path, fragment, _, err := parseLink(tt.rawLink)
+			if tt.wantErr != "" {
+				g.Expect(err).To(MatchError(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(path).To(Equal(tt.wantPath))
+			g.Expect(fragment).To(Equal(tt.wantFragment))
+		})
+	}
+}
+
+// linkSeedCorpus contains links used as seed corpus by fuzz tests.
+var linkSeedCorpus = []string{
+	"",
+	"#",
+	"#anchor",
+	"another-page",
+	"another-page.md",
+	"another-page#anchor",
+	"another-page.md#anchor",
+	"../folder/page#a#b",
+	"something/_index.md",
+	"something/_index.md#anchor",
+	"page#_index",
+	"page#section.md",
+	"/docs/page?q=1#section",
+	"https://www.google.com",
+	"$$$%%%???",
+	"page%20name#my%20section",
+	"{{< ref \"something\" >}}",
+	"{{< relref \"something\" >}}",
+	"page \"a tooltip\"",
+}
+
+func Fuzz_splitPathAndFragment(f *testing.F) {
+	for _, l := range linkSeedCorpus {
+		f.Add(l)
+	}
+	f.Fuzz(func(t *testing.T, addr string) {
+		path, fragment := splitPathAndFragment(addr)
+		if path+fragment != addr {
+			t.Errorf("splitPathAndFragment(%q) = %q, %q, which does not add up to the address", addr, path, fragment)
+		}
+		if strings.Contains(path, anchorSeparator) {
+			t.Errorf("splitPathAndFragment(%q) = %q, %q, path contains %s", addr, path, fragment, anchorSeparator)
+		}
+		if fragment != "" && !strings.HasPrefix(fragment, anchorSeparator) {
+			t.Errorf("splitPathAndFragment(%q) = %q, %q, fragment does not start with %s", addr, path, fragment, anchorSeparator)
+		}
+	})
+}
+
+func Fuzz_parseLink(f *testing.F) {
+	for _, l := range linkSeedCorpus {
+		f.Add(l)
+	}
+	f.Fuzz(func(t *testing.T, rawLink string) {
+		path, fragment, language, err := parseLink(rawLink)
+		if err != nil {
+			// Errors are returned only for forbidden links, suggesting the form to use instead.
+			var forbiddenErr *forbiddenLinkError
+			if !errors.As(err, &forbiddenErr) {
+				t.Errorf("parseLink(%q) returned an error which is not a forbidden link error: %v", rawLink, err)
+			}
+			if path != "" || fragment != "" || language != "" {
+				t.Errorf("parseLink(%q) = %q, %q, %q, with error %v", rawLink, path, fragment, language, err)
+			}
+			return
+		}
+		if strings.Contains(path, anchorSeparator) {
+			t.Errorf("parseLink(%q) = %q, %q, path contains %s", rawLink, path, fragment, anchorSeparator)
+		}
+		if fragment != "" && !strings.HasPrefix(fragment, anchorSeparator) {
+			t.Errorf("parseLink(%q) = %q, %q, fragment does not start with %s", rawLink, path, fragment, anchorSeparator)
+		}
+		if filepath.Ext(path) == ".md" {
+			t.Errorf("parseLink(%q) = %q, %q, path has the .md extension", rawLink, path, fragment)
+		}
+	})
+}
+
+func Test_readAllAndLinkcheckAll_trailingSlash(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	path := filepath.Join(root, "hugo", contentFolder, "en/_index.md")
+	write(g, path, `# Home
+
+See [folder](/folder), [folder with slash](/folder/), [relative folder](folder#section) or [page](/folder/page).
+See [home](/).
+`)
+	write(g, filepath.Join(root, "hugo", contentFolder, "en/folder/_index.md"), "# Folder\n\n## Section\n")
+	write(g, filepath.Join(root, "hugo", contentFolder, "en/folder/page.md"), "# Page\n")
+
+	trailingSlashBefore := *trailingSlash
+	defer func() { *trailingSlash = trailingSlashBefore }()
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	tests := []struct {
+		policy       string
+		wantWarnings []warning
+	}{
+		{
+			policy:       "",
+			wantWarnings: nil,
+		},
+		{
+			policy: trailingSlashAlways,
+			wantWarnings: []warning{
+				{lineNumber: 3, rawLink: "/folder", message: "links to folders should end with a trailing slash, e.g. /folder/"},
+				{lineNumber: 3, rawLink: "folder#section", message: "links to folders should end with a trailing slash, e.g. folder/"},
+			},
+		},
+		{
+			policy: trailingSlashNever,
+			wantWarnings: []warning{
+				{lineNumber: 3, rawLink: "/folder/", message: "links to folders should not end with a trailing slash, e.g. /folder"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			g := NewWithT(t)
+
+			pages = nil
+			pagesByPath = nil
+
+			*trailingSlash = tt.policy
+			g.Expect(readAll()).To(Succeed())
+			g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+			g.Expect(pagesByPath[path].warnings).To(Equal(tt.wantWarnings))
+			g.Expect(collectErrors(root)).To(BeEmpty())
+		})
+	}
+}
+
+func Test_readAllAndLinkcheckAll_stripLinkPrefixes(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	contentDir := filepath.Join(root, "hugo", contentFolder, "en")
+	write(g, filepath.Join(contentDir, "_index.md"), `# Home
+
+See [page](/src/tasks/page#section), [missing](/src/tasks/missing) or [src folder](/source/page).
+`)
+	write(g, filepath.Join(contentDir, "tasks/page.md"), "# Page\n\n## Section\n")
+	write(g, filepath.Join(contentDir, "source/page.md"), "# Source\n")
+
+	stripPrefixesBefore := *stripPrefixes
+	defer func() { *stripPrefixes = stripPrefixesBefore }()
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	// Without --strip-link-prefixes, /src/ is part of the link path.
+	*stripPrefixes = nil
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+	g.Expect(collectErrors(root)).To(Equal([]string{
+		"/hugo/content/en/_index.md:3: /src/tasks/missing: the link resolves to /hugo/content/en/src/tasks/missing.md which does not exist",
+		"/hugo/content/en/_index.md:3: /src/tasks/page#section: the link resolves to /hugo/content/en/src/tasks/page.md which does not exist",
+	}))
+
+	pages = nil
+	pagesByPath = nil
+
+	*stripPrefixes = []string{"/src/"}
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+	g.Expect(collectErrors(root)).To(Equal([]string{
+		"/hugo/content/en/_index.md:3: /src/tasks/missing: the link resolves to /hugo/content/en/tasks/missing.md which does not exist",
+	}))
+}
+
+func Test_readAllAndLinkcheckAll_headinglessPages(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	write(g, filepath.Join(root, "hugo", contentFolder, "en/_index.md"), `# Home
+
+See [plain](/plain#section), [plain](/plain) or [page](/page#missing).
+`)
+	write(g, filepath.Join(root, "hugo", contentFolder, "en/plain.md"), "A page without headings.\n")
+	write(g, filepath.Join(root, "hugo", contentFolder, "en/page.md"), "# Page\n\n## Section\n")
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+
+	g.Expect(collectErrors(root)).To(Equal([]string{
+		"/hugo/content/en/_index.md:3: /page#missing: #missing does exists in <site>/content/en/page.md",
+		"/hugo/content/en/_index.md:3: /plain#section: #section does not exist, the target page <site>/content/en/plain.md has no headings/anchors",
+	}))
+}
+
+func Test_splitPathAndFragment(t *testing.T) {
+	tests := []struct {
+		name            string
+		anchorSeparator string
+		addr            string
+		wantPath        string
+		wantFragment    string
+	}{
+		{
+			name:            "default separator",
+			anchorSeparator: anchorSeparator,
+			addr:            "/folder/page#section",
+			wantPath:        "/folder/page",
+			wantFragment:    "#section",
+		},
+		{
+			name:            "default separator without fragment",
+			anchorSeparator: anchorSeparator,
+			addr:            "/folder/page",
+			wantPath:        "/folder/page",
+			wantFragment:    "",
+		},
+		{
+			name:            "custom separator",
+			anchorSeparator: "!",
+			addr:            "/folder/page!section",
+			wantPath:        "/folder/page",
+			wantFragment:    "!section",
+		},
+		{
+			name:            "custom separator ignores #",
+			anchorSeparator: "!",
+			addr:            "/folder/page#section",
+			wantPath:        "/folder/page#section",
+			wantFragment:    "",
+		},
+		{
+			name:            "multi-character separator",
+			anchorSeparator: "::",
+			addr:            "::section",
+			wantPath:        "",
+			wantFragment:    "::section",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			anchorSepBefore := *anchorSep
+			defer func() { *anchorSep = anchorSepBefore }()
+			*anchorSep = tt.anchorSeparator
+
+			gotPath, gotFragment := splitPathAndFragment(tt.addr)
+			g.Expect(gotPath).To(Equal(tt.wantPath))
+			g.Expect(gotFragment).To(Equal(tt.wantFragment))
+		})
+	}
+}
+
+func Test_readAllAndLinkcheckAll_anchorSeparator(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	anchorSepBefore := *anchorSep
+	defer func() { *anchorSep = anchorSepBefore }()
+	*anchorSep = "!"
+
+	write(g, filepath.Join(root, "hugo", contentFolder, "en/_index.md"), `# Home
+
+## Local
+
+See [section](/page!section), [local](!local), [missing](/page!missing) or [page file](/page!page.md).
+`)
+	write(g, filepath.Join(root, "hugo", contentFolder, "en/page.md"), "# Page\n\n## Section\n")
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+
+	g.Expect(collectErrors(root)).To(Equal([]string{
+		"/hugo/content/en/_index.md:5: /page!missing: !missing does exists in <site>/content/en/page.md",
+		`/hugo/content/en/_index.md:5: /page!page.md: link fragments must not reference page files, use "/page!page" instead`,
+	}))
+}
+
+func Test_parseRefTarget(t *testing.T) {
+	tests := []struct {
+		target       string
+		wantPath     string
+		wantFragment string
+	}{
+		{target: "page", wantPath: "page", wantFragment: ""},
+		{target: "page.md", wantPath: "page", wantFragment: ""},
+		{target: "../folder/page.md#section", wantPath: "../folder/page", wantFragment: "#section"},
+		{target: "/docs/page#section", wantPath: "/docs/page", wantFragment: "#section"},
+		{target: "/docs/_index.md", wantPath: "/docs/", wantFragment: ""},
+		{target: "_index.md#section", wantPath: "./", wantFragment: "#section"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			g := NewWithT(t)
+
+			gotPath, gotFragment := parseRefTarget(tt.target)
+			g.Expect(gotPath).To(Equal(tt.wantPath))
+			g.Expect(gotFragment).To(Equal(tt.wantFragment))
+		})
+	}
+}
+
+func Test_readAllAndLinkcheckAll_allowRefShortcodes(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	path := filepath.Join(root, "hugo", contentFolder, "en/docs/_index.md")
+	write(g, path, `# Docs
+
+See [page]({{< ref "page.md" >}}), [section]({{< ref "page.md#section" >}}) or [home]({{< refLink "/_index.md" >}}).
+See [missing]({{< ref "missing.md" >}}) or [missing section]({{< ref "/docs/page#missing" >}}).
+`)
+	write(g, filepath.Join(root, "hugo", contentFolder, "en/docs/page.md"), "# Page\n\n## Section\n")
+	write(g, filepath.Join(root, "hugo", contentFolder, "en/_index.md"), "# Home\n")
+
+	allowRefsBefore := *allowRefs
+	defer func() { *allowRefs = allowRefsBefore }()
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	// By default, ref shortcodes are forbidden.
+	*allowRefs = false
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+	g.Expect(collectErrors(root)).To(HaveLen(5))
+
+	pages = nil
+	pagesByPath = nil
+
+	// With --allow-ref-shortcodes, the target of ref shortcodes is checked.
+	*allowRefs = true
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+	g.Expect(collectErrors(root)).To(Equal([]string{
+		`/hugo/content/en/docs/_index.md:4: {{< ref "/docs/page#missing" >}}: #missing does exists in <site>/content/en/docs/page.md`,
+		`/hugo/content/en/docs/_index.md:4: {{< ref "missing.md" >}}: the link resolves to /hugo/content/en/docs/missing.md which does not exist`,
+	}))
 }
@@ -0,0 +1,47 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// readLinkTextWarnings returns warnings for the [text](addr) links in a line using one of the --forbid-link-text,
+// e.g. texts discouraged by accessibility guidelines like "click here", because they do not describe the target
+// of the link when read out of context, e.g. by screen readers listing the links of a page.
+// NOTE: texts are compared case insensitive, ignoring surrounding spaces and trailing punctuation.
+func readLinkTextWarnings(line string, lineNumber int) []warning {
+	if len(*forbidLinkText) == 0 || !strings.Contains(line, "](") {
+		return nil
+	}
+
+	var warnings []warning
+	for _, m := range lRx.FindAllStringSubmatch(line, -1) {
+		text := strings.TrimRight(strings.TrimSpace(m[1]), ".,;:!?")
+		for _, forbidden := range *forbidLinkText {
+			if strings.EqualFold(text, strings.TrimSpace(forbidden)) {
+				warnings = append(warnings, warning{lineNumber: lineNumber, rawLink: m[2], message: fmt.Sprintf("link text %q does not describe the target of the link, e.g. for screen readers", m[1])})
+				break
+			}
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,112 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+// accessibilityLinkTexts are link texts discouraged by accessibility guidelines.
+var accessibilityLinkTexts = []string{"click here", "here", "this link", "link", "read more", "more", "this"}
+
+func Test_readLinkTextWarnings(t *testing.T) {
+	tests := []struct {
+		name           string
+		forbidLinkText []string
+		line           string
+		want           []warning
+	}{
+		{
+			name:           "click here is flagged",
+			forbidLinkText: accessibilityLinkTexts,
+			line:           "To install clusterctl [click here](/tasks/install).",
+			want:           []warning{{lineNumber: 3, rawLink: "/tasks/install", message: `link text "click here" does not describe the target of the link, e.g. for screen readers`}},
+		},
+		{
+			name:           "texts are compared case insensitive, ignoring trailing punctuation",
+			forbidLinkText: accessibilityLinkTexts,
+			line:           "See [Here.](/tasks/install) and [Read More](/tasks/upgrade).",
+			want: []warning{
+				{lineNumber: 3, rawLink: "/tasks/install", message: `link text "Here." does not describe the target of the link, e.g. for screen readers`},
+				{lineNumber: 3, rawLink: "/tasks/upgrade", message: `link text "Read More" does not describe the target of the link, e.g. for screen readers`},
+			},
+		},
+		{
+			name:           "descriptive texts are not flagged",
+			forbidLinkText: accessibilityLinkTexts,
+			line:           "See [how to click here and there](/tasks/install) or ![here](image.png).",
+			want:           nil,
+		},
+		{
+			name:           "custom texts",
+			forbidLinkText: []string{"docs"},
+			line:           "See [click here](/tasks/install) or [docs](/docs).",
+			want:           []warning{{lineNumber: 3, rawLink: "/docs", message: `link text "docs" does not describe the target of the link, e.g. for screen readers`}},
+		},
+		{
+			name:           "no forbidden texts",
+			forbidLinkText: []string{},
+			line:           "To install clusterctl [click here](/tasks/install).",
+			want:           nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			forbidLinkTextBefore := *forbidLinkText
+			defer func() { *forbidLinkText = forbidLinkTextBefore }()
+			*forbidLinkText = tt.forbidLinkText
+
+			g.Expect(readLinkTextWarnings(tt.line, 3)).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_readMarkdownPage_linkTexts(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	path := filepath.Join(root, "hugo", contentFolder, "en/test.md")
+	write(g, path, "# Test\n\nFor details [click here](/details).\n\n```markdown\n[click here](/example)\n```\n")
+
+	// The check is opt-in, so link texts are not checked by default.
+	p := readMarkdownPage(path)
+	g.Expect(p.warnings).To(BeEmpty())
+
+	forbidLinkTextBefore := *forbidLinkText
+	defer func() { *forbidLinkText = forbidLinkTextBefore }()
+	*forbidLinkText = accessibilityLinkTexts
+
+	p = readMarkdownPage(path)
+	g.Expect(p.warnings).To(Equal([]warning{
+		{lineNumber: 3, rawLink: "/details", message: `link text "click here" does not describe the target of the link, e.g. for screen readers`},
+	}))
+}
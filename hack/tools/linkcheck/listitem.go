@@ -0,0 +1,70 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Search for the items of top level ordered lists, e.g. 1. step or 1) step.
+var orderedListItemRx = regexp.MustCompile(`^\d+[.)]\s+\S`)
+
+// validateListItemAnchors checks the --list-item-anchors format has exactly one %d verb for the position of the item.
+func validateListItemAnchors(format string) error {
+	if format == "" {
+		return nil
+	}
+	if strings.Count(format, "%") != 1 || !strings.Contains(format, "%d") {
+		return errors.Errorf("invalid list item anchors format %q, must contain %%d exactly once, e.g. step-%%d", format)
+	}
+	return nil
+}
+
+// readListItemAnchors returns the anchors assigned by the theme to the items of top level ordered lists, using the
+// --list-item-anchors format with the position of the item in its list, e.g. step-1, step-2.
+// NOTE: a list ends at the first line that is neither an item, a blank line, nor indented content of an item.
+func readListItemAnchors(body string) (anchors []string) {
+	if *listItemAnchors == "" {
+		return
+	}
+
+	inCodeFence := false
+	position := 0
+	for _, line := range strings.Split(body, "\n") {
+		if codeFenceRx.MatchString(line) {
+			inCodeFence = !inCodeFence
+			continue
+		}
+		switch {
+		case inCodeFence, strings.TrimSpace(line) == "", strings.HasPrefix(line, " "), strings.HasPrefix(line, "\t"):
+			continue
+		case orderedListItemRx.MatchString(line):
+			position++
+			anchors = append(anchors, fmt.Sprintf(*listItemAnchors, position))
+		default:
+			position = 0
+		}
+	}
+	return
+}
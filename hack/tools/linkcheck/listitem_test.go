@@ -0,0 +1,91 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_validateListItemAnchors(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(validateListItemAnchors("")).To(Succeed())
+	g.Expect(validateListItemAnchors("step-%d")).To(Succeed())
+	g.Expect(validateListItemAnchors("step")).ToNot(Succeed())
+	g.Expect(validateListItemAnchors("step-%s")).ToNot(Succeed())
+	g.Expect(validateListItemAnchors("%d-step-%d")).ToNot(Succeed())
+}
+
+func Test_readListItemAnchors(t *testing.T) {
+	g := NewWithT(t)
+
+	listItemAnchorsBefore := *listItemAnchors
+	defer func() { *listItemAnchors = listItemAnchorsBefore }()
+
+	body := "# Tutorial\n\n1. First\n   details\n\n2. Second\n   1. nested\n3) Third\n\nA paragraph.\n\n1. Again\n\n```\n1. in code\n```\n"
+
+	*listItemAnchors = ""
+	g.Expect(readListItemAnchors(body)).To(BeEmpty())
+
+	*listItemAnchors = "step-%d"
+	g.Expect(readListItemAnchors(body)).To(Equal([]string{"step-1", "step-2", "step-3", "step-1"}))
+}
+
+func Test_readAllAndLinkcheckAll_listItemAnchors(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	write(g, filepath.Join(root, "hugo", contentFolder, "en/_index.md"), `# Tutorial
+
+1. Install the tools
+2. Create a cluster
+
+If something fails, go back to [step 2](#step-2); there is no [step 3](#step-3).
+`)
+
+	listItemAnchorsBefore := *listItemAnchors
+	defer func() { *listItemAnchors = listItemAnchorsBefore }()
+	*listItemAnchors = "step-%d"
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+	g.Expect(collectErrors(root)).To(Equal([]string{
+		"/hugo/content/en/_index.md:6: #step-3: #step-3 does exists in <site>/content/en/_index.md",
+	}))
+}
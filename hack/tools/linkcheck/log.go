@@ -0,0 +1,139 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+)
+
+// logLevel defines the granularity of the output of linkcheck.
+type logLevel int
+
+const (
+	// quietLogLevel prints only the final summary.
+	quietLogLevel logLevel = iota
+
+	// normalLogLevel prints errors and the final summary.
+	normalLogLevel
+
+	// verboseLogLevel prints errors, OK links and the final summary.
+	verboseLogLevel
+
+	// debugLogLevel prints everything, including link resolution steps.
+	debugLogLevel
+)
+
+// logLevelNames maps log level names to log levels.
+var logLevelNames = map[string]logLevel{
+	"quiet":   quietLogLevel,
+	"normal":  normalLogLevel,
+	"verbose": verboseLogLevel,
+	"debug":   debugLogLevel,
+}
+
+// logOutput is where debug messages are written.
+var logOutput io.Writer = os.Stdout
+
+// parseLogLevel returns the log level for a log level name.
+func parseLogLevel(name string) (logLevel, error) {
+	l, ok := logLevelNames[name]
+	if !ok {
+		return 0, errors.Errorf("invalid log level %q, must be one of quiet, normal, verbose, debug", name)
+	}
+	return l, nil
+}
+
+// outputLogLevel is the log level computed at startup by loadLogLevel.
+var outputLogLevel = normalLogLevel
+
+// loadLogLevel computes the log level defined by --log-level, increased by one for every -v; --quiet takes precedence.
+func loadLogLevel() error {
+	if *quiet {
+		outputLogLevel = quietLogLevel
+		return nil
+	}
+	l, err := parseLogLevel(*logLevelName)
+	if err != nil {
+		return err
+	}
+	l += logLevel(*verbosity)
+	if l > debugLogLevel {
+		l = debugLogLevel
+	}
+	outputLogLevel = l
+	return nil
+}
+
+// currentLogLevel returns the log level computed at startup.
+func currentLogLevel() logLevel {
+	return outputLogLevel
+}
+
+// verbosityFlag is a counter increased by every -v.
+// NOTE: --verbose=true and --verbose=false are accepted as well, as when --verbose was a boolean flag.
+type verbosityFlag int
+
+// verbosityFlagP defines a verbosityFlag with the given name and shorthand, e.g. -v, -vv or --verbose.
+func verbosityFlagP(name, shorthand, usage string) *verbosityFlag {
+	v := new(verbosityFlag)
+	pflag.CommandLine.VarPF(v, name, shorthand, usage).NoOptDefVal = "+1"
+	return v
+}
+
+// Set increases the counter for +1 or true, resets it for false, and sets it for numbers.
+func (v *verbosityFlag) Set(s string) error {
+	switch s {
+	case "+1", "true":
+		*v++
+		return nil
+	case "false":
+		*v = 0
+		return nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return errors.Errorf("invalid verbosity %q, must be a number, true or false", s)
+	}
+	*v = verbosityFlag(n)
+	return nil
+}
+
+// Type returns the type of the flag, as shown in the usage.
+func (v *verbosityFlag) Type() string {
+	return "count"
+}
+
+// String returns the value of the counter.
+func (v *verbosityFlag) String() string {
+	return strconv.Itoa(int(*v))
+}
+
+// debugf prints a debug message if the log level is debug.
+func debugf(format string, a ...interface{}) {
+	if currentLogLevel() >= debugLogLevel {
+		fmt.Fprintf(logOutput, "DEBUG: "+format+"\n", a...)
+	}
+}
@@ -0,0 +1,226 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/pflag"
+)
+
+func Test_currentLogLevel(t *testing.T) {
+	tests := []struct {
+		name         string
+		logLevelName string
+		verbosity    int
+		quiet        bool
+		want         logLevel
+	}{
+		{
+			name:         "default",
+			logLevelName: "normal",
+			want:         normalLogLevel,
+		},
+		{
+			name:         "quiet",
+			logLevelName: "quiet",
+			want:         quietLogLevel,
+		},
+		{
+			name:         "--quiet takes precedence over --log-level and -v",
+			logLevelName: "debug",
+			verbosity:    1,
+			quiet:        true,
+			want:         quietLogLevel,
+		},
+		{
+			name:         "-v",
+			logLevelName: "normal",
+			verbosity:    1,
+			want:         verboseLogLevel,
+		},
+		{
+			name:         "-vv",
+			logLevelName: "normal",
+			verbosity:    2,
+			want:         debugLogLevel,
+		},
+		{
+			name:         "-vvv does not go over debug",
+			logLevelName: "normal",
+			verbosity:    3,
+			want:         debugLogLevel,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			cancel := setLogLevel(tt.logLevelName, tt.verbosity)
+			defer cancel()
+
+			quietBefore := *quiet
+			defer func() { *quiet = quietBefore }()
+			*quiet = tt.quiet
+			g.Expect(loadLogLevel()).To(Succeed())
+
+			g.Expect(currentLogLevel()).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_verbosityFlag(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    verbosityFlag
+		wantErr bool
+	}{
+		{
+			name: "not set",
+			args: []string{},
+			want: 0,
+		},
+		{
+			name: "-v",
+			args: []string{"-v"},
+			want: 1,
+		},
+		{
+			name: "-vv",
+			args: []string{"-vv"},
+			want: 2,
+		},
+		{
+			name: "-v --verbose",
+			args: []string{"-v", "--verbose"},
+			want: 2,
+		},
+		{
+			name: "--verbose=true, as when --verbose was a boolean flag",
+			args: []string{"--verbose=true"},
+			want: 1,
+		},
+		{
+			name: "--verbose=false, as when --verbose was a boolean flag",
+			args: []string{"-v", "--verbose=false"},
+			want: 0,
+		},
+		{
+			name: "--verbose=2",
+			args: []string{"--verbose=2"},
+			want: 2,
+		},
+		{
+			name:    "invalid value",
+			args:    []string{"--verbose=yes"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			v := new(verbosityFlag)
+			flags.VarPF(v, "verbose", "v", "").NoOptDefVal = "+1"
+
+			err := flags.Parse(tt.args)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(*v).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_parseLogLevel(t *testing.T) {
+	g := NewWithT(t)
+
+	l, err := parseLogLevel("debug")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(l).To(Equal(debugLogLevel))
+
+	_, err = parseLogLevel("invalid")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func Test_debugf(t *testing.T) {
+	cancel := setFlags("/root", "hugo", []string{"en"})
+	defer cancel()
+
+	tests := []struct {
+		name         string
+		logLevelName string
+		want         string
+	}{
+		{
+			name:         "verbose does not print link resolution steps",
+			logLevelName: "verbose",
+			want:         "",
+		},
+		{
+			name:         "debug prints link resolution steps",
+			logLevelName: "debug",
+			want: "DEBUG: <site>/content/en/folder/test.md line 1, another#anchor: parsed path \"another\", fragment \"#anchor\", language \"\"\n" +
+				"DEBUG: <site>/content/en/folder/test.md line 1, another#anchor: resolved to /root/hugo/content/en/folder/another.md#anchor\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			cancel := setLogLevel(tt.logLevelName, 0)
+			defer cancel()
+
+			var out bytes.Buffer
+			logOutputBefore := logOutput
+			defer func() { logOutput = logOutputBefore }()
+			logOutput = &out
+
+			p := newPage("/root/hugo/content/en/folder/test.md")
+			p.addLink("another#anchor", 1)
+
+			g.Expect(out.String()).To(Equal(tt.want))
+		})
+	}
+}
+
+func setLogLevel(logLevelNameValue string, verbosityValue int) (resetLogLevel func()) {
+	logLevelNameBefore := *logLevelName
+	verbosityBefore := *verbosity
+	outputLogLevelBefore := outputLogLevel
+
+	*logLevelName = logLevelNameValue
+	*verbosity = verbosityFlag(verbosityValue)
+	if err := loadLogLevel(); err != nil {
+		panic(err.Error())
+	}
+
+	return func() {
+		*logLevelName = logLevelNameBefore
+		*verbosity = verbosityBefore
+		outputLogLevel = outputLogLevelBefore
+	}
+}
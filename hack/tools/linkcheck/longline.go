@@ -0,0 +1,58 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import "strings"
+
+// readLongLineLinks returns the links and the images defined in a line longer than --max-line-length, e.g. a
+// minified table, using a linear scan for [text](addr) and ![alt](addr) instead of the regexes used for other lines.
+// NOTE: reference links, shortcodes and other forms are not read from long lines.
+func readLongLineLinks(line string) (links, images []string) {
+	start := 0
+	for {
+		i := strings.Index(line[start:], "](")
+		if i < 0 {
+			return
+		}
+		i += start
+
+		j := strings.IndexByte(line[i+2:], ')')
+		if j < 0 {
+			return
+		}
+		addr := strings.TrimSpace(line[i+2 : i+2+j])
+
+		// Look for the opening bracket only after the previous link, so each char of the line is scanned once.
+		open := strings.LastIndexByte(line[start:i], '[')
+		if open >= 0 {
+			open += start
+		}
+		start = i + 2 + j + 1
+		if open < 0 || open+1 == i || addr == "" {
+			continue
+		}
+
+		if open > 0 && line[open-1] == '!' {
+			images = append(images, strings.Fields(addr)[0])
+			continue
+		}
+		links = append(links, addr)
+	}
+}
@@ -0,0 +1,105 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_readLongLineLinks(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		wantLinks  []string
+		wantImages []string
+	}{
+		{
+			name: "no links",
+			line: "| a | b | c |",
+		},
+		{
+			name:      "links",
+			line:      "| [a](a) | [b](/b#section) | [c](https://example.com/c) |",
+			wantLinks: []string{"a", "/b#section", "https://example.com/c"},
+		},
+		{
+			name:       "images",
+			line:       `| ![logo](logo.png) | ![logo](/images/logo.png "title") |`,
+			wantImages: []string{"logo.png", "/images/logo.png"},
+		},
+		{
+			name:      "empty text or address are not links",
+			line:      "| [](a) | [b]() | [c](c) |",
+			wantLinks: []string{"c"},
+		},
+		{
+			name:      "unterminated link",
+			line:      "| [a](a) | [b](b",
+			wantLinks: []string{"a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			links, images := readLongLineLinks(tt.line)
+			g.Expect(links).To(Equal(tt.wantLinks))
+			g.Expect(images).To(Equal(tt.wantImages))
+		})
+	}
+}
+
+func Test_readMarkdownPage_maxLineLength(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	maxLineLengthBefore := *maxLineLength
+	defer func() { *maxLineLength = maxLineLengthBefore }()
+	*maxLineLength = 1000
+
+	// A minified table of more than 2MB on a single line.
+	var b strings.Builder
+	for i := 0; i < 50000; i++ {
+		fmt.Fprintf(&b, "| [cell %d](#cell-%d) | some text in the cell ", i, i)
+	}
+	long := b.String()
+
+	path := filepath.Join(root, "hugo", contentFolder, "en/_index.md")
+	write(g, path, fmt.Sprintf("# Home\n\n%s\n\nSee [page](page).\n", long))
+
+	p := readMarkdownPage(path)
+	g.Expect(p.warnings).To(Equal([]warning{{lineNumber: 3, message: fmt.Sprintf("the line is %d bytes long, more than the 1000 allowed; only [text](addr) links and ![alt](addr) images are checked", len(long))}}))
+	g.Expect(p.links).To(HaveLen(50001))
+	g.Expect(p.links[49999].rawLink).To(Equal("#cell-49999"))
+	g.Expect(p.links[50000].rawLink).To(Equal("page"))
+	g.Expect(p.links[50000].lineNumber).To(Equal(5))
+}
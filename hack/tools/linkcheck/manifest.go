@@ -0,0 +1,93 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// manifestAssets contains the absolute path of each asset listed in the --assets-manifest file, with its line number.
+var manifestAssets map[string]int
+
+// loadAssetsManifest loads the assets listed in the --assets-manifest file, if any.
+// NOTE: --assets-manifest is converted to an absolute path, so it can be reported as a page.
+func loadAssetsManifest() error {
+	manifestAssets = nil
+	if *assetsManifest == "" {
+		return nil
+	}
+
+	path, err := filepath.Abs(*assetsManifest)
+	if err != nil {
+		return errors.Wrapf(err, "failed to convert assets manifest to an absolute path")
+	}
+	*assetsManifest = path
+
+	content, err := readFile(path)
+	if err != nil {
+		return err
+	}
+	manifestAssets = readAssetsManifest(string(content))
+	return nil
+}
+
+// readAssetsManifest reads an assets manifest, with the path of an asset relative to the hugo folder for each line,
+// e.g. static/images/logo.png; empty lines and lines starting with # are ignored.
+func readAssetsManifest(content string) map[string]int {
+	assets := map[string]int{}
+	for i, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		assets[filepath.Join(*root, *hugoFolder, filepath.FromSlash(line))] = i + 1
+	}
+	return assets
+}
+
+// isInAssetsManifest returns true if there is no --assets-manifest, or if the asset at an absolute path is listed in it.
+func isInAssetsManifest(path string) bool {
+	if manifestAssets == nil {
+		return true
+	}
+	_, ok := manifestAssets[path]
+	return ok
+}
+
+// checkAssetsManifest adds a page for the --assets-manifest file, with a link for each asset listed in it, so
+// linkcheck reports the assets listed in the manifest but missing on disk.
+func checkAssetsManifest() {
+	if manifestAssets == nil {
+		return
+	}
+
+	p := newPage(*assetsManifest)
+	for path, lineNumber := range manifestAssets {
+		rawLink := filepath.ToSlash(strings.TrimPrefix(path, filepath.Join(*root, *hugoFolder)+string(filepath.Separator)))
+		p.links = append(p.links, link{rawLink: rawLink, lineNumber: lineNumber, isImage: true, URL: &url.URL{Path: path}})
+	}
+	sort.Slice(p.links, func(i, j int) bool { return p.links[i].lineNumber < p.links[j].lineNumber })
+	addPage(p)
+}
@@ -0,0 +1,83 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_readAllAndLinkcheckAll_assetsManifest(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	hugoDir := filepath.Join(root, "hugo")
+	write(g, filepath.Join(hugoDir, contentFolder, "en/_index.md"), `# Home
+
+![listed](/images/listed.png) ![not listed](/images/not-listed.png) ![bundle](bundle.png)
+`)
+	touch(g, filepath.Join(hugoDir, contentFolder, "en/bundle.png"))
+	touch(g, filepath.Join(hugoDir, "static/images/listed.png"))
+	touch(g, filepath.Join(hugoDir, "static/images/not-listed.png"))
+
+	manifestPath := filepath.Join(root, "assets.txt")
+	write(g, manifestPath, `# processed images
+static/images/listed.png
+content/en/bundle.png
+
+static/images/deleted.png
+`)
+
+	assetsManifestBefore := *assetsManifest
+	defer func() {
+		*assetsManifest = assetsManifestBefore
+		manifestAssets = nil
+	}()
+	*assetsManifest = manifestPath
+	g.Expect(loadAssetsManifest()).To(Succeed())
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	g.Expect(readAll()).To(Succeed())
+	checkAssetsManifest()
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+
+	// Images on disk but not in the manifest, and assets in the manifest but not on disk, are reported.
+	g.Expect(collectErrors(root)).To(Equal([]string{
+		"/assets.txt:5: static/images/deleted.png: the link resolves to /hugo/static/images/deleted.png which does not exist",
+		"/hugo/content/en/_index.md:3: /images/not-listed.png: the image /hugo/static/images/not-listed.png is not listed in the assets manifest",
+	}))
+}
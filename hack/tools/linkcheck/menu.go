@@ -0,0 +1,78 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// checkMenus adds a page for the hugo website config, with a link for each menu entry url, so
+// menu entries are checked like absolute links in the content tree.
+func checkMenus() error {
+	config, err := readHugoConfig()
+	if err != nil {
+		return err
+	}
+
+	content, err := readFile(hugoConfigPath())
+	if err != nil {
+		return errors.Wrapf(err, "failed to read hugo config")
+	}
+	lines := strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+
+	// Menu entries are resolved as if they were links in the root page of the default language.
+	p := newPage(hugoConfigPath())
+	p.isHugoPage = true
+	p.hugoPath = "/"
+	if len(*hugoLanguages) > 0 {
+		p.hugoLanguage = (*hugoLanguages)[0]
+	}
+
+	menus := make([]string, 0, len(config.Menu))
+	for m := range config.Menu {
+		menus = append(menus, m)
+	}
+	sort.Strings(menus)
+
+	for _, m := range menus {
+		for _, e := range config.Menu[m] {
+			if e.URL == "" {
+				continue
+			}
+			p.addLink(e.URL, menuEntryLine(lines, e.URL))
+		}
+	}
+	addPage(p)
+	return nil
+}
+
+// menuEntryLine returns the line in the hugo config where a menu entry url is defined, if any.
+func menuEntryLine(lines []string, url string) int {
+	for i, l := range lines {
+		if strings.Contains(l, fmt.Sprintf("%q", url)) || strings.Contains(l, fmt.Sprintf("'%s'", url)) {
+			return i + 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,87 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_checkMenus(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	hugoFolder := "hugo"
+
+	cancel := setFlags(root, hugoFolder, []string{"en"})
+	defer cancel()
+
+	contentDir := filepath.Join(root, hugoFolder, contentFolder)
+	touch(g, filepath.Join(contentDir, "en/_index.md"))
+	touch(g, filepath.Join(contentDir, "en/docs/_index.md"))
+	touch(g, filepath.Join(contentDir, "en/docs/quickstart.md"))
+
+	write(g, filepath.Join(root, hugoFolder, hugoConfigFile), `title = "test"
+
+[[menu.main]]
+name = "Docs"
+url = "/docs/"
+
+[[menu.main]]
+name = "Quickstart"
+url = "/docs/quickstart"
+
+[[menu.main]]
+name = "Missing"
+url = "/docs/missing"
+
+[[menu.main]]
+name = "GitHub"
+url = "https://github.com/kubernetes-sigs/cluster-api"
+`)
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(checkMenus()).To(Succeed())
+
+	configPage, ok := pagesByPath[filepath.Join(root, hugoFolder, hugoConfigFile)]
+	g.Expect(ok).To(BeTrue())
+	g.Expect(configPage.links).To(HaveLen(4))
+
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+
+	g.Expect(collectErrors(root)).To(Equal([]string{
+		"/hugo/config.toml:13: /docs/missing: the link resolves to /hugo/content/en/docs/missing.md which does not exist",
+	}))
+}
@@ -0,0 +1,135 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// netrcCredentials defines the credentials for a machine in a netrc file.
+type netrcCredentials struct {
+	login    string
+	password string
+}
+
+// netrc defines the credentials read from a netrc file.
+type netrc struct {
+	// machines contains the credentials by machine name.
+	machines map[string]netrcCredentials
+
+	// defaultCredentials if set, applies to machines not listed in the netrc file.
+	defaultCredentials *netrcCredentials
+}
+
+// credentials returns the credentials for a host, if any.
+func (n *netrc) credentials(host string) (netrcCredentials, bool) {
+	if n == nil {
+		return netrcCredentials{}, false
+	}
+	if c, ok := n.machines[host]; ok {
+		return c, true
+	}
+	if n.defaultCredentials != nil {
+		return *n.defaultCredentials, true
+	}
+	return netrcCredentials{}, false
+}
+
+// defaultNetrcPath returns the path of the netrc file in the user home directory.
+func defaultNetrcPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".netrc")
+}
+
+// readNetrc reads a netrc file.
+// NOTE: macdef entries are skipped, because they are not relevant for linkcheck.
+func readNetrc(path string) (*netrc, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &netrc{machines: map[string]netrcCredentials{}}
+	var current *netrcCredentials
+	var currentMachine string
+	flush := func() {
+		if current == nil {
+			return
+		}
+		if currentMachine == "" {
+			n.defaultCredentials = current
+		} else {
+			n.machines[currentMachine] = *current
+		}
+		current = nil
+	}
+
+	inMacdef := false
+	for _, line := range strings.Split(string(content), "\n") {
+		// NOTE: a macdef is terminated by an empty line.
+		if inMacdef {
+			inMacdef = strings.TrimSpace(line) != ""
+			continue
+		}
+		if strings.HasPrefix(strings.TrimSpace(line), "macdef") {
+			flush()
+			inMacdef = true
+			continue
+		}
+
+		tokens := strings.Fields(line)
+		for i := 0; i < len(tokens); i++ {
+			switch tokens[i] {
+			case "machine":
+				flush()
+				if i+1 >= len(tokens) {
+					return nil, errors.Errorf("machine without a name in %s", path)
+				}
+				i++
+				currentMachine = tokens[i]
+				current = &netrcCredentials{}
+			case "default":
+				flush()
+				currentMachine = ""
+				current = &netrcCredentials{}
+			case "login", "password", "account":
+				if current == nil || i+1 >= len(tokens) {
+					return nil, errors.Errorf("unexpected %s in %s", tokens[i], path)
+				}
+				i++
+				switch tokens[i-1] {
+				case "login":
+					current.login = tokens[i]
+				case "password":
+					current.password = tokens[i]
+				}
+			}
+		}
+	}
+	flush()
+	return n, nil
+}
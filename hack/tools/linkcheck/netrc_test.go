@@ -0,0 +1,108 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_readNetrc(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		wantNetrc *netrc
+		wantErr   bool
+	}{
+		{
+			name:    "machines on a single line and on many lines",
+			content: "machine example.com login user password secret\n\nmachine docs.example.com\n  login another\n  password another-secret\n",
+			wantNetrc: &netrc{
+				machines: map[string]netrcCredentials{
+					"example.com":      {login: "user", password: "secret"},
+					"docs.example.com": {login: "another", password: "another-secret"},
+				},
+			},
+		},
+		{
+			name:    "default credentials and macdef",
+			content: "machine example.com login user password secret\nmacdef init\nlogin ignored\n\ndefault login anonymous password guest\n",
+			wantNetrc: &netrc{
+				machines: map[string]netrcCredentials{
+					"example.com": {login: "user", password: "secret"},
+				},
+				defaultCredentials: &netrcCredentials{login: "anonymous", password: "guest"},
+			},
+		},
+		{
+			name:    "login without machine",
+			content: "login user password secret\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			dir, err := os.MkdirTemp("", "linkcheck")
+			g.Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(dir)
+
+			path := filepath.Join(dir, ".netrc")
+			write(g, path, tt.content)
+
+			n, err := readNetrc(path)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(n).To(Equal(tt.wantNetrc))
+		})
+	}
+}
+
+func Test_netrc_credentials(t *testing.T) {
+	g := NewWithT(t)
+
+	var n *netrc
+	_, ok := n.credentials("example.com")
+	g.Expect(ok).To(BeFalse())
+
+	n = &netrc{
+		machines: map[string]netrcCredentials{
+			"example.com": {login: "user", password: "secret"},
+		},
+	}
+	c, ok := n.credentials("example.com")
+	g.Expect(ok).To(BeTrue())
+	g.Expect(c).To(Equal(netrcCredentials{login: "user", password: "secret"}))
+
+	_, ok = n.credentials("another.com")
+	g.Expect(ok).To(BeFalse())
+
+	n.defaultCredentials = &netrcCredentials{login: "anonymous", password: "guest"}
+	c, ok = n.credentials("another.com")
+	g.Expect(ok).To(BeTrue())
+	g.Expect(c).To(Equal(netrcCredentials{login: "anonymous", password: "guest"}))
+}
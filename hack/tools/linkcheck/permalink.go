@@ -0,0 +1,148 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// permalinkPatterns contains the permalink pattern of each section, as read from the permalinks of the hugo website config.
+var permalinkPatterns map[string]string
+
+// permalinkPaths contains the path of the page served at each permalink, in the language:/site/path form.
+var permalinkPaths map[string]string
+
+// loadPermalinks reads the permalink pattern of each section from the hugo website config, if any.
+func loadPermalinks() error {
+	permalinkPatterns = nil
+	if *hugoFolder == "" {
+		return nil
+	}
+
+	config, err := readHugoConfig()
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	for section, pattern := range config.Permalinks {
+		if _, ok := expandPermalink(pattern, &page{}); !ok {
+			return errors.Errorf("unsupported permalink pattern %q for section %s", pattern, section)
+		}
+	}
+	permalinkPatterns = config.Permalinks
+	return nil
+}
+
+// indexPermalinks computes the permalink of the pages in sections with a permalink pattern.
+// NOTE: as in hugo, permalink patterns apply to the pages of a section, not to the section itself.
+func indexPermalinks() {
+	permalinkPaths = nil
+	if len(permalinkPatterns) == 0 {
+		return
+	}
+
+	permalinkPaths = map[string]string{}
+	for i := range pages {
+		p := pages[i]
+		if !p.isHugoPage || p.fatalError != nil || path.Base(p.hugoPath) == "_index.md" {
+			continue
+		}
+
+		pattern, ok := permalinkPatterns[pageSection(p)]
+		if !ok {
+			continue
+		}
+		permalink, _ := expandPermalink(pattern, p)
+		permalinkPaths[fmt.Sprintf("%s:%s", p.hugoLanguage, cleanSitePath(permalink))] = p.path
+	}
+}
+
+// resolvePermalink returns the path of the page served at the permalink matching the path of a missing page.
+func resolvePermalink(missingPath string) (string, bool) {
+	missingp := newPage(missingPath)
+	if !missingp.isHugoPage || missingp.hugoLanguage == "" {
+		return "", false
+	}
+
+	targetPath, ok := permalinkPaths[fmt.Sprintf("%s:%s", missingp.hugoLanguage, cleanSitePath(missingp.sitePath()))]
+	return targetPath, ok
+}
+
+// pageSection returns the section of a page, which is the first folder of its path, e.g. blog for /blog/post.md.
+func pageSection(p *page) string {
+	section := strings.SplitN(strings.TrimPrefix(p.hugoPath, "/"), "/", 2)[0]
+	if section == path.Base(p.hugoPath) {
+		return ""
+	}
+	return section
+}
+
+// Search for permalink tokens, e.g. :slug, captures the token name.
+var permalinkTokenRx = regexp.MustCompile(`:(\w+)`)
+
+// expandPermalink returns the permalink of a page, replacing the tokens in a permalink pattern, e.g. /:section/:slug/;
+// it returns false if the pattern uses a token not supported by linkcheck.
+func expandPermalink(pattern string, p *page) (string, bool) {
+	filename := strings.TrimSuffix(path.Base(p.hugoPath), ".md")
+	slug := p.frontMatter.Slug
+	if slug == "" {
+		slug = anchorFromHeading(p.frontMatter.Title)
+	}
+	slugOrFilename := p.frontMatter.Slug
+	if slugOrFilename == "" {
+		slugOrFilename = filename
+	}
+	date := p.frontMatter.Date.Time
+
+	supported := true
+	permalink := permalinkTokenRx.ReplaceAllStringFunc(pattern, func(token string) string {
+		switch token {
+		case ":year":
+			return date.Format("2006")
+		case ":month":
+			return date.Format("01")
+		case ":day":
+			return date.Format("02")
+		case ":section":
+			return pageSection(p)
+		case ":sections":
+			return path.Dir(strings.TrimPrefix(p.hugoPath, "/"))
+		case ":title":
+			return anchorFromHeading(p.frontMatter.Title)
+		case ":slug":
+			return slug
+		case ":filename":
+			return filename
+		case ":slugorfilename":
+			return slugOrFilename
+		}
+		supported = false
+		return token
+	})
+	return path.Join("/", permalink), supported
+}
@@ -0,0 +1,139 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_expandPermalink(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		page    page
+		want    string
+		wantOk  bool
+	}{
+		{
+			name:    "section and slug",
+			pattern: ":section/:slug",
+			page:    page{hugoPath: "/blog/post.md", frontMatter: frontMatter{Slug: "my-post"}},
+			want:    "/blog/my-post",
+			wantOk:  true,
+		},
+		{
+			name:    "slug defaults to the title",
+			pattern: "/:section/:slug/",
+			page:    page{hugoPath: "/blog/post.md", frontMatter: frontMatter{Title: "My Post"}},
+			want:    "/blog/my-post",
+			wantOk:  true,
+		},
+		{
+			name:    "date and filename",
+			pattern: "/:year/:month/:day/:filename/",
+			page:    page{hugoPath: "/blog/2022/post.md", frontMatter: frontMatter{Date: frontMatterDate{time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC)}}},
+			want:    "/2022/01/02/post",
+			wantOk:  true,
+		},
+		{
+			name:    "sections and slug or filename",
+			pattern: "/:sections/:slugorfilename",
+			page:    page{hugoPath: "/blog/2022/post.md"},
+			want:    "/blog/2022/post",
+			wantOk:  true,
+		},
+		{
+			name:    "unsupported token",
+			pattern: "/:section/:unknown",
+			page:    page{hugoPath: "/blog/post.md"},
+			want:    "/blog/:unknown",
+			wantOk:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, ok := expandPermalink(tt.pattern, &tt.page)
+			g.Expect(got).To(Equal(tt.want))
+			g.Expect(ok).To(Equal(tt.wantOk))
+		})
+	}
+}
+
+func Test_readAllAndLinkcheckAll_permalinks(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	write(g, filepath.Join(root, "hugo", hugoConfigFile), `[permalinks]
+blog = ":section/:slug"
+`)
+	contentDir := filepath.Join(root, "hugo", contentFolder, "en")
+	write(g, filepath.Join(contentDir, "_index.md"), `# Home
+
+See the [post](/blog/my-post/), its [section](/blog/my-post#section) or a [missing post](/blog/missing).
+`)
+	write(g, filepath.Join(contentDir, "blog/_index.md"), "# Blog\n")
+	write(g, filepath.Join(contentDir, "blog/2022-01-02-post.md"), `---
+slug: my-post
+---
+# Post
+
+## Section
+`)
+
+	permalinkPatternsBefore := permalinkPatterns
+	defer func() { permalinkPatterns = permalinkPatternsBefore }()
+	g.Expect(loadPermalinks()).To(Succeed())
+	g.Expect(permalinkPatterns).To(Equal(map[string]string{"blog": ":section/:slug"}))
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+		permalinkPaths = nil
+	}()
+
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+	g.Expect(collectErrors(root)).To(Equal([]string{
+		"/hugo/content/en/_index.md:3: /blog/missing: the link resolves to /hugo/content/en/blog/missing.md which does not exist",
+	}))
+
+	// Unsupported permalink patterns are reported.
+	write(g, filepath.Join(root, "hugo", hugoConfigFile), `[permalinks]
+blog = ":section/:unknown"
+`)
+	g.Expect(loadPermalinks()).ToNot(Succeed())
+}
@@ -0,0 +1,161 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"io"
+	"sort"
+	"time"
+)
+
+// recheckPage reads again a page that changed, e.g. in watch mode or in editor integrations, and checks only the links
+// affected by the change: the links of the page itself, and the links of other pages resolving to the page, because the
+// anchors of the page could have changed. It returns the number of links checked.
+// NOTE: pages must be read and checked with readAll and linkcheckAll before.
+func recheckPage(ctx context.Context, path string) int {
+	p := readMarkdownPage(path)
+	if oldp, ok := pagesByPath[path]; ok {
+		*oldp = p
+	} else {
+		addPage(p)
+	}
+
+	linkcheckPage(ctx, path)
+	checked := len(pagesByPath[path].links)
+
+	// Check again the links of other pages resolving to the page, no matter if directly or e.g. through a redirect or a
+	// permalink; errors and warnings found when checking them before are dropped, because they could have been fixed by
+	// the change.
+	// NOTE: links with errors found when reading pages are not checked, so they have no target path and are not affected.
+	targetsPage := func(l link) bool {
+		return l.targetPath != "" && (l.targetPath == path || l.URL.Path == path)
+	}
+	for i := range pages {
+		other := pages[i]
+		if other.path == path || other.fatalError != nil {
+			continue
+		}
+
+		// NOTE: links are identified by line and raw link, because links equal to an affected link are affected too.
+		type linkKey struct {
+			lineNumber int
+			rawLink    string
+		}
+		affected := map[linkKey]bool{}
+		for j, l := range other.links {
+			if !targetsPage(l) {
+				continue
+			}
+			other.warnings = removeWarning(other.warnings, casingWarning(l, l.targetPath))
+			other.links[j].fatalError = nil
+			other.links[j].targetPath = ""
+			affected[linkKey{lineNumber: l.lineNumber, rawLink: l.rawLink}] = true
+		}
+		if len(affected) == 0 {
+			continue
+		}
+		n := 0
+		debugf("%s: checking links resolving to %s", other.logPath(), pagesByPath[path].logPath())
+		linkcheckPageLinks(ctx, other.path, func(l link) bool {
+			if !affected[linkKey{lineNumber: l.lineNumber, rawLink: l.rawLink}] {
+				return false
+			}
+			n++
+			return true
+		})
+		checked += n
+	}
+	return checked
+}
+
+// removeWarning returns warnings without the occurrences of warning.
+func removeWarning(warnings []warning, w warning) []warning {
+	kept := warnings[:0]
+	for _, k := range warnings {
+		if k != w {
+			kept = append(kept, k)
+		}
+	}
+	return kept
+}
+
+// watchPages checks again the pages changed on disk every interval, until ctx is done, and prints the report after
+// each change; only the links affected by the change are checked again, see recheckPage.
+// NOTE: pages added after linkcheck started are not read.
+func watchPages(ctx context.Context, w io.Writer, interval time.Duration) error {
+	modTimes := pageModTimes()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		changed := changedPages(modTimes)
+		if len(changed) == 0 {
+			continue
+		}
+
+		// Folders are listed again, because files could be renamed.
+		resetDirNames()
+		for _, path := range changed {
+			n := recheckPage(ctx, path)
+			debugf("%s: changed, %d links checked", pagesByPath[path].logPath(), n)
+		}
+		if err := printReport(w); err != nil {
+			return err
+		}
+	}
+}
+
+// pageModTimes returns the modification time of all the pages, by path; pages which cannot be read have a zero time.
+func pageModTimes() map[string]time.Time {
+	modTimes := map[string]time.Time{}
+	for i := range pages {
+		modTimes[pages[i].path] = pageModTime(pages[i].path)
+	}
+	return modTimes
+}
+
+func pageModTime(path string) time.Time {
+	info, err := statFile(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// changedPages returns the pages whose modification time is different from modTimes, sorted by path, and updates
+// modTimes accordingly.
+func changedPages(modTimes map[string]time.Time) []string {
+	var changed []string
+	for i := range pages {
+		path := pages[i].path
+		if t := pageModTime(path); !t.Equal(modTimes[path]) {
+			modTimes[path] = t
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
@@ -0,0 +1,216 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_recheckPage(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	contentDir := filepath.Join(root, "hugo", contentFolder, "en")
+	write(g, filepath.Join(contentDir, "_index.md"), "# Home\n\nSee [a](a#new-section), [a again](a) and [c](c#missing).\n")
+	write(g, filepath.Join(contentDir, "a.md"), "# A\n\n## Old Section\n\nSee [home](/).\n")
+	write(g, filepath.Join(contentDir, "c.md"), "# C\n")
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+	g.Expect(collectErrors(root)).To(Equal([]string{
+		"/hugo/content/en/_index.md:3: a#new-section: #new-section does exists in <site>/content/en/a.md",
+		"/hugo/content/en/_index.md:3: c#missing: #missing does exists in <site>/content/en/c.md",
+	}))
+
+	// The headings of a.md change; only its own link and the two links targeting it are checked again.
+	write(g, filepath.Join(contentDir, "a.md"), "# A\n\n## New Section\n\nSee [home](/) or the [old section](#old-section).\n")
+	g.Expect(recheckPage(context.Background(), filepath.Join(contentDir, "a.md"))).To(Equal(4))
+	g.Expect(collectErrors(root)).To(Equal([]string{
+		"/hugo/content/en/_index.md:3: c#missing: #missing does exists in <site>/content/en/c.md",
+		"/hugo/content/en/a.md:5: #old-section: #old-section does exists in <site>/content/en/a.md",
+	}))
+	g.Expect(pages).To(HaveLen(3))
+}
+
+func Test_recheckPage_resolvedTargets(t *testing.T) {
+	g := NewWithT(t)
+
+	cancel := setFlags("/root", "hugo", []string{"en"})
+	defer cancel()
+
+	r, err := readRedirects("/old /docs/page\n")
+	g.Expect(err).ToNot(HaveOccurred())
+	redirects = r
+	defer func() { redirects = map[string]string{} }()
+
+	fileSystemBefore := fileSystem
+	defer func() { fileSystem = fileSystemBefore }()
+	files := fstest.MapFS{
+		"root/hugo/content/en/_index.md":      {Data: []byte("# Home\n\nSee [old](/old#new-section), [guide](docs/Guide#new-section) and [other](docs/other).\n")},
+		"root/hugo/content/en/docs/_index.md": {Data: []byte("# Docs\n")},
+		"root/hugo/content/en/docs/page.md":   {Data: []byte("# Page\n\n## Old Section\n")},
+		"root/hugo/content/en/docs/guide.md":  {Data: []byte("# Guide\n\n## Old Section\n")},
+		"root/hugo/content/en/docs/other.md":  {Data: []byte("# Other\n")},
+	}
+	fileSystem = caseInsensitiveFS{MapFS: files}
+	resetDirNames()
+	defer resetDirNames()
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+	g.Expect(collectErrors("/root")).To(Equal([]string{
+		"/hugo/content/en/_index.md:3: /old#new-section: #new-section does exists in <site>/content/en/docs/page.md",
+		"/hugo/content/en/_index.md:3: docs/Guide#new-section: #new-section does exists in <site>/content/en/docs/guide.md",
+	}))
+	casingWarnings := []warning{
+		{lineNumber: 3, rawLink: "docs/Guide#new-section", message: "the link resolves to /hugo/content/en/docs/guide.md using a different casing, which does not exist on case sensitive filesystems"},
+	}
+	g.Expect(pagesByPath["/root/hugo/content/en/_index.md"].warnings).To(Equal(casingWarnings))
+
+	// The link reaching page.md through a redirect is checked again.
+	files["root/hugo/content/en/docs/page.md"] = &fstest.MapFile{Data: []byte("# Page\n\n## New Section\n")}
+	g.Expect(recheckPage(context.Background(), "/root/hugo/content/en/docs/page.md")).To(Equal(1))
+	g.Expect(collectErrors("/root")).To(Equal([]string{
+		"/hugo/content/en/_index.md:3: docs/Guide#new-section: #new-section does exists in <site>/content/en/docs/guide.md",
+	}))
+
+	// The link reaching guide.md using a different casing is checked again, and it is reported only once.
+	files["root/hugo/content/en/docs/guide.md"] = &fstest.MapFile{Data: []byte("# Guide\n\n## New Section\n")}
+	g.Expect(recheckPage(context.Background(), "/root/hugo/content/en/docs/guide.md")).To(Equal(1))
+	g.Expect(collectErrors("/root")).To(BeEmpty())
+	g.Expect(pagesByPath["/root/hugo/content/en/_index.md"].warnings).To(Equal(casingWarnings))
+}
+
+func Test_changedPages(t *testing.T) {
+	g := NewWithT(t)
+
+	cancel := setFlags("/root", "hugo", []string{"en"})
+	defer cancel()
+
+	fileSystemBefore := fileSystem
+	defer func() { fileSystem = fileSystemBefore }()
+	files := fstest.MapFS{
+		"root/hugo/content/en/a.md": {Data: []byte("# A\n"), ModTime: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)},
+		"root/hugo/content/en/b.md": {Data: []byte("# B\n"), ModTime: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	fileSystem = files
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	g.Expect(readAll()).To(Succeed())
+	modTimes := pageModTimes()
+	g.Expect(changedPages(modTimes)).To(BeEmpty())
+
+	files["root/hugo/content/en/b.md"].ModTime = time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC)
+	delete(files, "root/hugo/content/en/a.md")
+	g.Expect(changedPages(modTimes)).To(Equal([]string{"/root/hugo/content/en/a.md", "/root/hugo/content/en/b.md"}))
+	g.Expect(changedPages(modTimes)).To(BeEmpty())
+}
+
+// cancelOnReportWriter cancels a context when the report is printed.
+type cancelOnReportWriter struct {
+	bytes.Buffer
+	cancel context.CancelFunc
+}
+
+func (w *cancelOnReportWriter) Write(p []byte) (int, error) {
+	if strings.Contains(string(p), "Total page processed") {
+		defer w.cancel()
+	}
+	return w.Buffer.Write(p)
+}
+
+func Test_watchPages(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	contentDir := filepath.Join(root, "hugo", contentFolder, "en")
+	write(g, filepath.Join(contentDir, "_index.md"), "# Home\n\nSee [a](a#new-section).\n")
+	write(g, filepath.Join(contentDir, "a.md"), "# A\n\n## Old Section\n")
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+	g.Expect(collectErrors(root)).To(HaveLen(1))
+
+	ctx, cancelWatch := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelWatch()
+	w := &cancelOnReportWriter{cancel: cancelWatch}
+	done := make(chan error)
+	go func() { done <- watchPages(ctx, w, 10*time.Millisecond) }()
+
+	// Wait for the modification time of the pages to be collected, then change a.md.
+	time.Sleep(100 * time.Millisecond)
+	write(g, filepath.Join(contentDir, "a.md"), "# A\n\n## New Section\n")
+	modTime := time.Now().Add(time.Hour)
+	g.Expect(os.Chtimes(filepath.Join(contentDir, "a.md"), modTime, modTime)).To(Succeed())
+
+	g.Expect(<-done).To(Succeed())
+	g.Expect(ctx.Err()).To(Equal(context.Canceled))
+	g.Expect(w.String()).To(ContainSubstring("Total page processed: 2 links: 1 anchors: 3"))
+	g.Expect(collectErrors(root)).To(BeEmpty())
+}
@@ -0,0 +1,99 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// redirects contains the target of each redirected site path, as read from the --redirects file.
+var redirects = map[string]string{}
+
+// loadRedirects loads the redirects from the --redirects file, if any.
+func loadRedirects() error {
+	redirects = map[string]string{}
+	if *redirectsFile == "" {
+		return nil
+	}
+
+	content, err := os.ReadFile(*redirectsFile)
+	if err != nil {
+		return err
+	}
+	r, err := readRedirects(string(content))
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse %s", *redirectsFile)
+	}
+	redirects = r
+	return nil
+}
+
+// readRedirects reads redirects in the _redirects file format, with one "/from /to [status]" rule for each line.
+// NOTE: rules redirecting to other websites are ignored, because their target is not a page of the website.
+func readRedirects(content string) (map[string]string, error) {
+	r := map[string]string{}
+	for i, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) < 2 || !strings.HasPrefix(fields[0], "/") {
+			return nil, errors.Errorf("invalid redirect at line %d, must be in the \"/from /to [status]\" form", i+1)
+		}
+		if !strings.HasPrefix(fields[1], "/") {
+			continue
+		}
+		r[cleanSitePath(fields[0])] = cleanSitePath(fields[1])
+	}
+	return r, nil
+}
+
+// cleanSitePath returns a site path without trailing slashes, e.g. /docs/ becomes /docs.
+func cleanSitePath(p string) string {
+	p, _ = splitPathAndFragment(p)
+	return path.Clean("/" + p)
+}
+
+// resolveRedirect returns the path of the page targeted by a redirect, if the path of a missing page is redirected.
+func resolveRedirect(missingPath string) (string, bool) {
+	missingp := newPage(missingPath)
+	if !missingp.isHugoPage || missingp.hugoLanguage == "" {
+		return "", false
+	}
+
+	target, ok := redirects[cleanSitePath(missingp.sitePath())]
+	if !ok {
+		return "", false
+	}
+
+	targetPath := filepath.Join(languageContentDir(missingp.hugoLanguage), target)
+	if isDir, _ := isDirectory(targetPath); isDir {
+		return filepath.Join(targetPath, "_index.md"), true
+	}
+	if isAssetPath(targetPath) {
+		return targetPath, true
+	}
+	return targetPath + ".md", true
+}
@@ -0,0 +1,122 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_readRedirects(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:    "empty",
+			content: "",
+			want:    map[string]string{},
+		},
+		{
+			name:    "redirects with comments and status",
+			content: "# old docs\n/old /docs/page 301\n/old-section/ /docs/\n\n/external https://example.com 302\n",
+			want: map[string]string{
+				"/old":         "/docs/page",
+				"/old-section": "/docs",
+			},
+		},
+		{
+			name:    "missing target",
+			content: "/old\n",
+			wantErr: true,
+		},
+		{
+			name:    "relative source",
+			content: "old /docs/page\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := readRedirects(tt.content)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_readAllAndLinkcheckAll_redirects(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	redirectsPath := filepath.Join(root, "_redirects")
+	write(g, redirectsPath, "/old /docs/page 301\n/old-section /docs\n/old-broken /docs/missing\n")
+
+	redirectsFileBefore := *redirectsFile
+	defer func() { *redirectsFile = redirectsFileBefore }()
+	*redirectsFile = redirectsPath
+
+	g.Expect(loadRedirects()).To(Succeed())
+	defer func() { redirects = map[string]string{} }()
+
+	contentDir := filepath.Join(root, "hugo", contentFolder)
+	write(g, filepath.Join(contentDir, "en/_index.md"), `# Home
+
+See [old](/old), [old section](/old#section), [broken section](/old#broken) or [old docs](/old-section/).
+See [broken redirect](/old-broken) or [missing](/missing).
+`)
+	write(g, filepath.Join(contentDir, "en/docs/_index.md"), "# Docs\n")
+	write(g, filepath.Join(contentDir, "en/docs/page.md"), "# Page\n\n## Section\n")
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+
+	g.Expect(collectErrors(root)).To(Equal([]string{
+		"/hugo/content/en/_index.md:3: /old#broken: #broken does exists in <site>/content/en/docs/page.md",
+		"/hugo/content/en/_index.md:4: /missing: the link resolves to /hugo/content/en/missing.md which does not exist",
+		"/hugo/content/en/_index.md:4: /old-broken: the link resolves to /hugo/content/en/old-broken.md which redirects to /hugo/content/en/docs/missing.md which does not exist",
+	}))
+}
@@ -0,0 +1,175 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/html"
+)
+
+// renderedPage defines a page rendered by a running hugo server.
+type renderedPage struct {
+	// err if set, defines an error in fetching the page.
+	err string
+
+	// ids of the elements in the page, which are the anchors that can be linked.
+	ids []string
+
+	// hrefs of the links in the page.
+	hrefs []string
+}
+
+// renderedPages contains the rendered pages already fetched, by url without fragment.
+var renderedPages = map[string]*renderedPage{}
+
+// parseRenderedBaseURL returns the base url of the running hugo server defined by --rendered-base-url.
+func parseRenderedBaseURL() (*url.URL, error) {
+	u, err := url.Parse(*renderedBaseURL)
+	if err != nil {
+		return nil, err
+	}
+	if !isExternalLink(u) || u.Host == "" {
+		return nil, errors.Errorf("invalid rendered base url %q, must be an absolute http or https url", *renderedBaseURL)
+	}
+	return u, nil
+}
+
+// renderedURL returns the url of a page rendered by the hugo server.
+// NOTE: the first language is assumed to be the default language, which is served from the root of the website.
+func renderedURL(base *url.URL, p *page) *url.URL {
+	sitePath := p.sitePath()
+	if len(*hugoLanguages) > 0 && p.hugoLanguage != (*hugoLanguages)[0] {
+		sitePath = "/" + p.hugoLanguage + sitePath
+	}
+	u := *base
+	u.Path = strings.TrimSuffix(base.Path, "/") + strings.TrimSuffix(sitePath, "/") + "/"
+	return &u
+}
+
+// checkRenderedAll checks anchors and internal links of all hugo pages against the HTML rendered by a running hugo server.
+// This catches links generated by shortcodes or templates, that cannot be read from the markdown.
+// NOTE: errors are added to the page as links without a line number, because the rendered HTML cannot be mapped back to the markdown.
+func checkRenderedAll() error {
+	base, err := parseRenderedBaseURL()
+	if err != nil {
+		return err
+	}
+
+	for i := range pages {
+		p := pages[i]
+		if !p.isHugoPage || p.fatalError != nil || (p.frontMatter.Draft && !*includeDrafts) {
+			continue
+		}
+		checkRenderedPage(base, p)
+	}
+	return nil
+}
+
+// checkRenderedPage checks anchors and internal links of a page against the HTML rendered by a running hugo server.
+func checkRenderedPage(base *url.URL, p *page) {
+	pageURL := renderedURL(base, p)
+	rp := fetchRenderedPage(pageURL)
+	if rp.err != "" {
+		p.links = append(p.links, link{rawLink: pageURL.String(), URL: pageURL, fatalError: newLinkcheckError(renderedErrorCategory, "%s", rp.err)})
+		return
+	}
+
+	for _, href := range rp.hrefs {
+		u, err := pageURL.Parse(href)
+		if err != nil {
+			p.links = append(p.links, link{rawLink: href, fatalError: newLinkcheckError(renderedErrorCategory, "error parsing rendered url: %v", err)})
+			continue
+		}
+
+		// Links to other websites are checked only by --check-external.
+		if u.Host != base.Host || !strings.HasPrefix(u.Path, base.Path) {
+			continue
+		}
+
+		target := rp
+		if u.Path != pageURL.Path {
+			target = fetchRenderedPage(u)
+		}
+		if target.err != "" {
+			p.links = append(p.links, link{rawLink: href, URL: u, fatalError: newLinkcheckError(renderedErrorCategory, "%s", target.err)})
+			continue
+		}
+		if u.Fragment != "" && !containsString(target.ids, u.Fragment) {
+			p.links = append(p.links, link{rawLink: href, URL: u, fatalError: newLinkcheckError(renderedErrorCategory, "%s%s does not exist in the rendered page %s", anchorSeparator, u.Fragment, strings.TrimPrefix(u.Path, base.Path))})
+		}
+	}
+}
+
+// fetchRenderedPage fetches a page from the hugo server and reads its ids and links.
+func fetchRenderedPage(u *url.URL) *renderedPage {
+	key := *u
+	key.Fragment = ""
+	if rp, ok := renderedPages[key.String()]; ok {
+		return rp
+	}
+
+	rp := &renderedPage{}
+	renderedPages[key.String()] = rp
+
+	ctx, cancel := context.WithTimeout(context.Background(), *externalTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, key.String(), http.NoBody)
+	if err != nil {
+		rp.err = fmt.Sprintf("error fetching rendered page: %v", err)
+		return rp
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		rp.err = fmt.Sprintf("error fetching rendered page: %v", err)
+		return rp
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		rp.err = fmt.Sprintf("the rendered page %s returned HTTP status %d", key.Path, resp.StatusCode)
+		return rp
+	}
+
+	z := html.NewTokenizer(resp.Body)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return rp
+		case html.StartTagToken, html.SelfClosingTagToken:
+			t := z.Token()
+			for _, a := range t.Attr {
+				switch {
+				case a.Key == "id" && a.Val != "":
+					rp.ids = append(rp.ids, a.Val)
+				case a.Key == "name" && t.Data == "a" && a.Val != "":
+					rp.ids = append(rp.ids, a.Val)
+				case a.Key == "href" && t.Data == "a" && a.Val != "":
+					rp.hrefs = append(rp.hrefs, a.Val)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,120 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_checkRenderedAll(t *testing.T) {
+	g := NewWithT(t)
+
+	renderedHTML := map[string]string{
+		"/": `<html><body><h1 id="home">Home</h1><a href="/a/">A</a></body></html>`,
+		"/a/": `<html><body>
+<h1 id="a">A</h1>
+<h2 id="intro">Intro</h2>
+<a href="#intro">intro</a>
+<a href="#missing">missing on this page</a>
+<a href="/b/#b">b</a>
+<a href="/b/#generated">generated by a shortcode</a>
+<a href="/not-found/">not found</a>
+<a href="https://example.com/#anything">external</a>
+</body></html>`,
+		"/b/":  `<html><body><h1 id="b">B</h1><a name="generated"></a></body></html>`,
+		"/it/": `<html><body><h1 id="casa">Casa</h1><a href="/it/#nope">nope</a></body></html>`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := renderedHTML[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, content)
+	}))
+	defer server.Close()
+
+	cancel := setFlags("/root", "hugo", []string{"en", "it"})
+	defer cancel()
+
+	renderedBaseURLBefore := *renderedBaseURL
+	defer func() { *renderedBaseURL = renderedBaseURLBefore }()
+	*renderedBaseURL = server.URL
+
+	renderedPages = map[string]*renderedPage{}
+	defer func() { renderedPages = map[string]*renderedPage{} }()
+
+	pages = []*page{
+		{path: "/root/hugo/content/en/_index.md", isHugoPage: true, hugoLanguage: "en", hugoPath: "/_index.md"},
+		{path: "/root/hugo/content/en/a.md", isHugoPage: true, hugoLanguage: "en", hugoPath: "/a.md"},
+		{path: "/root/hugo/content/en/b.md", isHugoPage: true, hugoLanguage: "en", hugoPath: "/b.md"},
+		{path: "/root/hugo/content/en/draft.md", isHugoPage: true, hugoLanguage: "en", hugoPath: "/draft.md", frontMatter: frontMatter{Draft: true}},
+		{path: "/root/hugo/content/it/_index.md", isHugoPage: true, hugoLanguage: "it", hugoPath: "/_index.md"},
+		{path: "/root/README.md"},
+	}
+	defer func() { pages = nil }()
+
+	g.Expect(checkRenderedAll()).To(Succeed())
+
+	errs := []string{}
+	for _, p := range pages {
+		for _, l := range p.links {
+			g.Expect(l.fatalError.category).To(Equal(renderedErrorCategory))
+			errs = append(errs, fmt.Sprintf("%s: %s: %s", p.logPath(), l.rawLink, l.fatalError))
+		}
+	}
+	sort.Strings(errs)
+	g.Expect(errs).To(Equal([]string{
+		"<site>/content/en/a.md: #missing: #missing does not exist in the rendered page /a/",
+		"<site>/content/en/a.md: /not-found/: the rendered page /not-found/ returned HTTP status 404",
+		"<site>/content/it/_index.md: /it/#nope: #nope does not exist in the rendered page /it/",
+	}))
+}
+
+func Test_parseRenderedBaseURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{name: "http url", value: "http://localhost:1313", wantErr: false},
+		{name: "https url with path", value: "https://example.com/book/", wantErr: false},
+		{name: "relative url", value: "localhost:1313", wantErr: true},
+		{name: "path", value: "/book", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			renderedBaseURLBefore := *renderedBaseURL
+			defer func() { *renderedBaseURL = renderedBaseURLBefore }()
+			*renderedBaseURL = tt.value
+
+			_, err := parseRenderedBaseURL()
+			g.Expect(err != nil).To(Equal(tt.wantErr))
+		})
+	}
+}
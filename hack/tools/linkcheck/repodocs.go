@@ -0,0 +1,70 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"net/url"
+	"path"
+	"path/filepath"
+)
+
+// addRepoDocLink adds a link without scheme defined in a page outside the hugo website, e.g. the README of the repository.
+// NOTE: such pages are rendered by GitHub, so relative links are resolved from the folder of the page, and
+// absolute links from the root of the repository.
+func (p *page) addRepoDocLink(l string, lineNumber int, u *url.URL) {
+	target := p.path
+	switch {
+	case u.Path == "":
+		// The link is a fragment pointing to an anchor on the current page (e.g. #anchor).
+	case filepath.IsAbs(u.Path):
+		target = filepath.Join(*root, u.Path)
+	default:
+		target = filepath.Join(filepath.Dir(p.path), u.Path)
+	}
+
+	if !isInFolder(target, *root) {
+		p.links = append(p.links, link{rawLink: l, lineNumber: lineNumber, fatalError: newLinkcheckError(invalidLinkErrorCategory, "the link points outside of the root folder")})
+		return
+	}
+
+	debugf("%s line %d, %s: resolved to %s", p.logPath(), lineNumber, l, target)
+	p.links = append(p.links, link{rawLink: l, lineNumber: lineNumber, URL: &url.URL{Path: target, Fragment: u.Fragment}})
+}
+
+// isRepoDocFile returns true if a link from a page outside the hugo website targets a file which is not
+// a markdown page, e.g. LICENSE; such links are checked only for the file to exist.
+func isRepoDocFile(p *page, targetPath string) bool {
+	return !p.isHugoPage && filepath.Ext(targetPath) != ".md"
+}
+
+// allowsSchemelessLinks returns true if a page outside the hugo website matches one of the --allow-schemeless-paths
+// patterns, e.g. hack/*.md; patterns are matched against the path of the page relative to the root.
+func allowsSchemelessLinks(p *page) bool {
+	rel, err := filepath.Rel(*root, p.path)
+	if err != nil {
+		return false
+	}
+	for _, pattern := range *allowSchemeless {
+		if ok, _ := path.Match(pattern, filepath.ToSlash(rel)); ok {
+			return true
+		}
+	}
+	return false
+}
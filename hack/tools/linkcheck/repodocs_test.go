@@ -0,0 +1,126 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_readAllAndLinkcheckAll_repoDocs(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	write(g, filepath.Join(root, "README.md"), `# Project
+
+See the [guide](docs/guide.md), its [install section](docs/guide.md#install) or the [license](LICENSE).
+See the [missing section](docs/guide.md#missing), the [missing page](docs/missing.md) or [outside](../outside.md).
+See [below](#project) or the [website](hugo/content/en/_index.md).
+`)
+	write(g, filepath.Join(root, "docs/guide.md"), `# Guide
+
+## Install
+
+Back to the [README](../README.md#project) or the [root](/README.md).
+`)
+	touch(g, filepath.Join(root, "LICENSE"))
+	write(g, filepath.Join(root, "hugo", contentFolder, "en/_index.md"), "# Home\n")
+
+	repoDocsBefore := *repoDocs
+	defer func() { *repoDocs = repoDocsBefore }()
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	// Without --repo-docs, links without scheme are not allowed outside the hugo website.
+	*repoDocs = false
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+	g.Expect(collectErrors(root)).To(ContainElement("/README.md:3: docs/guide.md: scheme is required on links outside the hugo website"))
+
+	pages = nil
+	pagesByPath = nil
+
+	*repoDocs = true
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+	g.Expect(collectErrors(root)).To(Equal([]string{
+		"/README.md:4: ../outside.md: the link points outside of the root folder",
+		"/README.md:4: docs/guide.md#missing: #missing does exists in <root>/docs/guide.md",
+		"/README.md:4: docs/missing.md: the link resolves to /docs/missing.md which does not exist",
+	}))
+}
+
+func Test_readAllAndLinkcheckAll_allowSchemelessPaths(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	write(g, filepath.Join(root, "hack/README.md"), `# Hack
+
+See the [tools](tools.md), the [missing page](missing.md) or the [license](../LICENSE).
+`)
+	write(g, filepath.Join(root, "hack/tools.md"), "# Tools\n")
+	write(g, filepath.Join(root, "README.md"), `# Project
+
+See the [hack folder](hack/README.md).
+`)
+	touch(g, filepath.Join(root, "LICENSE"))
+	write(g, filepath.Join(root, "hugo", contentFolder, "en/_index.md"), "# Home\n")
+
+	allowSchemelessBefore := *allowSchemeless
+	defer func() { *allowSchemeless = allowSchemelessBefore }()
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	// Only pages matching --allow-schemeless-paths get relative links validated against disk.
+	*allowSchemeless = []string{"hack/*.md"}
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+	g.Expect(collectErrors(root)).To(Equal([]string{
+		"/README.md:3: hack/README.md: scheme is required on links outside the hugo website",
+		"/hack/README.md:3: missing.md: the link resolves to /hack/missing.md which does not exist",
+	}))
+}
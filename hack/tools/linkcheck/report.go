@@ -0,0 +1,398 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// groupByPage groups errors in the report by page.
+	groupByPage = "page"
+
+	// groupByError groups errors in the report by error category.
+	groupByError = "error"
+
+	// formatText prints the report as text for humans.
+	formatText = "text"
+
+	// formatGCC prints a line for each error in the path:line:col: severity: message format, like gcc does.
+	formatGCC = "gcc"
+
+	// formatSARIF prints the report as a SARIF document, for code scanning integration.
+	formatSARIF = "sarif"
+)
+
+// printReport prints the result of linkcheck for all pages.
+func printReport(w io.Writer) error {
+	// Sort pages by path and links by line number, so the report is the same no matter of the order pages and links are processed.
+	sort.SliceStable(pages, func(i, j int) bool { return pages[i].path < pages[j].path })
+	for i := range pages {
+		links := pages[i].links
+		sort.SliceStable(links, func(i, j int) bool { return links[i].lineNumber < links[j].lineNumber })
+	}
+
+	if *reportTemplate != "" {
+		return printReportTemplate(w)
+	}
+
+	if *format == formatGCC {
+		printReportGCC(w)
+		return nil
+	}
+
+	if *format == formatSARIF {
+		return printReportSARIF(w)
+	}
+
+	fmt.Fprintln(w)
+
+	// NOTE: when streaming, the result of each page is already printed by linkcheckAll, and only the warnings
+	// added afterwards are printed.
+	if currentLogLevel() > quietLogLevel && !*stream {
+		switch *groupBy {
+		case groupByPage:
+			printReportByPage(w)
+		case groupByError:
+			printReportByError(w)
+		}
+	}
+	if currentLogLevel() > quietLogLevel && *stream {
+		printUnstreamedWarnings(w)
+	}
+
+	// NOTE: the broken targets summarize all the pages, so they are printed also when streaming.
+	if currentLogLevel() > quietLogLevel && *topBrokenTargets > 0 {
+		printBrokenTargets(w, *topBrokenTargets)
+	}
+
+	sum := computeSummary()
+	fmt.Fprintf(w, "Total page processed: %d links: %d anchors: %d \n", sum.Pages, sum.Links, sum.Anchors)
+	if checksExternal() {
+		fmt.Fprintf(w, "External hosts contacted: %d\n", sum.ExternalHosts)
+	}
+	switch {
+	case *onlyExternal:
+		fmt.Fprintf(w, "Only external links checked\n")
+	case *onlyInternal:
+		fmt.Fprintf(w, "Only internal links checked\n")
+	}
+	return nil
+}
+
+// validateFormat checks --format is one of the supported formats of the report.
+func validateFormat(value string) error {
+	if value != formatText && value != formatGCC && value != formatSARIF {
+		return errors.Errorf("invalid format value %q, must be one of %s, %s, %s", value, formatText, formatGCC, formatSARIF)
+	}
+	return nil
+}
+
+// validateGroupBy checks --group-by is one of the supported ways of grouping errors in the report.
+func validateGroupBy(value string) error {
+	if value != groupByPage && value != groupByError {
+		return errors.Errorf("invalid group-by value %q, must be one of %s, %s", value, groupByPage, groupByError)
+	}
+	return nil
+}
+
+// summary defines the totals reported at the end of linkcheck.
+type summary struct {
+	Pages         int `json:"pages"`
+	Links         int `json:"links"`
+	Anchors       int `json:"anchors"`
+	Errors        int `json:"errors"`
+	ExternalHosts int `json:"externalHosts"`
+}
+
+// computeSummary computes the totals for all pages.
+// NOTE: external hosts are counted only when external links are checked, because otherwise they are not contacted.
+func computeSummary() summary {
+	sum := summary{Pages: len(pages)}
+	hosts := map[string]bool{}
+	for i := range pages {
+		p := pages[i]
+		sum.Anchors += len(p.anchors)
+		sum.Links += len(p.links)
+		if p.fatalError != nil && severityFor(p.fatalError.category) == errorSeverity {
+			sum.Errors++
+		}
+		for _, l := range p.links {
+			if l.fatalError != nil && severityFor(l.fatalError.category) == errorSeverity {
+				sum.Errors++
+			}
+			if checksExternal() && l.URL != nil && isExternalLink(l.URL) {
+				hosts[strings.ToLower(l.URL.Host)] = true
+			}
+		}
+	}
+	sum.ExternalHosts = len(hosts)
+	return sum
+}
+
+// writeSummaryJSON writes the totals for all pages to a JSON file.
+func writeSummaryJSON(path string) error {
+	content, err := json.MarshalIndent(computeSummary(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(content, '\n'), 0600)
+}
+
+// printReportByPage prints the result of linkcheck grouping errors by page.
+func printReportByPage(w io.Writer) {
+	for i := range pages {
+		printPage(w, pages[i])
+	}
+}
+
+// printPage prints the result of linkcheck for a page.
+// NOTE: pages without errors or warnings are printed only when verbose.
+func printPage(w io.Writer, p *page) {
+	s := ""
+	prints := false
+	s += fmt.Sprintf("PAGE: %s\n", p.logPath())
+	switch {
+	case p.fatalError != nil && severityFor(p.fatalError.category) != ignoreSeverity:
+		prints = true
+		s += fmt.Sprintln()
+		s += fmt.Sprintf(" - %s: %s\n", severityLabel(p.fatalError.category), p.fatalError)
+	default:
+		t := ""
+		errorst := 0
+		shown := map[warning]bool{}
+		for _, o := range groupLinks(p.links) {
+			l := o.link
+			switch {
+			case l.fatalError != nil && severityFor(l.fatalError.category) == errorSeverity:
+				prints = true
+				errorst += len(o.lineNumbers)
+				t += fmt.Sprintf(" - ERROR: %s, %s: %s%s\n", o.lines(), l.rawLink, l.fatalError, o.count())
+			case l.fatalError != nil && severityFor(l.fatalError.category) == warningSeverity:
+				prints = true
+				t += fmt.Sprintf(" - WARNING: %s, %s: %s%s\n", o.lines(), l.rawLink, l.fatalError, o.count())
+			case l.fatalError != nil:
+				// Errors in ignored categories are not reported.
+			default:
+				if currentLogLevel() >= verboseLogLevel {
+					// Links without errors but with warnings, e.g. a fragment hint, are reported as WARN instead of OK,
+					// with the reason of each warning; those warnings are not repeated below.
+					warnings := linkWarnings(p, l)
+					if len(warnings) == 0 {
+						t += fmt.Sprintf(" - OK: line %d, %s\n", l.lineNumber, l.rawLink)
+					}
+					for _, lw := range warnings {
+						prints = true
+						shown[lw] = true
+						t += fmt.Sprintf(" - WARN: %s\n", lw)
+					}
+				}
+			}
+		}
+		for _, pw := range p.warnings {
+			if shown[pw] {
+				continue
+			}
+			prints = true
+			t += fmt.Sprintf(" - WARNING: %s\n", pw)
+		}
+		switch errorst {
+		case 0:
+			s += fmt.Sprintf("      %d links, no errors\n\n", len(p.links))
+		default:
+			s += fmt.Sprintf("      %d links, %d errors\n\n", len(p.links), errorst)
+		}
+		if t != "" {
+			s += fmt.Sprintf("%s\n", t)
+		}
+	}
+
+	if currentLogLevel() >= verboseLogLevel || prints {
+		fmt.Fprint(w, s)
+	}
+}
+
+// printUnstreamedWarnings prints the warnings added to pages after they have been streamed, e.g. by
+// --unused-anchors, which requires all the pages to be checked.
+func printUnstreamedWarnings(w io.Writer) {
+	for i := range pages {
+		p := pages[i]
+		warnings := p.unstreamedWarnings()
+		if p.fatalError != nil || len(warnings) == 0 {
+			continue
+		}
+		s := fmt.Sprintf("PAGE: %s\n\n", p.logPath())
+		for _, pw := range warnings {
+			s += fmt.Sprintf(" - WARNING: %s\n", pw)
+		}
+		fmt.Fprintln(w, s)
+	}
+}
+
+// unstreamedWarnings returns the warnings added to a page after it has been streamed.
+func (p *page) unstreamedWarnings() []warning {
+	if p.streamedWarnings >= len(p.warnings) {
+		return nil
+	}
+	return p.warnings[p.streamedWarnings:]
+}
+
+// linkWarnings returns the page warnings about a link, if any.
+func linkWarnings(p *page, l link) []warning {
+	warnings := []warning{}
+	for _, pw := range p.warnings {
+		if pw.lineNumber == l.lineNumber && pw.rawLink == l.rawLink && pw.rawLink != "" {
+			warnings = append(warnings, pw)
+		}
+	}
+	return warnings
+}
+
+// severityLabel returns the label used when printing errors in a category.
+func severityLabel(c errorCategory) string {
+	if severityFor(c) == warningSeverity {
+		return "WARNING"
+	}
+	return "ERROR"
+}
+
+// hasErrors returns true if any page or link has an error with error severity.
+func hasErrors() bool {
+	for i := range pages {
+		if pages[i].fatalError != nil && severityFor(pages[i].fatalError.category) == errorSeverity {
+			return true
+		}
+		for _, l := range pages[i].links {
+			if l.fatalError != nil && severityFor(l.fatalError.category) == errorSeverity {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// printReportByError prints the result of linkcheck grouping errors by error category.
+func printReportByError(w io.Writer) {
+	errorsByCategory := map[errorCategory][]string{}
+	occurrencesByCategory := map[errorCategory]int{}
+	warnings := []string{}
+	for i := range pages {
+		p := pages[i]
+
+		for _, pw := range p.warnings {
+			warnings = append(warnings, fmt.Sprintf(" - WARNING: %s: %s\n", p.logPath(), pw))
+		}
+		if p.fatalError != nil {
+			errorsByCategory[p.fatalError.category] = append(errorsByCategory[p.fatalError.category], fmt.Sprintf(" - %s: %s: %s\n", severityLabel(p.fatalError.category), p.logPath(), p.fatalError))
+			occurrencesByCategory[p.fatalError.category]++
+			continue
+		}
+		for _, o := range groupLinks(p.links) {
+			if l := o.link; l.fatalError != nil {
+				errorsByCategory[l.fatalError.category] = append(errorsByCategory[l.fatalError.category], fmt.Sprintf(" - %s: %s %s, %s: %s%s\n", severityLabel(l.fatalError.category), p.logPath(), o.lines(), l.rawLink, l.fatalError, o.count()))
+				occurrencesByCategory[l.fatalError.category] += len(o.lineNumbers)
+			}
+		}
+	}
+
+	for _, c := range errorCategories {
+		errs := errorsByCategory[c]
+		if len(errs) == 0 || severityFor(c) == ignoreSeverity {
+			continue
+		}
+
+		s := fmt.Sprintf("CATEGORY: %s\n", c)
+		switch severityFor(c) {
+		case warningSeverity:
+			s += fmt.Sprintf("      %d warnings\n\n", occurrencesByCategory[c])
+		default:
+			s += fmt.Sprintf("      %d errors\n\n", occurrencesByCategory[c])
+		}
+		for _, e := range errs {
+			s += e
+		}
+		fmt.Fprintf(w, "%s\n", s)
+	}
+	if len(warnings) > 0 {
+		s := "WARNINGS\n"
+		s += fmt.Sprintf("      %d warnings\n\n", len(warnings))
+		for _, warning := range warnings {
+			s += warning
+		}
+		fmt.Fprintf(w, "%s\n", s)
+	}
+}
+
+// linkOccurrences defines a link in a page, together with the lines of all the identical links with the same error.
+type linkOccurrences struct {
+	link
+
+	// lineNumbers where the link has been found.
+	lineNumbers []int
+}
+
+// groupLinks groups links with the same raw link and error, so errors repeated in a page are reported only once.
+// NOTE: links without errors are never grouped; groups are returned in order of first occurrence.
+func groupLinks(links []link) []*linkOccurrences {
+	groups := []*linkOccurrences{}
+	groupsByError := map[string]*linkOccurrences{}
+	for _, l := range links {
+		if l.fatalError == nil {
+			groups = append(groups, &linkOccurrences{link: l, lineNumbers: []int{l.lineNumber}})
+			continue
+		}
+		key := fmt.Sprintf("%s\n%s", l.rawLink, l.fatalError)
+		if o, ok := groupsByError[key]; ok {
+			o.lineNumbers = append(o.lineNumbers, l.lineNumber)
+			continue
+		}
+		o := &linkOccurrences{link: l, lineNumbers: []int{l.lineNumber}}
+		groupsByError[key] = o
+		groups = append(groups, o)
+	}
+	return groups
+}
+
+// lines returns the lines where the link has been found, e.g. line 3 or lines 3, 5, 7.
+func (o *linkOccurrences) lines() string {
+	if len(o.lineNumbers) == 1 {
+		return fmt.Sprintf("line %d", o.lineNumbers[0])
+	}
+	lines := []string{}
+	for _, n := range o.lineNumbers {
+		lines = append(lines, fmt.Sprintf("%d", n))
+	}
+	return fmt.Sprintf("lines %s", strings.Join(lines, ", "))
+}
+
+// count returns the number of occurrences of the link, if the link has been found more than once.
+func (o *linkOccurrences) count() string {
+	if len(o.lineNumbers) == 1 {
+		return ""
+	}
+	return fmt.Sprintf(" (%d occurrences)", len(o.lineNumbers))
+}
@@ -0,0 +1,773 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_printReport(t *testing.T) {
+	cancel := setFlags("/root", "hugo", []string{"en"})
+	defer cancel()
+
+	tests := []struct {
+		name    string
+		groupBy string
+		want    string
+	}{
+		{
+			name:    "group by page",
+			groupBy: groupByPage,
+			want: `
+PAGE: <site>/content/en/a.md
+      3 links, 2 errors
+
+ - ERROR: line 1, missing: the link resolves to /hugo/content/en/missing.md which does not exist
+ - ERROR: line 2, #invalid: #invalid does exists in <site>/content/en/a.md
+
+PAGE: <site>/content/en/b.md
+      1 links, 1 errors
+
+ - ERROR: line 3, a.md: links must not have .md extension, use "a" instead
+
+PAGE: <site>/content/en/c.md
+
+ - ERROR: Error reading content: permission denied
+Total page processed: 3 links: 4 anchors: 0 
+`,
+		},
+		{
+			name:    "group by error",
+			groupBy: groupByError,
+			want: `
+CATEGORY: page error
+      1 errors
+
+ - ERROR: <site>/content/en/c.md: Error reading content: permission denied
+
+CATEGORY: forbidden link
+      1 errors
+
+ - ERROR: <site>/content/en/b.md line 3, a.md: links must not have .md extension, use "a" instead
+
+CATEGORY: missing file
+      1 errors
+
+ - ERROR: <site>/content/en/a.md line 1, missing: the link resolves to /hugo/content/en/missing.md which does not exist
+
+CATEGORY: missing anchor
+      1 errors
+
+ - ERROR: <site>/content/en/a.md line 2, #invalid: #invalid does exists in <site>/content/en/a.md
+
+Total page processed: 3 links: 4 anchors: 0 
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			groupByBefore := *groupBy
+			defer func() { *groupBy = groupByBefore }()
+			*groupBy = tt.groupBy
+
+			pages = []*page{
+				{
+					path:         "/root/hugo/content/en/c.md",
+					isHugoPage:   true,
+					hugoLanguage: "en",
+					hugoPath:     "/c.md",
+					fatalError:   newLinkcheckError(pageErrorCategory, "Error reading content: permission denied"),
+				},
+				{
+					path:         "/root/hugo/content/en/b.md",
+					isHugoPage:   true,
+					hugoLanguage: "en",
+					hugoPath:     "/b.md",
+					links: []link{
+						{rawLink: "a.md", lineNumber: 3, fatalError: newLinkcheckError(forbiddenLinkErrorCategory, "links must not have .md extension, use \"a\" instead")},
+					},
+				},
+				{
+					path:         "/root/hugo/content/en/a.md",
+					isHugoPage:   true,
+					hugoLanguage: "en",
+					hugoPath:     "/a.md",
+					links: []link{
+						{rawLink: "missing", lineNumber: 1, fatalError: newLinkcheckError(missingFileErrorCategory, "the link resolves to /hugo/content/en/missing.md which does not exist")},
+						{rawLink: "#invalid", lineNumber: 2, fatalError: newLinkcheckError(missingAnchorErrorCategory, "#invalid does exists in <site>/content/en/a.md")},
+						{rawLink: "b", lineNumber: 3},
+					},
+				},
+			}
+			defer func() { pages = nil }()
+
+			var out bytes.Buffer
+			g.Expect(printReport(&out)).To(Succeed())
+			g.Expect(out.String()).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_printReport_logLevel(t *testing.T) {
+	cancel := setFlags("/root", "hugo", []string{"en"})
+	defer cancel()
+
+	tests := []struct {
+		name         string
+		logLevelName string
+		want         string
+	}{
+		{
+			name:         "quiet prints only the summary",
+			logLevelName: "quiet",
+			want: `
+Total page processed: 2 links: 2 anchors: 0 
+`,
+		},
+		{
+			name:         "normal prints errors",
+			logLevelName: "normal",
+			want: `
+PAGE: <site>/content/en/a.md
+      2 links, 1 errors
+
+ - ERROR: line 1, missing: the link resolves to /hugo/content/en/missing.md which does not exist
+
+Total page processed: 2 links: 2 anchors: 0 
+`,
+		},
+		{
+			name:         "verbose prints errors and OK links",
+			logLevelName: "verbose",
+			want: `
+PAGE: <site>/content/en/a.md
+      2 links, 1 errors
+
+ - ERROR: line 1, missing: the link resolves to /hugo/content/en/missing.md which does not exist
+ - OK: line 2, b
+
+PAGE: <site>/content/en/b.md
+      0 links, no errors
+
+Total page processed: 2 links: 2 anchors: 0 
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			cancel := setLogLevel(tt.logLevelName, 0)
+			defer cancel()
+
+			pages = []*page{
+				{
+					path:         "/root/hugo/content/en/b.md",
+					isHugoPage:   true,
+					hugoLanguage: "en",
+					hugoPath:     "/b.md",
+				},
+				{
+					path:         "/root/hugo/content/en/a.md",
+					isHugoPage:   true,
+					hugoLanguage: "en",
+					hugoPath:     "/a.md",
+					links: []link{
+						{rawLink: "missing", lineNumber: 1, fatalError: newLinkcheckError(missingFileErrorCategory, "the link resolves to /hugo/content/en/missing.md which does not exist")},
+						{rawLink: "b", lineNumber: 2},
+					},
+				},
+			}
+			defer func() { pages = nil }()
+
+			var out bytes.Buffer
+			g.Expect(printReport(&out)).To(Succeed())
+			g.Expect(out.String()).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_printReport_linkStates(t *testing.T) {
+	cancel := setFlags("/root", "hugo", []string{"en"})
+	defer cancel()
+
+	tests := []struct {
+		name         string
+		logLevelName string
+		want         string
+	}{
+		{
+			name:         "normal prints errors and warnings",
+			logLevelName: "normal",
+			want: `
+PAGE: <site>/content/en/a.md
+      3 links, 1 errors
+
+ - ERROR: line 1, missing: the link resolves to /hugo/content/en/missing.md which does not exist
+ - WARNING: line 2, b#My Section: fragments should be lowercase with hyphens instead of spaces, e.g. my-section
+ - WARNING: page is not linked from the toc page <site>/content/en/SUMMARY.md
+ - WARNING: line 3, b: the link works on the website but not in the source repository
+ - WARNING: line 4, footnote [^1] is not defined
+
+Total page processed: 1 links: 3 anchors: 0 
+`,
+		},
+		{
+			name:         "verbose prints ERROR, WARN and OK links",
+			logLevelName: "verbose",
+			want: `
+PAGE: <site>/content/en/a.md
+      3 links, 1 errors
+
+ - ERROR: line 1, missing: the link resolves to /hugo/content/en/missing.md which does not exist
+ - WARN: line 2, b#My Section: fragments should be lowercase with hyphens instead of spaces, e.g. my-section
+ - WARN: line 3, b: the link works on the website but not in the source repository
+ - WARNING: page is not linked from the toc page <site>/content/en/SUMMARY.md
+ - WARNING: line 4, footnote [^1] is not defined
+
+Total page processed: 1 links: 3 anchors: 0 
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			cancel := setLogLevel(tt.logLevelName, 0)
+			defer cancel()
+
+			pages = []*page{
+				{
+					path:         "/root/hugo/content/en/a.md",
+					isHugoPage:   true,
+					hugoLanguage: "en",
+					hugoPath:     "/a.md",
+					links: []link{
+						{rawLink: "missing", lineNumber: 1, fatalError: newLinkcheckError(missingFileErrorCategory, "the link resolves to /hugo/content/en/missing.md which does not exist")},
+						{rawLink: "b#My Section", lineNumber: 2},
+						{rawLink: "b", lineNumber: 3},
+					},
+					warnings: []warning{
+						{lineNumber: 2, rawLink: "b#My Section", message: "fragments should be lowercase with hyphens instead of spaces, e.g. my-section"},
+						{message: "page is not linked from the toc page <site>/content/en/SUMMARY.md"},
+						{lineNumber: 3, rawLink: "b", message: "the link works on the website but not in the source repository"},
+						{lineNumber: 4, message: "footnote [^1] is not defined"},
+					},
+				},
+			}
+			defer func() { pages = nil }()
+
+			var out bytes.Buffer
+			g.Expect(printReport(&out)).To(Succeed())
+			g.Expect(out.String()).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_printReport_warnings(t *testing.T) {
+	cancel := setFlags("/root", "hugo", []string{"en"})
+	defer cancel()
+
+	tests := []struct {
+		name    string
+		groupBy string
+		want    string
+	}{
+		{
+			name:    "group by page",
+			groupBy: groupByPage,
+			want: `
+PAGE: <site>/content/en/a.md
+      1 links, no errors
+
+ - WARNING: alias /old is declared by more than one page
+
+Total page processed: 1 links: 1 anchors: 0 
+`,
+		},
+		{
+			name:    "group by error",
+			groupBy: groupByError,
+			want: `
+WARNINGS
+      1 warnings
+
+ - WARNING: <site>/content/en/a.md: alias /old is declared by more than one page
+
+Total page processed: 1 links: 1 anchors: 0 
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			groupByBefore := *groupBy
+			defer func() { *groupBy = groupByBefore }()
+			*groupBy = tt.groupBy
+
+			pages = []*page{
+				{
+					path:         "/root/hugo/content/en/a.md",
+					isHugoPage:   true,
+					hugoLanguage: "en",
+					hugoPath:     "/a.md",
+					links: []link{
+						{rawLink: "b", lineNumber: 1},
+					},
+					warnings: []warning{{message: "alias /old is declared by more than one page"}},
+				},
+			}
+			defer func() { pages = nil }()
+
+			var out bytes.Buffer
+			g.Expect(printReport(&out)).To(Succeed())
+			g.Expect(out.String()).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_linkcheckAll_stream(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	streamBefore := *stream
+	defer func() { *stream = streamBefore }()
+	*stream = true
+
+	contentDir := filepath.Join(root, "hugo", contentFolder)
+	write(g, filepath.Join(contentDir, "en/a.md"), "# A\n\nSee [b](b) and [missing](missing).\n")
+	write(g, filepath.Join(contentDir, "en/b.md"), "# B\n\nSee [a](a).\n")
+	write(g, filepath.Join(contentDir, "en/c.md"), "# C\n\nSee [missing](#missing).\n")
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	g.Expect(readAll()).To(Succeed())
+
+	var out bytes.Buffer
+	g.Expect(linkcheckAll(context.Background(), &out)).To(Succeed())
+
+	// Page results are printed while checking links, before the summary.
+	g.Expect(out.String()).To(Equal(`PAGE: <site>/content/en/a.md
+      2 links, 1 errors
+
+ - ERROR: line 3, missing: the link resolves to /hugo/content/en/missing.md which does not exist
+
+PAGE: <site>/content/en/c.md
+      1 links, 1 errors
+
+ - ERROR: line 3, #missing: #missing does exists in <site>/content/en/c.md
+
+`))
+
+	g.Expect(printReport(&out)).To(Succeed())
+	g.Expect(out.String()).To(HaveSuffix(`
+
+Total page processed: 3 links: 4 anchors: 3 
+`))
+	g.Expect(bytes.Count(out.Bytes(), []byte("PAGE:"))).To(Equal(2))
+	g.Expect(hasErrors()).To(BeTrue())
+}
+
+func Test_printReport_quiet(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	resetLogLevel := setLogLevel("verbose", 1)
+	defer resetLogLevel()
+
+	quietBefore := *quiet
+	streamBefore := *stream
+	defer func() {
+		*quiet = quietBefore
+		*stream = streamBefore
+	}()
+	*quiet = true
+	*stream = true
+	g.Expect(loadLogLevel()).To(Succeed())
+
+	contentDir := filepath.Join(root, "hugo", contentFolder)
+	write(g, filepath.Join(contentDir, "en/_index.md"), "# Home\n\nSee [a](a).\n")
+	write(g, filepath.Join(contentDir, "en/a.md"), "# A\n\nSee [home](/).\n")
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	summaryPath := filepath.Join(root, "summary.json")
+
+	var out bytes.Buffer
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), &out)).To(Succeed())
+	g.Expect(printReport(&out)).To(Succeed())
+	g.Expect(writeSummaryJSON(summaryPath)).To(Succeed())
+
+	// On a clean run, only the totals are printed, even if verbose and streaming; the JSON summary is still written.
+	g.Expect(out.String()).To(Equal("\nTotal page processed: 2 links: 2 anchors: 2 \n"))
+	g.Expect(hasErrors()).To(BeFalse())
+	g.Expect(summaryPath).To(BeAnExistingFile())
+}
+
+func Test_printReport_severity(t *testing.T) {
+	cancel := setFlags("/root", "hugo", []string{"en"})
+	defer cancel()
+
+	tests := []struct {
+		name       string
+		groupBy    string
+		severities []string
+		want       string
+		wantErrors bool
+	}{
+		{
+			name:       "group by page, missing anchor downgraded to warning",
+			groupBy:    groupByPage,
+			severities: []string{"missing-anchor=warning"},
+			want: `
+PAGE: <site>/content/en/a.md
+      2 links, no errors
+
+ - WARNING: line 2, b#c: #c does exists in <site>/content/en/b.md
+
+Total page processed: 1 links: 2 anchors: 0 
+`,
+			wantErrors: false,
+		},
+		{
+			name:       "group by error, missing anchor downgraded to warning",
+			groupBy:    groupByError,
+			severities: []string{"missing-anchor=warning"},
+			want: `
+CATEGORY: missing anchor
+      1 warnings
+
+ - WARNING: <site>/content/en/a.md line 2, b#c: #c does exists in <site>/content/en/b.md
+
+Total page processed: 1 links: 2 anchors: 0 
+`,
+			wantErrors: false,
+		},
+		{
+			name:       "missing anchor ignored",
+			groupBy:    groupByPage,
+			severities: []string{"missing-anchor=ignore"},
+			want: `
+Total page processed: 1 links: 2 anchors: 0 
+`,
+			wantErrors: false,
+		},
+		{
+			name:       "other categories are not affected",
+			groupBy:    groupByPage,
+			severities: []string{"external=warning"},
+			want: `
+PAGE: <site>/content/en/a.md
+      2 links, 1 errors
+
+ - ERROR: line 2, b#c: #c does exists in <site>/content/en/b.md
+
+Total page processed: 1 links: 2 anchors: 0 
+`,
+			wantErrors: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			groupByBefore := *groupBy
+			defer func() { *groupBy = groupByBefore }()
+			*groupBy = tt.groupBy
+
+			resetSeverities := setSeverities(tt.severities)
+			defer resetSeverities()
+
+			pages = []*page{
+				{
+					path:         "/root/hugo/content/en/a.md",
+					isHugoPage:   true,
+					hugoLanguage: "en",
+					hugoPath:     "/a.md",
+					links: []link{
+						{rawLink: "b", lineNumber: 1},
+						{rawLink: "b#c", lineNumber: 2, fatalError: newLinkcheckError(missingAnchorErrorCategory, "#c does exists in <site>/content/en/b.md")},
+					},
+				},
+			}
+			defer func() { pages = nil }()
+
+			var out bytes.Buffer
+			g.Expect(printReport(&out)).To(Succeed())
+			g.Expect(out.String()).To(Equal(tt.want))
+			g.Expect(hasErrors()).To(Equal(tt.wantErrors))
+		})
+	}
+}
+
+func Test_printReport_repeatedErrors(t *testing.T) {
+	cancel := setFlags("/root", "hugo", []string{"en"})
+	defer cancel()
+
+	tests := []struct {
+		name    string
+		groupBy string
+		want    string
+	}{
+		{
+			name:    "group by page",
+			groupBy: groupByPage,
+			want: `
+PAGE: <site>/content/en/a.md
+      5 links, 4 errors
+
+ - ERROR: lines 2, 5, 9, b#c: #c does exists in <site>/content/en/b.md (3 occurrences)
+ - ERROR: line 7, d: the link resolves to /hugo/content/en/d.md which does not exist
+
+Total page processed: 1 links: 5 anchors: 0 
+`,
+		},
+		{
+			name:    "group by error",
+			groupBy: groupByError,
+			want: `
+CATEGORY: missing file
+      1 errors
+
+ - ERROR: <site>/content/en/a.md line 7, d: the link resolves to /hugo/content/en/d.md which does not exist
+
+CATEGORY: missing anchor
+      3 errors
+
+ - ERROR: <site>/content/en/a.md lines 2, 5, 9, b#c: #c does exists in <site>/content/en/b.md (3 occurrences)
+
+Total page processed: 1 links: 5 anchors: 0 
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			groupByBefore := *groupBy
+			defer func() { *groupBy = groupByBefore }()
+			*groupBy = tt.groupBy
+
+			missingAnchor := newLinkcheckError(missingAnchorErrorCategory, "#c does exists in <site>/content/en/b.md")
+			pages = []*page{
+				{
+					path:         "/root/hugo/content/en/a.md",
+					isHugoPage:   true,
+					hugoLanguage: "en",
+					hugoPath:     "/a.md",
+					links: []link{
+						{rawLink: "b", lineNumber: 1},
+						{rawLink: "b#c", lineNumber: 2, fatalError: missingAnchor},
+						{rawLink: "b#c", lineNumber: 5, fatalError: missingAnchor},
+						{rawLink: "d", lineNumber: 7, fatalError: newLinkcheckError(missingFileErrorCategory, "the link resolves to /hugo/content/en/d.md which does not exist")},
+						{rawLink: "b#c", lineNumber: 9, fatalError: missingAnchor},
+					},
+				},
+			}
+			defer func() { pages = nil }()
+
+			var out bytes.Buffer
+			g.Expect(printReport(&out)).To(Succeed())
+			g.Expect(out.String()).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_printReport_workers(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	contentDir := filepath.Join(root, "hugo", contentFolder)
+	write(g, filepath.Join(contentDir, "en/_index.md"), "# Home\n")
+	for i := 0; i < 30; i++ {
+		write(g, filepath.Join(contentDir, fmt.Sprintf("en/page%02d.md", i)), fmt.Sprintf(`# Page %[1]d
+
+See [next](page%02[2]d#page-%[2]d), [broken](page%02[2]d#broken) and [missing](missing%[1]d).
+![image](missing%[1]d.png) and [md](page%02[2]d.md).
+`, i, (i+1)%30))
+	}
+
+	run := func(w int, groupByValue string) string {
+		workersBefore := *workers
+		defer func() { *workers = workersBefore }()
+		*workers = w
+
+		groupByBefore := *groupBy
+		defer func() { *groupBy = groupByBefore }()
+		*groupBy = groupByValue
+
+		pages = nil
+		pagesByPath = nil
+		defer func() {
+			pages = nil
+			pagesByPath = nil
+		}()
+
+		g.Expect(readAll()).To(Succeed())
+		g.Expect(linkcheckAll(context.Background(), &bytes.Buffer{})).To(Succeed())
+
+		var out bytes.Buffer
+		g.Expect(printReport(&out)).To(Succeed())
+		return out.String()
+	}
+
+	for _, groupByValue := range []string{groupByPage, groupByError} {
+		want := run(1, groupByValue)
+		g.Expect(want).To(ContainSubstring("ERROR"))
+		g.Expect(run(8, groupByValue)).To(Equal(want))
+		g.Expect(run(3, groupByValue)).To(Equal(want))
+	}
+
+	workersBefore := *workers
+	defer func() { *workers = workersBefore }()
+	*workers = 0
+	g.Expect(linkcheckAll(context.Background(), &bytes.Buffer{})).ToNot(Succeed())
+}
+
+func Test_hasErrors(t *testing.T) {
+	g := NewWithT(t)
+
+	defer func() { pages = nil }()
+
+	pages = []*page{{path: "/root/a.md", links: []link{{rawLink: "https://example.com"}}}}
+	g.Expect(hasErrors()).To(BeFalse())
+
+	pages = []*page{{path: "/root/a.md", links: []link{{rawLink: "a.md", fatalError: newLinkcheckError(pageErrorCategory, "scheme is required on links outside the hugo website")}}}}
+	g.Expect(hasErrors()).To(BeTrue())
+
+	pages = []*page{{path: "/root/a.md", fatalError: newLinkcheckError(pageErrorCategory, "Error reading content: permission denied")}}
+	g.Expect(hasErrors()).To(BeTrue())
+}
+
+func Test_validateFormat(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(validateFormat(formatText)).To(Succeed())
+	g.Expect(validateFormat(formatGCC)).To(Succeed())
+	g.Expect(validateFormat(formatSARIF)).To(Succeed())
+	g.Expect(validateFormat("json")).To(MatchError(`invalid format value "json", must be one of text, gcc, sarif`))
+}
+
+func Test_validateGroupBy(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(validateGroupBy(groupByPage)).To(Succeed())
+	g.Expect(validateGroupBy(groupByError)).To(Succeed())
+	g.Expect(validateGroupBy("category")).To(MatchError(`invalid group-by value "category", must be one of page, error`))
+}
+
+func Test_computeSummary(t *testing.T) {
+	g := NewWithT(t)
+
+	checkExternalBefore := *checkExternal
+	defer func() { *checkExternal = checkExternalBefore }()
+
+	pages = []*page{
+		{
+			path:    "/root/a.md",
+			anchors: []string{"a"},
+			links: []link{
+				{rawLink: "https://example.com/a", URL: mustParseUrl("https://example.com/a")},
+				{rawLink: "https://example.com/b", URL: mustParseUrl("https://example.com/b")},
+				{rawLink: "https://EXAMPLE.com/c", URL: mustParseUrl("https://EXAMPLE.com/c")},
+				{rawLink: "http://another.com", URL: mustParseUrl("http://another.com")},
+				{rawLink: "a.md", fatalError: newLinkcheckError(pageErrorCategory, "scheme is required on links outside the hugo website")},
+			},
+		},
+		{
+			path:    "/root/b.md",
+			anchors: []string{"b", "c"},
+			links: []link{
+				{rawLink: "https://docs.example.com", URL: mustParseUrl("https://docs.example.com")},
+			},
+		},
+		{
+			path:       "/root/c.md",
+			fatalError: newLinkcheckError(pageErrorCategory, "Error reading content: permission denied"),
+		},
+	}
+	defer func() { pages = nil }()
+
+	*checkExternal = false
+	g.Expect(computeSummary()).To(Equal(summary{Pages: 3, Links: 6, Anchors: 3, Errors: 2, ExternalHosts: 0}))
+
+	*checkExternal = true
+	g.Expect(computeSummary()).To(Equal(summary{Pages: 3, Links: 6, Anchors: 3, Errors: 2, ExternalHosts: 3}))
+
+	dir, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "summary.json")
+	g.Expect(writeSummaryJSON(path)).To(Succeed())
+	content, err := os.ReadFile(path)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(content)).To(Equal(`{
+  "pages": 3,
+  "links": 6,
+  "anchors": 3,
+  "errors": 2,
+  "externalHosts": 3
+}
+`))
+
+	var out bytes.Buffer
+	g.Expect(printReport(&out)).To(Succeed())
+	g.Expect(out.String()).To(HaveSuffix("External hosts contacted: 3\n"))
+}
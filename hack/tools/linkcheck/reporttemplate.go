@@ -0,0 +1,166 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"io"
+	"path/filepath"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// defaultReportTemplateName is the --report-template value selecting the default report template.
+const defaultReportTemplateName = "default"
+
+// defaultReportTemplate prints a line for each error or warning, followed by the totals.
+// NOTE: this is also a good starting point for writing custom report templates.
+const defaultReportTemplate = `
+{{- range $page := .Pages }}
+{{- if and .Error (ne .Severity "ignore") }}
+{{ .Severity }}: {{ .Path }}: {{ .Error }}
+{{- end }}
+{{- range .Links }}
+{{- if and .Error (ne .Severity "ignore") }}
+{{ .Severity }}: {{ $page.Path }} line {{ .Line }}, {{ .Link }}: {{ .Error }}
+{{- end }}
+{{- end }}
+{{- range .Warnings }}
+WARNING: {{ $page.Path }}: {{ . }}
+{{- end }}
+{{- end }}
+Total page processed: {{ .Summary.Pages }} links: {{ .Summary.Links }} anchors: {{ .Summary.Anchors }} errors: {{ .Summary.Errors }}
+`
+
+// reportData defines the data model available to report templates.
+type reportData struct {
+	// Pages processed by linkcheck, sorted by path.
+	Pages []reportPage
+
+	// Summary contains the totals for all pages.
+	Summary summary
+}
+
+// reportPage defines a page in the data model available to report templates.
+type reportPage struct {
+	// Path of the page, e.g. <site>/content/en/docs/page.md.
+	Path string
+
+	// Error preventing the page from being checked, if any.
+	Error string
+
+	// Severity of Error, one of error, warning, ignore.
+	Severity string
+
+	// Links defined in the page, sorted by line.
+	Links []reportLink
+
+	// Warnings for the page.
+	Warnings []string
+}
+
+// reportLink defines a link in the data model available to report templates.
+type reportLink struct {
+	// Line where the link is defined.
+	Line int
+
+	// Link as defined in the page.
+	Link string
+
+	// Error for the link, if any.
+	Error string
+
+	// Category of Error, e.g. missing anchor.
+	Category string
+
+	// Severity of Error, one of error, warning, ignore.
+	Severity string
+}
+
+// newReportData returns the data model available to report templates.
+func newReportData() reportData {
+	data := reportData{Summary: computeSummary()}
+	for _, p := range pages {
+		rp := reportPage{Path: p.logPath()}
+		for _, pw := range p.warnings {
+			rp.Warnings = append(rp.Warnings, pw.String())
+		}
+		if p.fatalError != nil {
+			rp.Error = p.fatalError.message
+			rp.Severity = string(severityFor(p.fatalError.category))
+		}
+		for _, l := range p.links {
+			rl := reportLink{Line: l.lineNumber, Link: l.rawLink}
+			if l.fatalError != nil {
+				rl.Error = l.fatalError.message
+				rl.Category = string(l.fatalError.category)
+				rl.Severity = string(severityFor(l.fatalError.category))
+			}
+			rp.Links = append(rp.Links, rl)
+		}
+		data.Pages = append(data.Pages, rp)
+	}
+	return data
+}
+
+// reportTmpl is the --report-template, if any, read and parsed at startup by loadReportTemplate.
+var reportTmpl *template.Template
+
+// loadReportTemplate reads and parses the --report-template, if any, so errors are reported before checking links.
+// NOTE: --report-template is converted to an absolute path, because paths are resolved from the root of fileSystem.
+func loadReportTemplate() error {
+	reportTmpl = nil
+	if *reportTemplate == "" {
+		return nil
+	}
+
+	text := defaultReportTemplate
+	if *reportTemplate != defaultReportTemplateName {
+		path, err := filepath.Abs(*reportTemplate)
+		if err != nil {
+			return errors.Wrapf(err, "failed to convert report template to an absolute path")
+		}
+		*reportTemplate = path
+
+		content, err := readFile(path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read report template")
+		}
+		text = string(content)
+	}
+
+	t, err := template.New("report").Parse(text)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse report template")
+	}
+	reportTmpl = t
+	return nil
+}
+
+// printReportTemplate prints the result of linkcheck for all pages using the --report-template loaded at startup.
+func printReportTemplate(w io.Writer) error {
+	if reportTmpl == nil {
+		return errors.New("report template not loaded")
+	}
+	if err := reportTmpl.Execute(w, newReportData()); err != nil {
+		return errors.Wrapf(err, "failed to render report template")
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_printReport_reportTemplate(t *testing.T) {
+	root, err := os.MkdirTemp("", "linkcheck")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "default template",
+			template: defaultReportTemplateName,
+			want: `
+error: <site>/content/en/a.md line 2, b#c: #c does exists in <site>/content/en/b.md
+WARNING: <site>/content/en/a.md: line 3, footnote [^1] is not defined
+error: <site>/content/en/b.md: Error reading content: permission denied
+Total page processed: 2 links: 2 anchors: 1 errors: 2
+`,
+		},
+		{
+			name: "custom template",
+			template: `{{ range $page := .Pages }}{{ range .Links }}{{ if .Error }}:x: *{{ $page.Path }}:{{ .Line }}* ` + "`{{ .Link }}`" + ` ({{ .Category }})
+{{ end }}{{ end }}{{ end }}{{ .Summary.Errors }} errors
+`,
+			want: ":x: *<site>/content/en/a.md:2* `b#c` (missing anchor)\n2 errors\n",
+		},
+		{
+			name:     "invalid template",
+			template: `{{ range .Pages }}`,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			reportTemplateBefore := *reportTemplate
+			defer func() { *reportTemplate = reportTemplateBefore }()
+			*reportTemplate = tt.template
+			if tt.template != defaultReportTemplateName {
+				*reportTemplate = filepath.Join(root, "report.tmpl")
+				write(g, *reportTemplate, tt.template)
+			}
+			defer func() { reportTmpl = nil }()
+
+			// Templates are read and parsed at startup, before checking links.
+			err := loadReportTemplate()
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+
+			pages = []*page{
+				{
+					path:         filepath.Join(root, "hugo/content/en/a.md"),
+					isHugoPage:   true,
+					hugoLanguage: "en",
+					hugoPath:     "/a.md",
+					anchors:      []string{"a"},
+					links: []link{
+						{rawLink: "b", lineNumber: 1},
+						{rawLink: "b#c", lineNumber: 2, fatalError: newLinkcheckError(missingAnchorErrorCategory, "#c does exists in <site>/content/en/b.md")},
+					},
+					warnings: []warning{{lineNumber: 3, message: "footnote [^1] is not defined"}},
+				},
+				{
+					path:         filepath.Join(root, "hugo/content/en/b.md"),
+					isHugoPage:   true,
+					hugoLanguage: "en",
+					hugoPath:     "/b.md",
+					fatalError:   newLinkcheckError(pageErrorCategory, "Error reading content: permission denied"),
+				},
+			}
+			defer func() { pages = nil }()
+
+			var out bytes.Buffer
+			g.Expect(printReport(&out)).To(Succeed())
+			g.Expect(out.String()).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_loadReportTemplate_relativePath(t *testing.T) {
+	g := NewWithT(t)
+
+	dir, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(dir)
+	write(g, filepath.Join(dir, "report.tmpl"), "{{ .Summary.Pages }} pages\n")
+
+	wd, err := os.Getwd()
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() { g.Expect(os.Chdir(wd)).To(Succeed()) }()
+	g.Expect(os.Chdir(dir)).To(Succeed())
+
+	reportTemplateBefore := *reportTemplate
+	defer func() { *reportTemplate = reportTemplateBefore }()
+	*reportTemplate = "report.tmpl"
+	defer func() { reportTmpl = nil }()
+
+	// Relative paths are resolved from the current directory, not from the root of fileSystem.
+	g.Expect(loadReportTemplate()).To(Succeed())
+	g.Expect(*reportTemplate).To(Equal(filepath.Join(dir, "report.tmpl")))
+
+	pages = nil
+	var out bytes.Buffer
+	g.Expect(printReportTemplate(&out)).To(Succeed())
+	g.Expect(out.String()).To(Equal("0 pages\n"))
+}
@@ -0,0 +1,191 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// sarifVersion is the version of the SARIF documents printed by linkcheck.
+	sarifVersion = "2.1.0"
+
+	// sarifSchema is the JSON schema of the SARIF documents printed by linkcheck.
+	sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	// sarifSrcRoot is the base id of the URIs in the SARIF documents, i.e. the root folder, which code scanning tools
+	// resolve to the root of the repository.
+	sarifSrcRoot = "%SRCROOT%"
+)
+
+// sarifLog defines the subset of a SARIF document used by linkcheck.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+// sarifRun defines a run of linkcheck in a SARIF document.
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+// sarifTool defines linkcheck and its rules, one for each error category, in a SARIF document.
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+// sarifDriver defines linkcheck and its rules in a SARIF document.
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+// sarifRule defines an error category in a SARIF document.
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+// sarifMessage defines a text in a SARIF document.
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+// sarifResult defines an error in a SARIF document.
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+// sarifLocation defines where an error has been found in a SARIF document.
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+// sarifPhysicalLocation defines the file and the region where an error has been found in a SARIF document.
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+// sarifArtifactLocation defines a file, relative to the root folder, in a SARIF document.
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId"`
+}
+
+// sarifRegion defines the line and the column, starting from 1, where an error has been found in a SARIF document.
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+}
+
+// sarifRuleID returns the id of the rule for an error category, e.g. missing-file.
+// NOTE: ids are the names used in --severity, so the same name can be used to filter results and to change severities.
+func sarifRuleID(c errorCategory) string {
+	return errorCategoryName(c)
+}
+
+// printReportSARIF prints the result of linkcheck for all pages as a SARIF document, so broken links can be
+// uploaded to code scanning tools, e.g. GitHub code scanning.
+// NOTE: the document is printed also when quiet or streaming, because otherwise it would not be valid; page
+// warnings are not included, because they are not broken links.
+func printReportSARIF(w io.Writer) error {
+	rules := []sarifRule{}
+	for _, c := range errorCategories {
+		if severityFor(c) == ignoreSeverity {
+			continue
+		}
+		rules = append(rules, sarifRule{ID: sarifRuleID(c), ShortDescription: sarifMessage{Text: string(c)}})
+	}
+
+	results := []sarifResult{}
+	for i := range pages {
+		results = append(results, pageSARIFResults(pages[i])...)
+	}
+
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{
+			{
+				Tool:    sarifTool{Driver: sarifDriver{Name: "linkcheck", Rules: rules}},
+				Results: results,
+			},
+		},
+	}
+	content, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", content)
+	return err
+}
+
+// pageSARIFResults returns the SARIF results for the errors of a page.
+// NOTE: as in the gcc format, errors on the whole page are reported on the first line, and columns are
+// computed looking for the raw link in the page.
+func pageSARIFResults(p *page) []sarifResult {
+	path := p.path
+	if rel, err := filepath.Rel(*root, p.path); err == nil {
+		path = filepath.ToSlash(rel)
+	}
+	result := func(e *linkcheckError, message string, line, col int) sarifResult {
+		return sarifResult{
+			RuleID:  sarifRuleID(e.category),
+			Level:   gccSeverity(e.category),
+			Message: sarifMessage{Text: message},
+			Locations: []sarifLocation{
+				{
+					PhysicalLocation: sarifPhysicalLocation{
+						ArtifactLocation: sarifArtifactLocation{URI: path, URIBaseID: sarifSrcRoot},
+						Region:           sarifRegion{StartLine: line, StartColumn: col},
+					},
+				},
+			},
+		}
+	}
+
+	results := []sarifResult{}
+	if p.fatalError != nil {
+		if severityFor(p.fatalError.category) != ignoreSeverity {
+			results = append(results, result(p.fatalError, p.fatalError.Error(), 1, 1))
+		}
+		return results
+	}
+
+	content, _ := readFile(p.path)
+	lines := strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+	for _, l := range p.links {
+		if l.fatalError == nil || severityFor(l.fatalError.category) == ignoreSeverity {
+			continue
+		}
+		results = append(results, result(l.fatalError, fmt.Sprintf("%s: %s", l.rawLink, l.fatalError), l.lineNumber, column(lines, l.lineNumber, l.rawLink)))
+	}
+	return results
+}
@@ -0,0 +1,123 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_printReport_sarifFormat(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	formatBefore := *format
+	defer func() { *format = formatBefore }()
+	*format = formatSARIF
+
+	contentDir := filepath.Join(root, "hugo", contentFolder)
+	write(g, filepath.Join(contentDir, "en/a.md"), "# A\n\nSee [b](b) and [missing](missing).\nSee ⇒ [anchor](#missing).\n")
+	write(g, filepath.Join(contentDir, "en/b.md"), "# B\n\nSee [a](a).\n")
+	write(g, filepath.Join(contentDir, "it/c.md"), "# C\n")
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), &bytes.Buffer{})).To(Succeed())
+
+	var out bytes.Buffer
+	g.Expect(printReport(&out)).To(Succeed())
+
+	log := sarifLog{}
+	g.Expect(json.Unmarshal(out.Bytes(), &log)).To(Succeed())
+	g.Expect(log.Schema).To(Equal(sarifSchema))
+	g.Expect(log.Version).To(Equal("2.1.0"))
+	g.Expect(log.Runs).To(HaveLen(1))
+
+	run := log.Runs[0]
+	g.Expect(run.Tool.Driver.Name).To(Equal("linkcheck"))
+	g.Expect(run.Tool.Driver.Rules).To(ContainElements(
+		sarifRule{ID: "missing-file", ShortDescription: sarifMessage{Text: "missing file"}},
+		sarifRule{ID: "missing-anchor", ShortDescription: sarifMessage{Text: "missing anchor"}},
+	))
+
+	location := func(uri string, line, col int) []sarifLocation {
+		return []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: uri, URIBaseID: "%SRCROOT%"},
+			Region:           sarifRegion{StartLine: line, StartColumn: col},
+		}}}
+	}
+	g.Expect(run.Results).To(Equal([]sarifResult{
+		{
+			RuleID:    "missing-file",
+			Level:     "error",
+			Message:   sarifMessage{Text: "missing: the link resolves to /hugo/content/en/missing.md which does not exist"},
+			Locations: location("hugo/content/en/a.md", 3, 26),
+		},
+		{
+			RuleID:    "missing-anchor",
+			Level:     "error",
+			Message:   sarifMessage{Text: "#missing: #missing does exists in <site>/content/en/a.md"},
+			Locations: location("hugo/content/en/a.md", 4, 16),
+		},
+		{
+			RuleID:    "page",
+			Level:     "error",
+			Message:   sarifMessage{Text: "hugo page /it/c.md does not belong to one of the know languages: en"},
+			Locations: location("hugo/content/it/c.md", 1, 1),
+		},
+	}))
+
+	// Every result refers to one of the rules.
+	ruleIDs := []string{}
+	for _, r := range run.Tool.Driver.Rules {
+		ruleIDs = append(ruleIDs, r.ID)
+	}
+	for _, r := range run.Results {
+		g.Expect(ruleIDs).To(ContainElement(r.RuleID))
+	}
+}
+
+func Test_sarifRuleID(t *testing.T) {
+	g := NewWithT(t)
+
+	// Rule ids are the names used in --severity.
+	g.Expect(sarifRuleID(missingFileErrorCategory)).To(Equal("missing-file"))
+	g.Expect(sarifRuleID(pageErrorCategory)).To(Equal("page"))
+	g.Expect(sarifRuleID(externalErrorCategory)).To(Equal("external"))
+	g.Expect(sarifRuleID(renderedErrorCategory)).To(Equal("rendered"))
+}
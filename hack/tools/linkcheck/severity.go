@@ -0,0 +1,114 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// severity defines how errors in a category affect the output and the exit code of linkcheck.
+type severity string
+
+const (
+	// errorSeverity reports errors and makes linkcheck fail.
+	errorSeverity severity = "error"
+
+	// warningSeverity reports errors as warnings, without making linkcheck fail.
+	warningSeverity severity = "warning"
+
+	// ignoreSeverity does not report errors.
+	ignoreSeverity severity = "ignore"
+)
+
+// errorCategoryNames maps the names used in --severity to error categories.
+var errorCategoryNames = map[string]errorCategory{
+	"page":             pageErrorCategory,
+	"invalid-link":     invalidLinkErrorCategory,
+	"forbidden-link":   forbiddenLinkErrorCategory,
+	"missing-file":     missingFileErrorCategory,
+	"missing-anchor":   missingAnchorErrorCategory,
+	"unpublished-page": unpublishedPageErrorCategory,
+	"unknown-version":  unknownVersionErrorCategory,
+	"external":         externalErrorCategory,
+	"rendered":         renderedErrorCategory,
+	"insecure-link":    insecureLinkErrorCategory,
+	"oversized-image":  oversizedImageErrorCategory,
+}
+
+// errorCategoryName returns the name used in --severity for an error category, e.g. missing-file.
+func errorCategoryName(c errorCategory) string {
+	for name, category := range errorCategoryNames {
+		if category == c {
+			return name
+		}
+	}
+	return strings.ReplaceAll(string(c), " ", "-")
+}
+
+// parseSeverities returns the severity for error categories defined by a list of category=level values.
+func parseSeverities(values []string) (map[errorCategory]severity, error) {
+	severities := map[errorCategory]severity{}
+	for _, v := range values {
+		name, level, ok := strings.Cut(v, "=")
+		if !ok {
+			return nil, errors.Errorf("invalid severity %q, must be in the category=level form", v)
+		}
+		c, ok := errorCategoryNames[name]
+		if !ok {
+			names := make([]string, 0, len(errorCategoryNames))
+			for n := range errorCategoryNames {
+				names = append(names, n)
+			}
+			sort.Strings(names)
+			return nil, errors.Errorf("invalid error category %q, must be one of %s", name, strings.Join(names, ", "))
+		}
+		switch s := severity(level); s {
+		case errorSeverity, warningSeverity, ignoreSeverity:
+			severities[c] = s
+		default:
+			return nil, errors.Errorf("invalid severity level %q for %s, must be one of error, warning, ignore", level, name)
+		}
+	}
+	return severities, nil
+}
+
+// categorySeverities defines the severity for error categories, as set by --severity.
+var categorySeverities = map[errorCategory]severity{}
+
+// loadSeverities parses --severity, so errors are reported before checking links.
+func loadSeverities() error {
+	s, err := parseSeverities(*severities)
+	if err != nil {
+		return err
+	}
+	categorySeverities = s
+	return nil
+}
+
+// severityFor returns the severity for an error category, defaulting to error.
+func severityFor(c errorCategory) severity {
+	if s, ok := categorySeverities[c]; ok {
+		return s
+	}
+	return errorSeverity
+}
@@ -0,0 +1,113 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_parseSeverities(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		want    map[errorCategory]severity
+		wantErr bool
+	}{
+		{
+			name:   "no values",
+			values: nil,
+			want:   map[errorCategory]severity{},
+		},
+		{
+			name:   "valid values",
+			values: []string{"missing-anchor=warning", "external=error", "unpublished-page=ignore"},
+			want: map[errorCategory]severity{
+				missingAnchorErrorCategory:   warningSeverity,
+				externalErrorCategory:        errorSeverity,
+				unpublishedPageErrorCategory: ignoreSeverity,
+			},
+		},
+		{
+			name:    "missing level",
+			values:  []string{"missing-anchor"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid category",
+			values:  []string{"missing-page=warning"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid level",
+			values:  []string{"missing-anchor=info"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := parseSeverities(tt.values)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_severityFor(t *testing.T) {
+	g := NewWithT(t)
+
+	resetSeverities := setSeverities([]string{"missing-anchor=warning"})
+	defer resetSeverities()
+
+	g.Expect(severityFor(missingAnchorErrorCategory)).To(Equal(warningSeverity))
+	g.Expect(severityFor(missingFileErrorCategory)).To(Equal(errorSeverity))
+}
+
+func Test_errorCategoryName(t *testing.T) {
+	g := NewWithT(t)
+
+	// Every error category has a name in --severity.
+	for _, c := range errorCategories {
+		name := errorCategoryName(c)
+		g.Expect(errorCategoryNames).To(HaveKeyWithValue(name, c))
+	}
+}
+
+func setSeverities(severitiesValue []string) (resetSeverities func()) {
+	severitiesBefore := *severities
+	categorySeveritiesBefore := categorySeverities
+
+	*severities = severitiesValue
+	if err := loadSeverities(); err != nil {
+		panic(err.Error())
+	}
+
+	return func() {
+		*severities = severitiesBefore
+		categorySeverities = categorySeveritiesBefore
+	}
+}
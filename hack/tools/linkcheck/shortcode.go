@@ -0,0 +1,138 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// shortcode defines a shortcode invocation in a page, e.g. {{< name "value" param="value" >}}.
+type shortcode struct {
+	// name of the shortcode.
+	name string
+
+	// positionalParams contains the values of positional parameters, in order.
+	positionalParams []string
+
+	// namedParams contains the values of named parameters.
+	namedParams map[string]string
+}
+
+// Search for shortcode invocations in the format {{< name params >}} or {{% name params %}}, captures name and params.
+// NOTE: closing shortcodes, e.g. {{< /name >}}, are not matched.
+var shortcodeRx = regexp.MustCompile(`\{\{[<%]\s*([\w\-\.]+(?:/[\w\-\.]+)*)((?:\s+[^%>]*?)?)\s*[%>]\}\}`)
+
+// Search for shortcode params in the format name="value" or "value", captures name (if any) and value.
+var shortcodeParamRx = regexp.MustCompile(`(?:([\w\-]+)\s*=\s*)?"([^"]*)"`)
+
+// readShortcodes returns the shortcodes invoked in a text.
+func readShortcodes(text string) (shortcodes []shortcode) {
+	for _, m := range shortcodeRx.FindAllStringSubmatch(text, -1) {
+		s := shortcode{name: m[1], namedParams: map[string]string{}}
+		for _, pm := range shortcodeParamRx.FindAllStringSubmatch(m[2], -1) {
+			if pm[1] == "" {
+				s.positionalParams = append(s.positionalParams, pm[2])
+				continue
+			}
+			s.namedParams[pm[1]] = pm[2]
+		}
+		shortcodes = append(shortcodes, s)
+	}
+	return
+}
+
+// readShortcodeAnchors returns the anchors generated by shortcodes rendering headings.
+// The heading text is read from the title parameter or from the first positional parameter.
+func readShortcodeAnchors(body string) (anchors []string) {
+	if len(*headingShortcodes) == 0 {
+		return
+	}
+
+	for _, s := range readShortcodes(body) {
+		if !containsString(*headingShortcodes, s.name) {
+			continue
+		}
+
+		title, ok := s.namedParams["title"]
+		if !ok && len(s.positionalParams) > 0 {
+			title = s.positionalParams[0]
+		}
+		if title != "" {
+			anchors = append(anchors, anchorFromHeading(title))
+		}
+	}
+	return
+}
+
+// parseLinkShortcodes returns the link parameters of each shortcode defined by a list of shortcode.param values.
+// NOTE: shortcode names can contain dots, so the parameter name is the part after the last dot.
+func parseLinkShortcodes(values []string) (map[string][]string, error) {
+	params := map[string][]string{}
+	for _, v := range values {
+		i := strings.LastIndex(v, ".")
+		if i <= 0 || i == len(v)-1 {
+			return nil, errors.Errorf("invalid link shortcode %q, must be in the shortcode.param form", v)
+		}
+		params[v[:i]] = append(params[v[:i]], v[i+1:])
+	}
+	return params, nil
+}
+
+// linkShortcodeParams defines the link parameters of each shortcode, as set by --link-shortcodes.
+var linkShortcodeParams = map[string][]string{}
+
+// loadLinkShortcodes parses --link-shortcodes, so errors are reported before reading pages.
+func loadLinkShortcodes() error {
+	params, err := parseLinkShortcodes(*linkShortcodes)
+	if err != nil {
+		return err
+	}
+	linkShortcodeParams = params
+	return nil
+}
+
+// readShortcodeLinks returns the links defined by link parameters of shortcodes invoked in a line, e.g. {{< button href="/path" >}},
+// given the link parameters of each shortcode.
+func readShortcodeLinks(line string, params map[string][]string) (links []string) {
+	if len(params) == 0 {
+		return
+	}
+
+	for _, s := range readShortcodes(line) {
+		for _, param := range params[s.name] {
+			if l, ok := s.namedParams[param]; ok && l != "" {
+				links = append(links, l)
+			}
+		}
+	}
+	return
+}
+
+func containsString(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
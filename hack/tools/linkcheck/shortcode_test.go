@@ -0,0 +1,306 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_readShortcodes(t *testing.T) {
+	tests := []struct {
+		name           string
+		text           string
+		wantShortcodes []shortcode
+	}{
+		{
+			name:           "no shortcodes",
+			text:           "# Title",
+			wantShortcodes: nil,
+		},
+		{
+			name: "shortcode without params",
+			text: "{{< toc >}}",
+			wantShortcodes: []shortcode{
+				{name: "toc", namedParams: map[string]string{}},
+			},
+		},
+		{
+			name: "shortcode with positional and named params",
+			text: `before {{< heading "My Title" level="2" >}} after`,
+			wantShortcodes: []shortcode{
+				{name: "heading", positionalParams: []string{"My Title"}, namedParams: map[string]string{"level": "2"}},
+			},
+		},
+		{
+			name: "percent shortcode with a nested name",
+			text: `{{% blocks/section title="Section" %}}`,
+			wantShortcodes: []shortcode{
+				{name: "blocks/section", namedParams: map[string]string{"title": "Section"}},
+			},
+		},
+		{
+			name:           "closing shortcode",
+			text:           "{{< /tab >}}",
+			wantShortcodes: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(readShortcodes(tt.text)).To(Equal(tt.wantShortcodes))
+		})
+	}
+}
+
+func Test_readShortcodeAnchors(t *testing.T) {
+	tests := []struct {
+		name              string
+		headingShortcodes []string
+		body              string
+		wantAnchors       []string
+	}{
+		{
+			name:              "no heading shortcodes configured",
+			headingShortcodes: []string{},
+			body:              `{{< heading "My Title" >}}`,
+			wantAnchors:       nil,
+		},
+		{
+			name:              "heading shortcode with positional title",
+			headingShortcodes: []string{"heading"},
+			body:              `{{< heading "My Title" >}}`,
+			wantAnchors:       []string{"my-title"},
+		},
+		{
+			name:              "heading shortcode with named title",
+			headingShortcodes: []string{"heading"},
+			body:              "# Title\n\n{{% heading level=\"2\" title=\"Another Title\" %}}\n",
+			wantAnchors:       []string{"another-title"},
+		},
+		{
+			name:              "shortcode not rendering a heading",
+			headingShortcodes: []string{"heading"},
+			body:              `{{< alert title="Note" >}}`,
+			wantAnchors:       nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			headingShortcodesBefore := *headingShortcodes
+			defer func() { *headingShortcodes = headingShortcodesBefore }()
+			*headingShortcodes = tt.headingShortcodes
+
+			g.Expect(readShortcodeAnchors(tt.body)).To(Equal(tt.wantAnchors))
+		})
+	}
+}
+
+func Test_parseLinkShortcodes(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		want    map[string][]string
+		wantErr bool
+	}{
+		{
+			name:   "no link shortcodes",
+			values: []string{},
+			want:   map[string][]string{},
+		},
+		{
+			name:   "link shortcodes",
+			values: []string{"button.href", "card.link", "card.image", "docs.card.href"},
+			want: map[string][]string{
+				"button":    {"href"},
+				"card":      {"link", "image"},
+				"docs.card": {"href"},
+			},
+		},
+		{
+			name:    "missing param",
+			values:  []string{"button"},
+			wantErr: true,
+		},
+		{
+			name:    "empty param",
+			values:  []string{"button."},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := parseLinkShortcodes(tt.values)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_readShortcodeLinks(t *testing.T) {
+	tests := []struct {
+		name           string
+		linkShortcodes []string
+		line           string
+		wantLinks      []string
+	}{
+		{
+			name:           "no link shortcodes configured",
+			linkShortcodes: []string{},
+			line:           `{{< button href="/docs" >}}Docs{{< /button >}}`,
+			wantLinks:      nil,
+		},
+		{
+			name:           "link shortcode",
+			linkShortcodes: []string{"button.href"},
+			line:           `{{< button color="primary" href="/docs#install" >}}Docs{{< /button >}}`,
+			wantLinks:      []string{"/docs#install"},
+		},
+		{
+			name:           "link shortcode without the link param",
+			linkShortcodes: []string{"button.href"},
+			line:           `{{< button color="primary" >}}`,
+			wantLinks:      nil,
+		},
+		{
+			name:           "other shortcodes",
+			linkShortcodes: []string{"button.href"},
+			line:           `{{% card href="/docs" %}}`,
+			wantLinks:      nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			params, err := parseLinkShortcodes(tt.linkShortcodes)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			g.Expect(readShortcodeLinks(tt.line, params)).To(Equal(tt.wantLinks))
+		})
+	}
+}
+
+func Test_readAllAndLinkcheckAll_linkShortcodes(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	resetLinkShortcodes := setLinkShortcodes([]string{"button.href"})
+	defer resetLinkShortcodes()
+
+	contentDir := filepath.Join(root, "hugo", contentFolder)
+	write(g, filepath.Join(contentDir, "en/_index.md"), `# Home
+
+{{< button href="/docs" >}}Docs{{< /button >}}
+{{< button href="/docs/missing" >}}Missing{{< /button >}}
+`)
+	write(g, filepath.Join(contentDir, "en/docs/_index.md"), "# Docs\n")
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+
+	g.Expect(collectErrors(root)).To(Equal([]string{
+		"/hugo/content/en/_index.md:4: /docs/missing: the link resolves to /hugo/content/en/docs/missing.md which does not exist",
+	}))
+}
+
+func Test_readAllAndLinkcheckAll_percentShortcodes(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	// Hugo renders the content of percent-style shortcodes as markdown, so links and headings inside them count.
+	path := filepath.Join(root, "hugo", contentFolder, "en/_index.md")
+	write(g, path, `# Home
+
+{{% alert title="Note" color="info" %}}
+## Inside Alert
+
+See the [page](page), the [heading](#inside-alert) or the [missing page](missing).
+{{% /alert %}}
+
+{{% alert %}}See the [inline missing page](inline-missing).{{% /alert %}}
+`)
+	write(g, filepath.Join(root, "hugo", contentFolder, "en/page.md"), "# Page\n")
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+
+	g.Expect(pagesByPath[path].anchors).To(ContainElement("inside-alert"))
+	g.Expect(pagesByPath[path].links).To(HaveLen(4))
+	g.Expect(collectErrors(root)).To(Equal([]string{
+		"/hugo/content/en/_index.md:6: missing: the link resolves to /hugo/content/en/missing.md which does not exist",
+		"/hugo/content/en/_index.md:9: inline-missing: the link resolves to /hugo/content/en/inline-missing.md which does not exist",
+	}))
+}
+
+func setLinkShortcodes(linkShortcodesValue []string) (resetLinkShortcodes func()) {
+	linkShortcodesBefore := *linkShortcodes
+	linkShortcodeParamsBefore := linkShortcodeParams
+
+	*linkShortcodes = linkShortcodesValue
+	if err := loadLinkShortcodes(); err != nil {
+		panic(err.Error())
+	}
+
+	return func() {
+		*linkShortcodes = linkShortcodesBefore
+		linkShortcodeParams = linkShortcodeParamsBefore
+	}
+}
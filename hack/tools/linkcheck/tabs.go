@@ -0,0 +1,96 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	// tabHeadingsAnchor defines that headings inside tab panels generate anchors, like any other heading.
+	tabHeadingsAnchor = "anchor"
+
+	// tabHeadingsSkip defines that headings inside tab panels do not generate anchors, and that the anchors
+	// of links pointing to those headings are not checked, because they depend on the theme.
+	tabHeadingsSkip = "skip"
+)
+
+// Search for closing shortcodes in the format {{< /name >}} or {{% /name %}}, captures name.
+var closingShortcodeRx = regexp.MustCompile(`\{\{[<%]\s*/([\w\-\.]+(?:/[\w\-\.]+)*)\s*[%>]\}\}`)
+
+// splitTabPanels splits a page body into the content outside tab panels and the content inside tab panels,
+// where tab panels are defined by the shortcodes in --tab-shortcodes, e.g. {{< tab >}} ... {{< /tab >}}.
+// NOTE: lines inside tab panels are blanked in the content outside, so both keep the line numbers of the body.
+func splitTabPanels(body string) (outside, inside string) {
+	lines := strings.Split(body, "\n")
+	outsideLines := make([]string, len(lines))
+	insideLines := make([]string, len(lines))
+	depth := 0
+	for i, line := range lines {
+		delta := 0
+		for _, s := range readShortcodes(line) {
+			if containsString(*tabShortcodes, s.name) {
+				delta++
+			}
+		}
+		for _, m := range closingShortcodeRx.FindAllStringSubmatch(line, -1) {
+			if containsString(*tabShortcodes, m[1]) {
+				delta--
+			}
+		}
+
+		// Lines opening or closing a tab panel are considered outside.
+		if depth > 0 && delta == 0 {
+			insideLines[i] = line
+		} else {
+			outsideLines[i] = line
+		}
+		depth += delta
+		if depth < 0 {
+			depth = 0
+		}
+	}
+	return strings.Join(outsideLines, "\n"), strings.Join(insideLines, "\n")
+}
+
+// readTabPanelAnchors returns the anchors generated by headings in a page body, and the level of each header,
+// together with the anchors of headings inside tab panels which are not registered because of --tab-headings=skip.
+func readTabPanelAnchors(body string) (anchors []string, levels []int, tabAnchors []string) {
+	if len(*tabShortcodes) == 0 || *tabHeadings != tabHeadingsSkip {
+		anchors, levels = readMarkdownAnchors(body)
+		return
+	}
+
+	outside, inside := splitTabPanels(body)
+	anchors, levels = readMarkdownAnchors(outside)
+	tabAnchors, _ = readMarkdownAnchors(inside)
+	return
+}
+
+// isTabPanelAnchor returns true if a link fragment matches the anchor of a heading inside a tab panel of a page.
+func isTabPanelAnchor(p *page, fragment string) bool {
+	for _, a := range p.tabAnchors {
+		if anchorMatches(fragment, a) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_splitTabPanels(t *testing.T) {
+	g := NewWithT(t)
+
+	tabShortcodesBefore := *tabShortcodes
+	defer func() { *tabShortcodes = tabShortcodesBefore }()
+	*tabShortcodes = []string{"tabpane", "tab"}
+
+	outside, inside := splitTabPanels(`# Title
+{{< tabpane >}}
+{{< tab header="Linux" >}}
+## Linux
+{{< /tab >}}
+{{% tab header="Mac" %}}
+## Mac
+{{% /tab %}}
+{{< /tabpane >}}
+## After`)
+	g.Expect(outside).To(Equal("# Title\n{{< tabpane >}}\n{{< tab header=\"Linux\" >}}\n\n{{< /tab >}}\n{{% tab header=\"Mac\" %}}\n\n{{% /tab %}}\n{{< /tabpane >}}\n## After"))
+	g.Expect(inside).To(Equal("\n\n\n## Linux\n\n\n## Mac\n\n\n"))
+}
+
+func Test_readAllAndLinkcheckAll_tabHeadings(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	path := filepath.Join(root, "hugo", contentFolder, "en/_index.md")
+	write(g, path, `# Home
+
+{{< tab header="Linux" >}}
+## Install on Linux
+{{< /tab >}}
+
+See [install](#install-on-linux) or [missing](#missing).
+`)
+
+	tabShortcodesBefore := *tabShortcodes
+	tabHeadingsBefore := *tabHeadings
+	defer func() {
+		*tabShortcodes = tabShortcodesBefore
+		*tabHeadings = tabHeadingsBefore
+	}()
+	*tabShortcodes = []string{"tab"}
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	// With --tab-headings=anchor, headings inside tab panels generate anchors.
+	*tabHeadings = tabHeadingsAnchor
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+	g.Expect(pagesByPath[path].anchors).To(Equal([]string{"home", "install-on-linux"}))
+	g.Expect(pagesByPath[path].tabAnchors).To(BeEmpty())
+	g.Expect(collectErrors(root)).To(Equal([]string{
+		"/hugo/content/en/_index.md:7: #missing: #missing does exists in <site>/content/en/_index.md",
+	}))
+
+	pages = nil
+	pagesByPath = nil
+
+	// With --tab-headings=skip, headings inside tab panels do not generate anchors, and links to them are not checked.
+	*tabHeadings = tabHeadingsSkip
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+	g.Expect(pagesByPath[path].anchors).To(Equal([]string{"home"}))
+	g.Expect(pagesByPath[path].tabAnchors).To(Equal([]string{"install-on-linux"}))
+	g.Expect(collectErrors(root)).To(Equal([]string{
+		"/hugo/content/en/_index.md:7: #missing: #missing does exists in <site>/content/en/_index.md",
+	}))
+}
@@ -0,0 +1,139 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"sort"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultTaxonomies are the taxonomies used by hugo when the hugo website config does not define taxonomies.
+var defaultTaxonomies = []string{"tags", "categories"}
+
+// taxonomies contains the plural name of the taxonomies of the hugo website, e.g. tags, as used in front matter and urls.
+var taxonomies []string
+
+// taxonomyPaths contains the site path of the taxonomy and term pages generated by hugo, e.g. /tags and /tags/foo,
+// in the language:/site/path form.
+var taxonomyPaths map[string]bool
+
+// loadTaxonomies reads the taxonomies from the hugo website config, if any, defaulting to tags and categories.
+func loadTaxonomies() error {
+	taxonomies = nil
+	if *hugoFolder == "" {
+		return nil
+	}
+
+	config, err := readHugoConfig()
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			taxonomies = defaultTaxonomies
+			return nil
+		}
+		return err
+	}
+	if config.Taxonomies == nil {
+		taxonomies = defaultTaxonomies
+		return nil
+	}
+
+	// NOTE: taxonomies are defined as singular = "plural", e.g. tag = "tags"; an empty table disables taxonomies.
+	for _, plural := range config.Taxonomies {
+		taxonomies = append(taxonomies, plural)
+	}
+	sort.Strings(taxonomies)
+	return nil
+}
+
+// readFrontMatterTerms returns the terms of each taxonomy defined in the front matter of a markdown page, e.g.
+// tags: [foo, bar]; a taxonomy with a single term can also be defined as a string, e.g. tags: foo.
+func readFrontMatterTerms(content string) (map[string][]string, error) {
+	if len(taxonomies) == 0 {
+		return nil, nil
+	}
+
+	data, err := readFrontMatterYAML(content)
+	if err != nil || data == "" {
+		return nil, err
+	}
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(data), &values); err != nil {
+		return nil, errors.Wrap(err, "failed to parse YAML front matter")
+	}
+
+	var terms map[string][]string
+	for _, t := range taxonomies {
+		var ts []string
+		switch v := values[t].(type) {
+		case string:
+			ts = append(ts, v)
+		case []interface{}:
+			for _, term := range v {
+				ts = append(ts, fmt.Sprint(term))
+			}
+		}
+		if len(ts) == 0 {
+			continue
+		}
+		if terms == nil {
+			terms = map[string][]string{}
+		}
+		terms[t] = ts
+	}
+	return terms, nil
+}
+
+// indexTaxonomies computes the site path of the taxonomy and term pages generated by hugo for the terms used in
+// the front matter of the pages, e.g. /tags/foo for a page with tags: [foo].
+// NOTE: as in hugo, terms are urlized, e.g. /tags/cluster-class for tags: [Cluster Class], and draft pages do not
+// generate term pages.
+func indexTaxonomies() {
+	taxonomyPaths = nil
+	if len(taxonomies) == 0 {
+		return
+	}
+
+	taxonomyPaths = map[string]bool{}
+	for i := range pages {
+		p := pages[i]
+		if !p.isHugoPage || p.fatalError != nil || (p.frontMatter.Draft && !*includeDrafts) {
+			continue
+		}
+		for taxonomy, terms := range p.terms {
+			for _, term := range terms {
+				taxonomyPaths[fmt.Sprintf("%s:/%s", p.hugoLanguage, taxonomy)] = true
+				taxonomyPaths[fmt.Sprintf("%s:/%s/%s", p.hugoLanguage, taxonomy, anchorFromHeading(term))] = true
+			}
+		}
+	}
+}
+
+// isTaxonomyPath returns true if the path of a missing page is the path of a taxonomy or term page generated by hugo.
+func isTaxonomyPath(missingPath string) bool {
+	missingp := newPage(missingPath)
+	if !missingp.isHugoPage || missingp.hugoLanguage == "" {
+		return false
+	}
+	return taxonomyPaths[fmt.Sprintf("%s:%s", missingp.hugoLanguage, cleanSitePath(missingp.sitePath()))]
+}
@@ -0,0 +1,146 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_loadTaxonomies(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+	defer func() { taxonomies = nil }()
+
+	// No config, defaults to tags and categories.
+	g.Expect(loadTaxonomies()).To(Succeed())
+	g.Expect(taxonomies).To(Equal([]string{"tags", "categories"}))
+
+	write(g, filepath.Join(root, "hugo", hugoConfigFile), "title = \"test\"\n")
+	g.Expect(loadTaxonomies()).To(Succeed())
+	g.Expect(taxonomies).To(Equal([]string{"tags", "categories"}))
+
+	write(g, filepath.Join(root, "hugo", hugoConfigFile), "[taxonomies]\ntag = \"tags\"\nseries = \"series\"\n")
+	g.Expect(loadTaxonomies()).To(Succeed())
+	g.Expect(taxonomies).To(Equal([]string{"series", "tags"}))
+
+	// An empty table disables taxonomies.
+	write(g, filepath.Join(root, "hugo", hugoConfigFile), "[taxonomies]\n")
+	g.Expect(loadTaxonomies()).To(Succeed())
+	g.Expect(taxonomies).To(BeEmpty())
+
+	write(g, filepath.Join(root, "hugo", hugoConfigFile), "[taxonomies")
+	g.Expect(loadTaxonomies()).ToNot(Succeed())
+}
+
+func Test_readFrontMatterTerms(t *testing.T) {
+	taxonomiesBefore := taxonomies
+	defer func() { taxonomies = taxonomiesBefore }()
+	taxonomies = []string{"tags", "categories"}
+
+	tests := []struct {
+		name    string
+		content string
+		want    map[string][]string
+		wantErr bool
+	}{
+		{
+			name:    "no front matter",
+			content: "# Page\n",
+			want:    nil,
+		},
+		{
+			name:    "front matter without terms",
+			content: "---\ntitle: Page\nseries: [upgrades]\n---\n# Page\n",
+			want:    nil,
+		},
+		{
+			name:    "front matter with terms",
+			content: "---\ntags: [Cluster Class, upgrades]\ncategories: tasks\n---\n# Page\n",
+			want:    map[string][]string{"tags": {"Cluster Class", "upgrades"}, "categories": {"tasks"}},
+		},
+		{
+			name:    "invalid front matter",
+			content: "---\ntags: [\n---\n# Page\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := readFrontMatterTerms(tt.content)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_readAllAndLinkcheckAll_taxonomies(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	g.Expect(loadTaxonomies()).To(Succeed())
+	defer func() { taxonomies = nil }()
+
+	write(g, filepath.Join(root, "hugo", contentFolder, "en/_index.md"), `# Home
+
+See [tags](/tags/), [cluster class](/tags/cluster-class), [tasks](/categories/tasks/) or [missing](/tags/missing).
+See [draft](/tags/draft) or [categories](/categories#tasks).
+`)
+	write(g, filepath.Join(root, "hugo", contentFolder, "en/tasks/upgrade.md"), "---\ntags: [Cluster Class]\ncategories: tasks\n---\n# Upgrade\n")
+	write(g, filepath.Join(root, "hugo", contentFolder, "en/tasks/draft.md"), "---\ndraft: true\ntags: [draft]\n---\n# Draft\n")
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+
+	g.Expect(collectErrors(root)).To(Equal([]string{
+		"/hugo/content/en/_index.md:3: /tags/missing: the link resolves to /hugo/content/en/tags/missing.md which does not exist",
+		"/hugo/content/en/_index.md:4: /tags/draft: the link resolves to /hugo/content/en/tags/draft.md which does not exist",
+	}))
+}
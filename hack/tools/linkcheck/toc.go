@@ -0,0 +1,80 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// checkTOC warns about the pages of the hugo website not linked from the --toc-file page, which defines
+// the navigation of the website, e.g. a SUMMARY.md page; the links of the toc page are checked like any other page.
+// NOTE: only the pages in the same language of the toc page are expected to be linked from it.
+func checkTOC() error {
+	if *tocFile == "" {
+		return nil
+	}
+
+	path, err := filepath.Abs(*tocFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to convert toc file to an absolute path")
+	}
+	*tocFile = path
+
+	// The toc page is read with all the other pages, unless it is outside root.
+	toc, ok := pagesByPath[path]
+	if !ok {
+		addPage(readMarkdownPage(path))
+		toc = pagesByPath[path]
+	}
+	if toc.fatalError != nil {
+		return errors.Wrapf(toc.fatalError, "failed to read toc file")
+	}
+
+	language := toc.hugoLanguage
+	if language == "" && len(*hugoLanguages) > 0 {
+		language = (*hugoLanguages)[0]
+	}
+
+	// Collect the pages the links in the toc page resolve to, e.g. folder/_index.md for a link to folder/.
+	linked := map[string]bool{}
+	for _, l := range toc.links {
+		if l.fatalError != nil || l.URL == nil || l.URL.Scheme != "" {
+			continue
+		}
+		linked[l.URL.Path] = true
+	}
+
+	for i := range pages {
+		p := pages[i]
+		if !p.isHugoPage || p.fatalError != nil || p.hugoLanguage != language || p.path == toc.path {
+			continue
+		}
+		if p.frontMatter.Draft && !*includeDrafts {
+			continue
+		}
+		if !linked[p.path] {
+			p.warnings = append(p.warnings, warning{message: fmt.Sprintf("page is not linked from the toc page %s", toc.logPath())})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_checkTOC(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en", "it"})
+	defer cancel()
+
+	contentDir := filepath.Join(root, "hugo", contentFolder)
+	tocPath := filepath.Join(contentDir, "en/SUMMARY.md")
+	write(g, tocPath, `# Summary
+
+- [Introduction](/)
+- [Tasks](/tasks/)
+  - [Install](/tasks/install#prerequisites)
+  - [Missing](/tasks/missing)
+- [GitHub](https://github.com/kubernetes-sigs/cluster-api)
+`)
+	touch(g, filepath.Join(contentDir, "en/_index.md"))
+	touch(g, filepath.Join(contentDir, "en/tasks/_index.md"))
+	write(g, filepath.Join(contentDir, "en/tasks/install.md"), "# Install\n\n## Prerequisites\n")
+	touch(g, filepath.Join(contentDir, "en/tasks/upgrade.md"))
+	write(g, filepath.Join(contentDir, "en/tasks/draft.md"), "---\ndraft: true\n---\n# Draft\n")
+	touch(g, filepath.Join(contentDir, "it/_index.md"))
+
+	tocFileBefore := *tocFile
+	defer func() { *tocFile = tocFileBefore }()
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	// Without a toc file, pages are not expected to be linked from it.
+	*tocFile = ""
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(checkTOC()).To(Succeed())
+	g.Expect(pagesByPath[filepath.Join(contentDir, "en/tasks/upgrade.md")].warnings).To(BeEmpty())
+
+	pages = nil
+	pagesByPath = nil
+
+	*tocFile = tocPath
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(checkTOC()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+
+	// Pages missing from the toc, except drafts and pages in other languages, are reported.
+	g.Expect(pagesByPath[filepath.Join(contentDir, "en/tasks/upgrade.md")].warnings).To(Equal([]warning{
+		{message: "page is not linked from the toc page <site>/content/en/SUMMARY.md"},
+	}))
+	for _, path := range []string{"en/_index.md", "en/tasks/_index.md", "en/tasks/install.md", "en/tasks/draft.md", "en/SUMMARY.md", "it/_index.md"} {
+		g.Expect(pagesByPath[filepath.Join(contentDir, path)].warnings).To(BeEmpty(), path)
+	}
+
+	// The links in the toc page are checked.
+	g.Expect(collectErrors(root)).To(Equal([]string{
+		"/hugo/content/en/SUMMARY.md:6: /tasks/missing: the link resolves to /hugo/content/en/tasks/missing.md which does not exist",
+	}))
+}
+
+func Test_checkTOC_missingFile(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	tocFileBefore := *tocFile
+	defer func() { *tocFile = tocFileBefore }()
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	*tocFile = filepath.Join(root, "missing", "SUMMARY.md")
+	g.Expect(checkTOC()).ToNot(Succeed())
+}
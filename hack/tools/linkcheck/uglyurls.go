@@ -0,0 +1,83 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// htmlExtension is the extension of the files generated by hugo for each page.
+const htmlExtension = ".html"
+
+// uglyURLs is true if the hugo website config sets uglyURLs, and pages are served as page.html instead of page/index.html.
+// NOTE: sections are served as folder/index.html in both cases.
+var uglyURLs bool
+
+// loadUglyURLs reads the uglyURLs setting from the hugo website config, if any.
+func loadUglyURLs() error {
+	uglyURLs = false
+	if *hugoFolder == "" {
+		return nil
+	}
+
+	config, err := readHugoConfig()
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	uglyURLs = config.UglyURLs
+	return nil
+}
+
+// trimHTMLExtension returns the path of the page a link to a generated html file points to, e.g. page for page.html
+// or folder/ for folder/index.html, and whether the link points to an html file.
+func trimHTMLExtension(linkPath string) (string, bool) {
+	if !strings.EqualFold(path.Ext(linkPath), htmlExtension) {
+		return linkPath, false
+	}
+	if strings.EqualFold(path.Base(linkPath), "index"+htmlExtension) {
+		return strings.TrimSuffix(path.Dir(linkPath), "/") + "/", true
+	}
+	return linkPath[:len(linkPath)-len(htmlExtension)], true
+}
+
+// urlStyleHint returns a hint for links using a form that is not served with the url style of the hugo website.
+func urlStyleHint(linkPath string, isDir, isHTML bool) string {
+	if isHTML && strings.EqualFold(path.Base(linkPath), "index"+htmlExtension) {
+		return ""
+	}
+
+	switch {
+	case isDir && isHTML:
+		return fmt.Sprintf("sections are served as folders, use %s/", linkPath[:len(linkPath)-len(htmlExtension)])
+	case !isDir && isHTML && !uglyURLs:
+		return fmt.Sprintf("with pretty urls pages are served as folders, use %s/", linkPath[:len(linkPath)-len(htmlExtension)])
+	case !isDir && !isHTML && uglyURLs:
+		return fmt.Sprintf("with uglyURLs pages are served as html files, use %s%s", strings.TrimRight(linkPath, "/"), htmlExtension)
+	}
+	return ""
+}
@@ -0,0 +1,148 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_loadUglyURLs(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+	defer func() { uglyURLs = false }()
+
+	// No config, defaults to pretty urls.
+	g.Expect(loadUglyURLs()).To(Succeed())
+	g.Expect(uglyURLs).To(BeFalse())
+
+	write(g, filepath.Join(root, "hugo", hugoConfigFile), "uglyURLs = true\n")
+	g.Expect(loadUglyURLs()).To(Succeed())
+	g.Expect(uglyURLs).To(BeTrue())
+
+	write(g, filepath.Join(root, "hugo", hugoConfigFile), "uglyURLs = \n")
+	g.Expect(loadUglyURLs()).ToNot(Succeed())
+}
+
+func Test_trimHTMLExtension(t *testing.T) {
+	tests := []struct {
+		linkPath   string
+		wantPath   string
+		wantIsHTML bool
+	}{
+		{linkPath: "/folder/page", wantPath: "/folder/page", wantIsHTML: false},
+		{linkPath: "/folder/page.html", wantPath: "/folder/page", wantIsHTML: true},
+		{linkPath: "page.HTML", wantPath: "page", wantIsHTML: true},
+		{linkPath: "/folder/index.html", wantPath: "/folder/", wantIsHTML: true},
+		{linkPath: "index.html", wantPath: "./", wantIsHTML: true},
+		{linkPath: "/index.html", wantPath: "/", wantIsHTML: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.linkPath, func(t *testing.T) {
+			g := NewWithT(t)
+
+			gotPath, gotIsHTML := trimHTMLExtension(tt.linkPath)
+			g.Expect(gotPath).To(Equal(tt.wantPath))
+			g.Expect(gotIsHTML).To(Equal(tt.wantIsHTML))
+		})
+	}
+}
+
+func Test_readAllAndLinkcheckAll_uglyURLs(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	path := filepath.Join(root, "hugo", contentFolder, "en/_index.md")
+	write(g, path, `# Home
+
+See [page](/folder/page), [page with slash](folder/page/#section), [html page](/folder/page.html#section).
+See [folder](/folder/), [folder index](/folder/index.html), [html folder](/folder.html) or [missing](/missing.html).
+`)
+	write(g, filepath.Join(root, "hugo", contentFolder, "en/folder/_index.md"), "# Folder\n")
+	write(g, filepath.Join(root, "hugo", contentFolder, "en/folder/page.md"), "# Page\n\n## Section\n")
+
+	uglyURLsBefore := uglyURLs
+	defer func() { uglyURLs = uglyURLsBefore }()
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	tests := []struct {
+		name         string
+		uglyURLs     bool
+		wantWarnings []warning
+	}{
+		{
+			name:     "pretty urls",
+			uglyURLs: false,
+			wantWarnings: []warning{
+				{lineNumber: 3, rawLink: "/folder/page.html#section", message: "with pretty urls pages are served as folders, use /folder/page/"},
+				{lineNumber: 4, rawLink: "/folder.html", message: "sections are served as folders, use /folder/"},
+				{lineNumber: 4, rawLink: "/missing.html", message: "with pretty urls pages are served as folders, use /missing/"},
+			},
+		},
+		{
+			name:     "ugly urls",
+			uglyURLs: true,
+			wantWarnings: []warning{
+				{lineNumber: 3, rawLink: "/folder/page", message: "with uglyURLs pages are served as html files, use /folder/page.html"},
+				{lineNumber: 3, rawLink: "folder/page/#section", message: "with uglyURLs pages are served as html files, use folder/page.html"},
+				{lineNumber: 4, rawLink: "/folder.html", message: "sections are served as folders, use /folder/"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			pages = nil
+			pagesByPath = nil
+
+			uglyURLs = tt.uglyURLs
+			g.Expect(readAll()).To(Succeed())
+			g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+			g.Expect(pagesByPath[path].warnings).To(Equal(tt.wantWarnings))
+			g.Expect(collectErrors(root)).To(Equal([]string{
+				"/hugo/content/en/_index.md:4: /missing.html: the link resolves to /hugo/content/en/missing.md which does not exist",
+			}))
+		})
+	}
+}
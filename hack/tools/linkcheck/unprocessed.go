@@ -0,0 +1,69 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// unprocessedTargetError returns the error for a link resolving to a path that exists but has not been read
+// by linkcheck, explaining why the path has not been read and, when possible, how to check it.
+func unprocessedTargetError(targetPath string) *linkcheckError {
+	if !isInFolder(targetPath, *root) {
+		return newLinkcheckError(invalidLinkErrorCategory, "the link resolves to %s which is outside of the root folder %s", targetPath, *root)
+	}
+
+	relPath := strings.TrimPrefix(targetPath, *root)
+	if folder, ok := symlinkedFolder(targetPath); ok && !*followSymlinks {
+		return newLinkcheckError(invalidLinkErrorCategory, "the link resolves to %s which is inside the symlinked folder %s; use --follow-symlinks to check it", relPath, strings.TrimPrefix(folder, *root))
+	}
+	if isDir, _ := isDirectory(targetPath); isDir {
+		return newLinkcheckError(invalidLinkErrorCategory, "the link resolves to the folder %s which is not a page; link to a page in the folder instead", relPath)
+	}
+	if ext := filepath.Ext(targetPath); ext != ".md" {
+		if ext == "" {
+			return newLinkcheckError(invalidLinkErrorCategory, "the link resolves to %s which is neither a markdown page nor an asset", relPath)
+		}
+		return newLinkcheckError(invalidLinkErrorCategory, "the link resolves to %s which is neither a markdown page nor an asset; if it is an asset, add %s to --asset-extensions", relPath, ext)
+	}
+	return newLinkcheckError(invalidLinkErrorCategory, "the link resolves to %s which exists but has not been read by linkcheck", relPath)
+}
+
+// symlinkedFolder returns the first folder inside root which is a symlink among the folders containing a path, if any.
+func symlinkedFolder(path string) (string, bool) {
+	folders := []string{}
+	for dir := filepath.Dir(path); isInFolder(dir, *root) && dir != *root; dir = filepath.Dir(dir) {
+		folders = append([]string{dir}, folders...)
+	}
+	for _, folder := range folders {
+		entries, err := fs.ReadDir(fileSystem, fsPath(filepath.Dir(folder)))
+		if err != nil {
+			return "", false
+		}
+		for _, e := range entries {
+			if e.Name() == filepath.Base(folder) && e.Type()&fs.ModeSymlink != 0 {
+				return folder, true
+			}
+		}
+	}
+	return "", false
+}
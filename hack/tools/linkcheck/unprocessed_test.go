@@ -0,0 +1,124 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_readAllAndLinkcheckAll_unprocessedTargets(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	contentDir := filepath.Join(root, "hugo", contentFolder, "en")
+	write(g, filepath.Join(contentDir, "_index.md"), `# Home
+
+See the [install script](../../static/install.sh), the [notes](../../static/notes.txt), the [makefile](../../static/Makefile) or the [files](../../static/files).
+`)
+	staticDir := filepath.Join(root, "hugo", "static")
+	touch(g, filepath.Join(staticDir, "install.sh"))
+	touch(g, filepath.Join(staticDir, "notes.txt"))
+	touch(g, filepath.Join(staticDir, "Makefile"))
+	touch(g, filepath.Join(staticDir, "files/file.sh"))
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	// Files existing on disk but not read by linkcheck, because they are neither markdown pages nor assets,
+	// are reported explaining how to fix them.
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(pagesByPath).ToNot(HaveKey(filepath.Join(staticDir, "install.sh")))
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+	g.Expect(collectErrors(root)).To(Equal([]string{
+		"/hugo/content/en/_index.md:3: ../../static/Makefile: the link resolves to /hugo/static/Makefile which is neither a markdown page nor an asset",
+		"/hugo/content/en/_index.md:3: ../../static/files: the link resolves to the folder /hugo/static/files which is not a page; link to a page in the folder instead",
+		"/hugo/content/en/_index.md:3: ../../static/install.sh: the link resolves to /hugo/static/install.sh which is neither a markdown page nor an asset; if it is an asset, add .sh to --asset-extensions",
+	}))
+}
+
+func Test_unprocessedTargetError(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	submodule, err := os.MkdirTemp("", "submodule")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(submodule)
+
+	touch(g, filepath.Join(submodule, "page.md"))
+	touch(g, filepath.Join(root, "docs/page.md"))
+	g.Expect(os.Symlink(submodule, filepath.Join(root, "docs/provider"))).To(Succeed())
+
+	followSymlinksBefore := *followSymlinks
+	defer func() { *followSymlinks = followSymlinksBefore }()
+	*followSymlinks = false
+
+	tests := []struct {
+		name       string
+		targetPath string
+		want       string
+	}{
+		{
+			name:       "outside root",
+			targetPath: filepath.Join(submodule, "page.md"),
+			want:       "the link resolves to " + filepath.Join(submodule, "page.md") + " which is outside of the root folder " + root,
+		},
+		{
+			name:       "inside a symlinked folder",
+			targetPath: filepath.Join(root, "docs/provider/page.md"),
+			want:       "the link resolves to /docs/provider/page.md which is inside the symlinked folder /docs/provider; use --follow-symlinks to check it",
+		},
+		{
+			name:       "markdown page",
+			targetPath: filepath.Join(root, "docs/page.md"),
+			want:       "the link resolves to /docs/page.md which exists but has not been read by linkcheck",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			err := unprocessedTargetError(tt.targetPath)
+			g.Expect(err.category).To(Equal(invalidLinkErrorCategory))
+			g.Expect(err.Error()).To(Equal(tt.want))
+		})
+	}
+}
@@ -0,0 +1,102 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Search for version segments in the format v1.6 or v1.6.0.
+var versionSegmentRx = regexp.MustCompile(`^v\d+\.\d+(?:\.\d+)?$`)
+
+// parseVersions returns the hugo folder for each version defined by a list of version=folder values.
+func parseVersions(values []string) (map[string]string, error) {
+	versions := map[string]string{}
+	for _, v := range values {
+		version, folder, ok := strings.Cut(v, "=")
+		if !ok || folder == "" {
+			return nil, errors.Errorf("invalid version %q, must be in the version=folder form", v)
+		}
+		if !versionSegmentRx.MatchString(version) {
+			return nil, errors.Errorf("invalid version %q, must be in the v1.6 form", version)
+		}
+		versions[version] = folder
+	}
+	return versions, nil
+}
+
+// versionFolders defines the hugo folder for each version, as set by --versions.
+var versionFolders = map[string]string{}
+
+// loadVersions parses --versions, so errors are reported before reading pages.
+func loadVersions() error {
+	folders, err := parseVersions(*versions)
+	if err != nil {
+		return err
+	}
+	versionFolders = folders
+	return nil
+}
+
+// splitVersion splits an absolute site path starting with a version segment, e.g. /v1.6/tasks, into
+// the version and the path inside the versioned website.
+// NOTE: version segments are considered only when --versions is set.
+func splitVersion(path string) (version, rest string, ok bool) {
+	if len(versionFolders) == 0 {
+		return "", path, false
+	}
+	segments := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)
+	if !versionSegmentRx.MatchString(segments[0]) {
+		return "", path, false
+	}
+	rest = "/"
+	if len(segments) == 2 {
+		rest += segments[1]
+	}
+	return segments[0], rest, true
+}
+
+// versionFolder returns the hugo folder of a version.
+func versionFolder(version string) (string, bool) {
+	folder, ok := versionFolders[version]
+	return folder, ok
+}
+
+// crossVersionHint returns a hint for relative links leaving the version folder of the page, e.g. ../../v1.5/tasks
+// or ../../tasks from /v1.6/tasks/page.md, because they silently break when the versions are published separately.
+// NOTE: the version folder of the page is its first segment, e.g. /v1.6, also when --versions is not set.
+func crossVersionHint(p *page, linkPath, fragment string) string {
+	segments := strings.SplitN(strings.TrimPrefix(p.hugoPath, "/"), "/", 2)
+	if len(segments) < 2 || !versionSegmentRx.MatchString(segments[0]) {
+		return ""
+	}
+
+	versionDir := "/" + segments[0]
+	target := path.Join(path.Dir(p.hugoPath), linkPath)
+	if target == versionDir || strings.HasPrefix(target, versionDir+"/") {
+		return ""
+	}
+	return fmt.Sprintf("relative links must not leave the %s version folder, use an absolute link, e.g. %s%s", segments[0], target, fragment)
+}
@@ -0,0 +1,187 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_parseVersions(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:   "no values",
+			values: nil,
+			want:   map[string]string{},
+		},
+		{
+			name:   "valid values",
+			values: []string{"v1.5=docs/book-v1.5", "v1.6.0=docs/book-v1.6"},
+			want:   map[string]string{"v1.5": "docs/book-v1.5", "v1.6.0": "docs/book-v1.6"},
+		},
+		{
+			name:    "missing folder",
+			values:  []string{"v1.5"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid version",
+			values:  []string{"latest=docs/book"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := parseVersions(tt.values)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_readAllAndLinkcheckAll_versions(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	versionsBefore := *versions
+	versionFoldersBefore := versionFolders
+	defer func() {
+		*versions = versionsBefore
+		versionFolders = versionFoldersBefore
+	}()
+	*versions = []string{"v1.6=hugo-v1.6"}
+	g.Expect(loadVersions()).To(Succeed())
+
+	write(g, filepath.Join(root, "hugo", contentFolder, "en/_index.md"), `# Home
+
+See [upgrade](/v1.6/tasks/upgrade#upgrading), [v1.6](/v1.6/), [missing](/v1.6/tasks/missing) or [unknown](/v1.7/tasks/upgrade).
+`)
+	write(g, filepath.Join(root, "hugo-v1.6", contentFolder, "en/_index.md"), "# Home\n")
+	write(g, filepath.Join(root, "hugo-v1.6", contentFolder, "en/tasks/upgrade.md"), "# Upgrading\n")
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+
+	g.Expect(collectErrors(root)).To(Equal([]string{
+		"/hugo/content/en/_index.md:3: /v1.6/tasks/missing: the link resolves to /hugo-v1.6/content/en/tasks/missing.md which does not exist",
+		"/hugo/content/en/_index.md:3: /v1.7/tasks/upgrade: the link points to version v1.7 which is not one of the known versions",
+	}))
+
+	p := pagesByPath[filepath.Join(root, "hugo", contentFolder, "en/_index.md")]
+	g.Expect(p.links[3].fatalError.category).To(Equal(unknownVersionErrorCategory))
+}
+
+func Test_readAllAndLinkcheckAll_absoluteCrossVersionLinks(t *testing.T) {
+	g := NewWithT(t)
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	contentDir := filepath.Join(root, "hugo", contentFolder, "en")
+	path := filepath.Join(contentDir, "v1.6/tasks/upgrade.md")
+	write(g, path, `# Upgrading
+
+See [install](install), [home](../), [v1.5](../../v1.5/tasks/upgrade#upgrading) or [reference](../../reference).
+See [absolute v1.5](/v1.5/tasks/upgrade) or [absolute reference](/reference).
+`)
+	touch(g, filepath.Join(contentDir, "v1.6/_index.md"))
+	touch(g, filepath.Join(contentDir, "v1.6/tasks/install.md"))
+	write(g, filepath.Join(contentDir, "v1.5/tasks/upgrade.md"), "# Upgrading\n")
+	touch(g, filepath.Join(contentDir, "reference.md"))
+	write(g, filepath.Join(contentDir, "_index.md"), "# Home\n\nSee [v1.6](v1.6/tasks/upgrade).\n")
+
+	absCrossVersionBefore := *absCrossVersion
+	defer func() { *absCrossVersion = absCrossVersionBefore }()
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	tests := []struct {
+		name            string
+		absCrossVersion bool
+		wantWarnings    []warning
+	}{
+		{
+			name:            "relative links leaving the version folder are allowed",
+			absCrossVersion: false,
+			wantWarnings:    nil,
+		},
+		{
+			name:            "relative links leaving the version folder must be absolute",
+			absCrossVersion: true,
+			wantWarnings: []warning{
+				{lineNumber: 3, rawLink: "../../v1.5/tasks/upgrade#upgrading", message: "relative links must not leave the v1.6 version folder, use an absolute link, e.g. /v1.5/tasks/upgrade#upgrading"},
+				{lineNumber: 3, rawLink: "../../reference", message: "relative links must not leave the v1.6 version folder, use an absolute link, e.g. /reference"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			pages = nil
+			pagesByPath = nil
+
+			*absCrossVersion = tt.absCrossVersion
+			g.Expect(readAll()).To(Succeed())
+			g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+			g.Expect(pagesByPath[path].warnings).To(Equal(tt.wantWarnings))
+			g.Expect(pagesByPath[filepath.Join(contentDir, "_index.md")].warnings).To(BeEmpty())
+			g.Expect(collectErrors(root)).To(BeEmpty())
+		})
+	}
+}
@@ -0,0 +1,52 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Search for the language of code fences, e.g. yaml in ```yaml or ```{.yaml}, captures the language.
+var codeFenceLanguageRx = regexp.MustCompile("^\\s*(?:```|~~~)\\s*\\{?\\.?([\\w-]+)")
+
+// isYAMLCodeFence returns true if a line opens a yaml code fence, e.g. ```yaml or ```yml.
+func isYAMLCodeFence(line string) bool {
+	m := codeFenceLanguageRx.FindStringSubmatch(line)
+	return m != nil && (strings.EqualFold(m[1], "yaml") || strings.EqualFold(m[1], "yml"))
+}
+
+// readYAMLCommentURLs returns the http and https urls in the comment of a line of a yaml code fence, e.g.
+// "replicas: 3 # see https://cluster-api.sigs.k8s.io/tasks/scaling".
+// NOTE: a # starts a comment only at the beginning of the line or after a space, and outside quoted strings.
+func readYAMLCommentURLs(line string) []string {
+	inSingleQuote, inDoubleQuote := false, false
+	for i, c := range line {
+		switch {
+		case c == '\'' && !inDoubleQuote:
+			inSingleQuote = !inSingleQuote
+		case c == '"' && !inSingleQuote:
+			inDoubleQuote = !inDoubleQuote
+		case c == '#' && !inSingleQuote && !inDoubleQuote && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return readBareURLs(line[i+1:])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+//gox:build tools
+// +xbuild tools
+
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_isYAMLCodeFence(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{line: "```yaml", want: true},
+		{line: "```yml", want: true},
+		{line: "  ~~~ YAML", want: true},
+		{line: "```{.yaml}", want: true},
+		{line: "```yaml-template", want: false},
+		{line: "```bash", want: false},
+		{line: "```", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(isYAMLCodeFence(tt.line)).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_readYAMLCommentURLs(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want []string
+	}{
+		{
+			name: "comment line",
+			line: "# See https://cluster-api.sigs.k8s.io/tasks/scaling.",
+			want: []string{"https://cluster-api.sigs.k8s.io/tasks/scaling"},
+		},
+		{
+			name: "comment after a value",
+			line: "  replicas: 3 # see https://example.com/docs and http://example.com/more",
+			want: []string{"https://example.com/docs", "http://example.com/more"},
+		},
+		{
+			name: "url in a value",
+			line: "  url: https://example.com/docs",
+			want: nil,
+		},
+		{
+			name: "# in a quoted value",
+			line: `  description: "see # https://example.com/docs"`,
+			want: nil,
+		},
+		{
+			name: "# not preceded by a space",
+			line: "  image: registry.example.com/image#https://example.com/docs",
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(readYAMLCommentURLs(tt.line)).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_readAllAndLinkcheckAll_yamlComments(t *testing.T) {
+	g := NewWithT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/docs" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	root, err := os.MkdirTemp("", "linkcheck")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	cancel := setFlags(root, "hugo", []string{"en"})
+	defer cancel()
+
+	checkYAMLCommentsBefore, checkExternalBefore := *checkYAMLComments, *checkExternal
+	defer func() { *checkYAMLComments, *checkExternal = checkYAMLCommentsBefore, checkExternalBefore }()
+	*checkExternal = true
+
+	externalResults = map[string]*externalResult{}
+	defer func() { externalResults = map[string]*externalResult{} }()
+
+	path := filepath.Join(root, "hugo", contentFolder, "en/_index.md")
+	write(g, path, "# Home\n\n```yaml\n# See "+server.URL+"/docs\nkind: Cluster\nspec:\n  replicas: 3 # see "+server.URL+"/moved\n```\n\n```bash\n# See "+server.URL+"/bash\n```\n")
+
+	pages = nil
+	pagesByPath = nil
+	defer func() {
+		pages = nil
+		pagesByPath = nil
+	}()
+
+	// Without --check-yaml-comments, urls in yaml comments are not read.
+	*checkYAMLComments = false
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(pagesByPath[path].links).To(BeEmpty())
+
+	pages = nil
+	pagesByPath = nil
+
+	*checkYAMLComments = true
+	g.Expect(readAll()).To(Succeed())
+	g.Expect(linkcheckAll(context.Background(), io.Discard)).To(Succeed())
+	g.Expect(pagesByPath[path].links).To(HaveLen(2))
+	g.Expect(collectErrors(root)).To(Equal([]string{
+		"/hugo/content/en/_index.md:7: " + server.URL + "/moved: the link returned HTTP status 404",
+	}))
+}